@@ -14,6 +14,7 @@ import (
 	"github.com/AltSumpreme/Medistream.git/routes"
 	"github.com/AltSumpreme/Medistream.git/services/cache"
 	"github.com/AltSumpreme/Medistream.git/services/mail"
+	"github.com/AltSumpreme/Medistream.git/services/telehealth"
 	"github.com/AltSumpreme/Medistream.git/utils"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -59,6 +60,11 @@ func main() {
 		From:     os.Getenv("SMTP_FROM"),
 	})
 
+	// Initialize the video meeting provider
+	if err := telehealth.Init(); err != nil {
+		utils.Log.Warnf("Telehealth disabled: %v", err)
+	}
+
 	// Set Gin to release mode in production
 	if gin.Mode() != gin.DebugMode {
 		gin.SetMode(gin.ReleaseMode)
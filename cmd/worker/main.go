@@ -1,11 +1,15 @@
 package main
 
 import (
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/AltSumpreme/Medistream.git/config"
 	"github.com/AltSumpreme/Medistream.git/metrics"
 	"github.com/AltSumpreme/Medistream.git/queue"
+	"github.com/AltSumpreme/Medistream.git/services/mail"
+	"github.com/AltSumpreme/Medistream.git/services/telehealth"
 	"github.com/AltSumpreme/Medistream.git/utils"
 	"github.com/AltSumpreme/Medistream.git/workers"
 	"github.com/hibiken/asynq"
@@ -22,12 +26,35 @@ func main() {
 	defer config.CloseDB()
 	// Initialize Job Queue
 	config.InitAsynqQueue()
+	// Handlers enqueue follow-up tasks such as emails and reminders.
+	queue.Init()
+	defer queue.Close()
+
+	// Initialize SMTP Mailer
+	port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+	if err != nil {
+		utils.Log.Fatalf("Invalid SMTP_PORT: %v", err)
+	}
+	mail.InitMailer(mail.MailerConfig{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     port,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	})
+
+	// Meetings of no-show appointments are ended by the sweep.
+	if err := telehealth.Init(); err != nil {
+		utils.Log.Warnf("Telehealth disabled: %v", err)
+	}
+
 	srv := asynq.NewServer(
 		config.QueueRedisOpt,
 		asynq.Config{
 			Concurrency: 10,
 
 			Queues: map[string]int{
+				"emergencies":  10,
 				"appointments": 5,
 				"emails":       3,
 				//	"reports":      2,
@@ -50,8 +77,20 @@ func main() {
 
 	mux.HandleFunc(string(queue.JobTypeCreateAppointment), workers.ProcessCreateAppointmentTask)
 	workers.RegisterEmailHandlers(mux)
+	workers.RegisterWaitlistHandlers(mux)
+	workers.RegisterReminderHandlers(mux)
+	workers.RegisterNoShowHandlers(mux)
 	//muz.HandleFunc(string(queue.JobTypeGenerateReport),workers.ProcessReportTask);
 
+	scheduler, err := workers.NewNoShowScheduler()
+	if err != nil {
+		utils.Log.Fatalf("could not register periodic tasks: %v", err)
+	}
+	if err := scheduler.Start(); err != nil {
+		utils.Log.Fatalf("could not start scheduler: %v", err)
+	}
+	defer scheduler.Shutdown()
+
 	if err := srv.Run(mux); err != nil {
 		utils.Log.Fatalf("could not run asynq server: %v", err)
 	}
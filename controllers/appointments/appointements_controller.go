@@ -1,26 +1,40 @@
 package appointments
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/AltSumpreme/Medistream.git/config"
-	"github.com/AltSumpreme/Medistream.git/handlers"
 	"github.com/AltSumpreme/Medistream.git/metrics"
 	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/queue"
 	"github.com/AltSumpreme/Medistream.git/services/cache"
 	"github.com/AltSumpreme/Medistream.git/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
+// AppointmentStatusInput needs a reasonCode when cancelling. Reason is
+// required for the OTHER code.
 type AppointmentStatusInput struct {
-	Status string `json:"status" binding:"required,oneof=SCHEDULED CONFIRMED CANCELLED COMPLETED"`
+	Status     string `json:"status" binding:"required,oneof=CONFIRMED CHECKED_IN IN_CONSULTATION CANCELLED COMPLETED NO_SHOW"`
+	Reason     string `json:"reason" binding:"required_if=ReasonCode OTHER,max=500"`
+	ReasonCode string `json:"reasonCode" binding:"required_if=Status CANCELLED,omitempty,oneof=PATIENT_REQUEST PATIENT_ILLNESS SCHEDULE_CONFLICT DOCTOR_UNAVAILABLE CLINIC_CLOSURE DUPLICATE_BOOKING OTHER"`
+}
+
+// CancelAppointmentInput requires a reason code, and a written reason for
+// the OTHER code.
+type CancelAppointmentInput struct {
+	ReasonCode string `json:"reasonCode" binding:"required,oneof=PATIENT_REQUEST PATIENT_ILLNESS SCHEDULE_CONFLICT DOCTOR_UNAVAILABLE CLINIC_CLOSURE DUPLICATE_BOOKING OTHER"`
+	Reason     string `json:"reason" binding:"required_if=ReasonCode OTHER,max=500"`
 }
 type AppointmentUpdateInput struct {
 	StartTime string `json:"appointment_time" binding:"omitempty"`
@@ -30,50 +44,195 @@ type AppointmentUpdateInput struct {
 	Notes     string `json:"notes" binding:"omitempty"`
 }
 
-func CreateAppointment(data any) {
-	var input handlers.AppointmentInput
+// CreateAppointment books a queued booking request. Scheduling conflicts
+// reject the request and are not retried; other errors are returned so the
+// queue retries, and the request is marked failed after the last attempt.
+func CreateAppointment(ctx context.Context, requestID uuid.UUID) error {
+	var request models.BookingRequest
+	if err := config.DB.WithContext(ctx).First(&request, "id = ?", requestID).Error; err != nil {
+		utils.Log.Warnf("CreateAppointment: Booking request %s not found - %v", requestID, err)
+		return nil
+	}
+	if request.Status != models.BookingRequestQueued && request.Status != models.BookingRequestProcessing {
+		return nil
+	}
+	config.DB.WithContext(ctx).Model(&request).Update("status", models.BookingRequestProcessing)
+
+	// Triaged emergencies may overbook the doctor and are confirmed at once.
+	emergency := request.AppointmentType == models.ApptTypeEmergency && request.TriageLevel != nil
 
-	var patient models.Patient
-	err := metrics.DbMetrics(config.DB, "select_patient", func(db *gorm.DB) error {
-		return db.
-			Select("id").
-			Where("user_id = ?", input.UserID).
-			First(&patient).Error
+	var appointment models.Appointment
+	var scheduleErr error
+	err := metrics.DbMetrics(config.DB, "insert_appointment", func(db *gorm.DB) error {
+		return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if emergency {
+				scheduleErr = utils.ScheduleEmergency(tx, request.DoctorID, request.PatientID, request.AppointmentDate, request.StartTime, request.EndTime)
+			} else {
+				scheduleErr = utils.ScheduleAppointment(tx, request.DoctorID, request.PatientID, request.AppointmentDate, request.StartTime, request.EndTime, request.AppointmentType, nil)
+			}
+			if scheduleErr != nil {
+				if utils.IsScheduleConflict(scheduleErr) {
+					return nil
+				}
+				return scheduleErr
+			}
+
+			appointment = models.Appointment{
+				PatientID:       request.PatientID,
+				DoctorID:        request.DoctorID,
+				AppointmentDate: request.AppointmentDate,
+				Status:          models.AppointmentStatusPending,
+				StartTime:       request.StartTime,
+				EndTime:         request.EndTime,
+				Mode:            request.Mode,
+				AppointmentType: request.AppointmentType,
+				TriageLevel:     request.TriageLevel,
+				Notes:           request.Notes,
+				BookedBy:        &request.UserID,
+			}
+			if scheduleErr = utils.AssignRoom(tx, &appointment); scheduleErr != nil {
+				if !utils.IsScheduleConflict(scheduleErr) {
+					return scheduleErr
+				}
+				if !emergency {
+					return nil
+				}
+				// An emergency with every room taken is still seen; the
+				// desk finds it a room on arrival.
+				scheduleErr = nil
+			}
+			if err := tx.Create(&appointment).Error; err != nil {
+				return utils.BookingError(err)
+			}
+			if emergency {
+				if err := utils.TransitionAppointment(tx, &appointment, models.AppointmentStatusConfirmed, utils.StatusActor{UserID: &request.UserID}, "Emergency booking"); err != nil {
+					return err
+				}
+			}
+			return tx.Model(&request).Updates(map[string]interface{}{
+				"status":         models.BookingRequestBooked,
+				"appointment_id": appointment.ID,
+				"processed_at":   time.Now(),
+			}).Error
+		})
 	})
+	// Another booking can still win the slot between the check and the
+	// insert; the database constraint reports that as a conflict.
+	if utils.IsScheduleConflict(err) {
+		scheduleErr, err = err, nil
+	}
+	if err != nil {
+		utils.Log.Errorf("CreateAppointment: Database error - %v", err)
+		retried, _ := asynq.GetRetryCount(ctx)
+		maxRetry, ok := asynq.GetMaxRetry(ctx)
+		if !ok || retried >= maxRetry {
+			settleBookingRequest(ctx, &request, models.BookingRequestFailed, "The booking could not be processed, please try again")
+		}
+		return err
+	}
+
+	if scheduleErr != nil {
+		utils.Log.Warnf("CreateAppointment: Scheduling error - %v", scheduleErr)
+		settleBookingRequest(ctx, &request, models.BookingRequestRejected, scheduleErr.Error())
+		return nil
+	}
+
+	request.Status = models.BookingRequestBooked
+	request.AppointmentID = &appointment.ID
+	notifyBookingOutcome(request)
+	if emergency {
+		syncReminders(ctx, appointment)
+		syncMeeting(ctx, appointment)
+		notifyEmergencyDelays(ctx, appointment.ID)
+	}
+	utils.Log.Infof("CreateAppointment: Appointment created successfully with ID %s", appointment.ID)
+	return nil
+}
+
+func settleBookingRequest(ctx context.Context, request *models.BookingRequest, status models.BookingRequestStatus, reason string) {
+	err := config.DB.WithContext(ctx).Model(request).Updates(map[string]interface{}{
+		"status":       status,
+		"reason":       reason,
+		"processed_at": time.Now(),
+	}).Error
 	if err != nil {
-		utils.Log.Warnf("CreateAppointment: Failed to get patient ID - %v", err)
+		utils.Log.Errorf("CreateAppointment: Failed to update booking request %s - %v", request.ID, err)
 		return
 	}
-	patientID := patient.ID
+	request.Status = status
+	request.Reason = reason
+	notifyBookingOutcome(*request)
+}
 
-	appointment := models.Appointment{
-		PatientID:       patientID,
-		DoctorID:        input.DoctorID,
-		AppointmentDate: input.AppointmentDate,
-		Status:          models.AppointmentStatusPending,
-		StartTime:       input.StartTime,
-		EndTime:         input.EndTime,
-		Mode:            input.Mode,
-		AppointmentType: models.ApptType(input.AppointmentType),
-		Notes:           input.Notes,
+// notifyBookingOutcome emails the patient the result of a booking request
+// when they asked for it.
+func notifyBookingOutcome(request models.BookingRequest) {
+	if !request.NotifyByEmail || queue.Client == nil {
+		return
+	}
+	contact, err := utils.GetPatientContact(config.DB, request.PatientID)
+	if err != nil || contact.Email == "" {
+		utils.Log.Warnf("notifyBookingOutcome: No email for patient %s - %v", request.PatientID, err)
+		return
 	}
-	scheduleErr := utils.ScheduleAppointment(config.DB, input.DoctorID, patientID, input.AppointmentDate, input.StartTime, input.EndTime, nil)
 
-	if scheduleErr != nil {
-		utils.Log.Warnf("CreateAppointment: Scheduling error - %v", scheduleErr)
+	booked := request.Status == models.BookingRequestBooked
+	tmpl := utils.GetBookingOutcomeTemplate(booked, request.AppointmentDate.Format("2006-01-02"), request.StartTime, request.Reason)
+
+	var attachments []queue.EmailAttachment
+	if booked && request.AppointmentID != nil {
+		if _, _, invite, err := calendarInvite(*request.AppointmentID, utils.ICalMethodRequest); err == nil {
+			attachments = append(attachments, invite)
+		} else {
+			utils.Log.Warnf("notifyBookingOutcome: Failed to build calendar invite - %v", err)
+		}
+	}
+
+	task, err := queue.NewEmailTask(contact.Email, tmpl.Subject, tmpl.Body, attachments...)
+	if err != nil {
+		utils.Log.Errorf("notifyBookingOutcome: Failed to create email task - %v", err)
 		return
 	}
+	if _, err := queue.Client.Enqueue(task, asynq.Queue("emails"), asynq.MaxRetry(3)); err != nil {
+		utils.Log.Errorf("notifyBookingOutcome: Failed to enqueue email - %v", err)
+	}
+}
+
+func GetBookingRequest(c *gin.Context) {
+	user, _ := utils.GetCurrentUser(c)
 
-	err = metrics.DbMetrics(config.DB, "insert_appointment", func(db *gorm.DB) error { return db.Create(&appointment).Error })
+	var request models.BookingRequest
+	err := metrics.DbMetrics(config.DB, "get_booking_request", func(db *gorm.DB) error {
+		return db.WithContext(c.Request.Context()).First(&request, "id = ?", c.Param("id")).Error
+	})
 	if err != nil {
-		utils.Log.Errorf("CreateAppointment: Database error - %v", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Booking request not found"})
 		return
 	}
-	utils.Log.Infof("CreateAppointment: Appointment created successfully with ID %s", appointment.ID)
+	if models.Role(user.Role) != models.RoleAdmin && request.UserID != user.UserID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"request": request})
+}
 
+// callerLocation reads the zone appointment times are rendered in and
+// answers 400 when it is not a valid IANA name.
+func callerLocation(c *gin.Context) (*time.Location, bool) {
+	loc, err := utils.CallerLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return loc, true
 }
 
 func GetAllAppointments(c *gin.Context) {
+	loc, ok := callerLocation(c)
+	if !ok {
+		return
+	}
 	limit := 10
 	page := 1
 	Maxlimit := 100
@@ -95,6 +254,8 @@ func GetAllAppointments(c *gin.Context) {
 		return db.WithContext(c.Request.Context()).
 			Preload("Patient").
 			Preload("Doctor").
+			Preload("Clinic").
+			Preload("Room").
 			Limit(limit).
 			Offset((page - 1) * limit).
 			Find(&appointments).Error
@@ -106,6 +267,7 @@ func GetAllAppointments(c *gin.Context) {
 	}
 
 	utils.Log.Infof("GetAppointments: Retrieved %d appointments for page %d with limit %d", len(appointments), page, limit)
+	utils.LocalizeAppointments(appointments, loc)
 	c.JSON(200, gin.H{"appointments": appointments, "page": page, "limit": limit, "total": len(appointments)})
 }
 
@@ -114,6 +276,10 @@ func GetAppointmentByID(c *gin.Context) {
 	appointmentID := c.Param("id")
 	user, _ := utils.GetCurrentUser(c)
 	var appointment models.Appointment
+	loc, ok := callerLocation(c)
+	if !ok {
+		return
+	}
 
 	// cache
 	cachekey := fmt.Sprintf("cache:appointment:%s", appointmentID)
@@ -123,6 +289,11 @@ func GetAppointmentByID(c *gin.Context) {
 		var appointment models.Appointment
 		metrics.CacheHits.WithLabelValues("appointment_by_id").Inc()
 		if jsonErr := json.Unmarshal([]byte(val), &appointment); jsonErr == nil {
+			if !canManageAppointment(c, user, appointment) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+				return
+			}
+			utils.LocalizeAppointment(&appointment, loc)
 			c.JSON(http.StatusOK, gin.H{"appointment": appointment})
 			return
 		}
@@ -136,14 +307,14 @@ func GetAppointmentByID(c *gin.Context) {
 	}
 
 	err = metrics.DbMetrics(config.DB, "get_appointment_by_appt_id", func(db *gorm.DB) error {
-		return db.WithContext(c.Request.Context()).Preload("Patient").Preload("Doctor").Where("id = ?", appointmentID).First(&appointment).Error
+		return db.WithContext(c.Request.Context()).Preload("Patient").Preload("Doctor").Preload("Clinic").Preload("Room").Where("id = ?", appointmentID).First(&appointment).Error
 	})
 	if err != nil {
 		utils.Log.Errorf("GetAppointmentByID: Appointment not found - %v", err)
 		c.JSON(404, gin.H{"error": "Appointment not found - " + err.Error()})
 		return
 	}
-	if models.Role(user.Role) != models.RoleAdmin && (appointment.PatientID != user.UserID || appointment.DoctorID != user.UserID) {
+	if !canManageAppointment(c, user, appointment) {
 		utils.Log.Warnf("GetAppointmentByID: You are not authorised to access this appointment")
 		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 		return
@@ -152,6 +323,7 @@ func GetAppointmentByID(c *gin.Context) {
 	data, _ := json.Marshal(appointment)
 	config.Rdb.Set(config.Ctx, cachekey, data, 5*time.Minute)
 
+	utils.LocalizeAppointment(&appointment, loc)
 	c.JSON(200, gin.H{"appointment": appointment})
 }
 func GetAppointmentByDoctorID(c *gin.Context) {
@@ -163,6 +335,11 @@ func GetAppointmentByDoctorID(c *gin.Context) {
 		return
 	}
 
+	loc, ok := callerLocation(c)
+	if !ok {
+		return
+	}
+
 	if models.Role(user.Role) == models.RoleDoctor && user.UserID.String() != doctorID {
 		utils.Log.Warnf("GetAppointmentByDoctorID: Doctor %s attempted to access data of doctor %s", user.UserID, doctorID)
 		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
@@ -193,6 +370,7 @@ func GetAppointmentByDoctorID(c *gin.Context) {
 	switch err {
 	case nil:
 		if jsonErr := json.Unmarshal([]byte(val), &appointments); jsonErr == nil {
+			utils.LocalizeAppointments(appointments, loc)
 			c.JSON(http.StatusOK, gin.H{"appointments": appointments})
 			return
 		}
@@ -205,6 +383,8 @@ func GetAppointmentByDoctorID(c *gin.Context) {
 	}
 
 	if err := config.DB.WithContext(c.Request.Context()).
+		Preload("Clinic").
+		Preload("Room").
 		Where("doctor_id = ?", doctorID).
 		Order("appointment_date desc").
 		Limit(limit).
@@ -217,6 +397,7 @@ func GetAppointmentByDoctorID(c *gin.Context) {
 	data, _ := json.Marshal(appointments)
 	config.Rdb.Set(config.Ctx, cachekey, data, 5*time.Minute).Result()
 
+	utils.LocalizeAppointments(appointments, loc)
 	c.JSON(http.StatusOK, gin.H{"appointments": appointments})
 }
 
@@ -229,6 +410,10 @@ func GetAppointmentByPatientID(c *gin.Context) {
 		return
 	}
 	patientID := c.Param("id")
+	loc, ok := callerLocation(c)
+	if !ok {
+		return
+	}
 
 	if models.Role(user.Role) == models.RolePatient && user.UserID.String() != patientID {
 		utils.Log.Warnf("GetAppointmentByPatientID: Access denied for patient %s to data of %s", user.UserID, patientID)
@@ -262,6 +447,7 @@ func GetAppointmentByPatientID(c *gin.Context) {
 	case nil:
 		metrics.CacheHits.WithLabelValues("get_appointments_by_patient").Inc()
 		if jsonErr := json.Unmarshal([]byte(val), &appointments); jsonErr == nil {
+			utils.LocalizeAppointments(appointments, loc)
 			c.JSON(http.StatusOK, gin.H{"appointments:": appointments})
 			return
 		}
@@ -271,7 +457,7 @@ func GetAppointmentByPatientID(c *gin.Context) {
 		metrics.CacheMisses.WithLabelValues("get_appointments_by_patient").Inc()
 		utils.Log.Warnf("GetAppointmentByID: Redis error - %v", err)
 	}
-	db := config.DB.WithContext(c.Request.Context()).Preload("Patient").
+	db := config.DB.WithContext(c.Request.Context()).Preload("Patient").Preload("Clinic").Preload("Room").
 		Where("patient_id = ?", patientID).
 		Order("appointment_date desc").
 		Limit(limit).
@@ -290,12 +476,14 @@ func GetAppointmentByPatientID(c *gin.Context) {
 	data, _ := json.Marshal(appointments)
 	config.Rdb.Set(config.Ctx, cachekey, data, 5*time.Minute)
 
+	utils.LocalizeAppointments(appointments, loc)
 	c.JSON(http.StatusOK, gin.H{"appointments": appointments})
 }
 
 func GetAvailableSlots(c *gin.Context) {
 	doctorIDParam := c.Query("doctorId")
 	dateParam := c.Query("date")
+	apptType := models.ApptType(c.DefaultQuery("type", string(models.ApptTypeConsultation)))
 
 	if doctorIDParam == "" || dateParam == "" {
 		utils.Log.Warnf("GetAvailableSlots: Missing required query parameters")
@@ -317,15 +505,30 @@ func GetAvailableSlots(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
 		return
 	}
-	cacheKey := fmt.Sprintf("cache:doctorSchedule:%s:%s", doctorID.String(), appointmentDate.Format("2006-01-02"))
+
+	if !utils.IsValidApptType(apptType) {
+		utils.Log.Warnf("GetAvailableSlots: Invalid appointment type - %s", apptType)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type. Use CONSULTATION, FOLLOWUP, CHECKUP or EMERGENCY"})
+		return
+	}
+
+	// date is the day on the doctor's calendar; starts_at and ends_at are
+	// rendered in the caller's zone.
+	callerLoc, ok := callerLocation(c)
+	if !ok {
+		return
+	}
+
+	cacheKey := fmt.Sprintf("cache:doctorSchedule:%s:%s:%s", doctorID.String(), appointmentDate.Format("2006-01-02"), apptType)
 	val, err := config.Rdb.Get(config.Ctx, cacheKey).Result()
 
 	switch err {
 	case nil:
-		var slots []string
+		var slots []utils.Slot
 		if jsonErr := json.Unmarshal([]byte(val), &slots); jsonErr == nil {
 			metrics.CacheHits.WithLabelValues("get_available_slots").Inc()
-			c.JSON(http.StatusOK, gin.H{"availableSlots": slots})
+			utils.LocalizeSlots(slots, callerLoc)
+			c.JSON(http.StatusOK, gin.H{"availableSlots": slots, "type": apptType, "timeZone": callerLoc.String()})
 			return
 		}
 	case redis.Nil:
@@ -335,7 +538,12 @@ func GetAvailableSlots(c *gin.Context) {
 		utils.Log.Warnf("GetAvailableSlots: Redis error - %v", err)
 	}
 
-	slots, err := utils.GetAvailableSlots(config.DB, doctorID, appointmentDate)
+	var slots []utils.Slot
+	err = metrics.DbMetrics(config.DB, "get_available_slots", func(db *gorm.DB) error {
+		var slotErr error
+		slots, slotErr = utils.GetAvailableSlots(db.WithContext(c.Request.Context()), doctorID, appointmentDate, apptType)
+		return slotErr
+	})
 	if err != nil {
 		utils.Log.Errorf("GetAvailableSlots: Failed to retrieve slots - %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve available slots"})
@@ -345,7 +553,8 @@ func GetAvailableSlots(c *gin.Context) {
 	data, _ := json.Marshal(slots)
 	config.Rdb.Set(config.Ctx, cacheKey, data, 5*time.Minute)
 
-	c.JSON(http.StatusOK, gin.H{"availableSlots": slots})
+	utils.LocalizeSlots(slots, callerLoc)
+	c.JSON(http.StatusOK, gin.H{"availableSlots": slots, "type": apptType, "timeZone": callerLoc.String()})
 }
 
 func UpdateAppointment(c *gin.Context, appointmentCache *cache.Cache) {
@@ -366,28 +575,16 @@ func UpdateAppointment(c *gin.Context, appointmentCache *cache.Cache) {
 		return
 	}
 
-	// Role-based checks
-	switch models.Role(user.Role) {
-	case models.RolePatient:
-		if user.UserID != appt.PatientID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to update this appointment"})
-			return
-		}
-		if appt.Status == "ACCEPTED" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "You cannot update an accepted appointment"})
-			return
-		}
-
-	case models.RoleDoctor:
-		if user.UserID != appt.DoctorID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to update this appointment"})
-			return
-		}
-
-	case models.RoleAdmin:
-		// Admin can always update
-	default:
-		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid role"})
+	if !canManageAppointment(c, user, appt) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to update this appointment"})
+		return
+	}
+	if utils.IsTerminalStatus(appt.Status) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A " + string(appt.Status) + " appointment can no longer be updated"})
+		return
+	}
+	if models.Role(user.Role) == models.RolePatient && appt.Status != models.AppointmentStatusPending {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot update a confirmed appointment"})
 		return
 	}
 
@@ -410,6 +607,7 @@ func UpdateAppointment(c *gin.Context, appointmentCache *cache.Cache) {
 			appt.AppointmentDate,
 			input.StartTime,
 			input.EndTime,
+			appt.AppointmentType,
 			&appt.ID); err != nil {
 			utils.Log.Warnf("UpdateAppointment: Conflict in scheduling the appointment")
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -417,6 +615,11 @@ func UpdateAppointment(c *gin.Context, appointmentCache *cache.Cache) {
 		}
 	}
 
+	calendarChanged := (input.StartTime != "" && input.StartTime != appt.StartTime) ||
+		(input.EndTime != "" && input.EndTime != appt.EndTime) ||
+		(input.Mode != "" && input.Mode != appt.Mode) ||
+		(input.Location != "" && input.Location != appt.Location)
+
 	if input.StartTime != "" {
 		appt.StartTime = input.StartTime
 	}
@@ -429,11 +632,27 @@ func UpdateAppointment(c *gin.Context, appointmentCache *cache.Cache) {
 	if input.Notes != "" {
 		appt.Notes = input.Notes
 	}
+	if input.StartTime != "" || input.EndTime != "" || input.Mode != "" {
+		if err := utils.AssignRoom(config.DB.WithContext(c.Request.Context()), &appt); err != nil {
+			if utils.IsScheduleConflict(err) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			utils.Log.Errorf("UpdateAppointment: Failed to assign a room - %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update appointment"})
+			return
+		}
+	}
 	if input.Location != "" {
 		appt.Location = input.Location
 	}
+	appt.UpdatedBy = &user.UserID
 
-	err = metrics.DbMetrics(config.DB, "update_appointment", func(db *gorm.DB) error { return db.Save(&appt).Error })
+	err = metrics.DbMetrics(config.DB, "update_appointment", func(db *gorm.DB) error { return utils.BookingError(db.Save(&appt).Error) })
+	if utils.IsScheduleConflict(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		utils.Log.Errorf("UpdateAppointment: Failed to update appointment - %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update appointment"})
@@ -441,6 +660,11 @@ func UpdateAppointment(c *gin.Context, appointmentCache *cache.Cache) {
 	}
 
 	appointmentCache.AppointmentInvalidate(appointmentID, appt.DoctorID.String(), appt.PatientID.String(), appt.AppointmentDate.Format("2006-01-02"))
+	if appt.Status == models.AppointmentStatusConfirmed && calendarChanged {
+		sendCalendarInvite(appt.ID, utils.ICalMethodRequest)
+		syncReminders(c.Request.Context(), appt)
+		syncMeeting(c.Request.Context(), appt)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Appointment updated successfully", "appointment": appt})
 }
@@ -453,6 +677,11 @@ func DeleteAppointment(c *gin.Context, appointmentCache *cache.Cache) {
 		c.JSON(404, gin.H{"error": "Appointment not found - " + err.Error()})
 		return
 	}
+	// Reminder and meeting rows go with the appointment, so their tasks and
+	// meetings are removed first.
+	CancelReminders(c.Request.Context(), appointment.ID)
+	EndMeeting(c.Request.Context(), appointment.ID)
+
 	err := metrics.DbMetrics(config.DB, "delete_appointment", func(db *gorm.DB) error { return db.WithContext(c.Request.Context()).Delete(&appointment).Error })
 	if err != nil {
 		utils.Log.Errorf("DeleteAppointment: Failed to delete appointment - %v", err)
@@ -465,35 +694,124 @@ func DeleteAppointment(c *gin.Context, appointmentCache *cache.Cache) {
 	c.JSON(200, gin.H{"message": "Appointment deleted successfully"})
 }
 
-func ChangeAppointmentStatus(c *gin.Context) {
+func ChangeAppointmentStatus(c *gin.Context, appointmentCache *cache.Cache) {
 	appointmentID := c.Param("id")
 
-	_, err := utils.GetCurrentUser(c)
-
+	user, err := utils.GetCurrentUser(c)
 	if err != nil {
-		c.JSON(403, gin.H{"error": "Unauthorized: " + err.Error()})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: " + err.Error()})
+		return
 	}
 
 	var appointment models.Appointment
-
 	if err := config.DB.WithContext(c.Request.Context()).First(&appointment, "id = ?", appointmentID).Error; err != nil {
 		utils.Log.Errorf("ChangeAppointmentStatus: Appointment not found - %v", err)
-		c.JSON(404, gin.H{"error": "Appointment not found - " + err.Error()})
+		c.JSON(http.StatusNotFound, gin.H{"error": "Appointment not found"})
+		return
+	}
+	if !canManageAppointment(c, user, appointment) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 		return
 	}
 
 	var input AppointmentStatusInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		utils.Log.Warnf("ChangeAppointmentStatus: Invalid input - %v", err)
-		c.JSON(400, gin.H{"error": "Invalid input"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
 		return
 	}
-	appointment.Status = models.AppointmentStatus(input.Status)
-	if err := config.DB.WithContext(c.Request.Context()).Save(&appointment).Error; err != nil {
-		utils.Log.Errorf("ChangeAppointmentStatus: Failed to update appointment status - %v", err)
-		c.JSON(500, gin.H{"error": "Failed to update appointment status - " + err.Error()})
+
+	actor := utils.ActorFromClaims(user)
+	code := models.CancellationReason(input.ReasonCode)
+	err = metrics.DbMetrics(config.DB, "change_appointment_status", func(db *gorm.DB) error {
+		return db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			if input.Status == string(models.AppointmentStatusCancelled) {
+				return cancelAppointment(tx, &appointment, actor, code, input.Reason, false)
+			}
+			return utils.TransitionAppointment(tx, &appointment, models.AppointmentStatus(input.Status), actor, input.Reason)
+		})
+	})
+	if err != nil {
+		utils.Log.Warnf("ChangeAppointmentStatus: %v", err)
+		respondTransitionError(c, err)
 		return
 	}
+
+	appointmentCache.AppointmentInvalidate(appointmentID, appointment.DoctorID.String(), appointment.PatientID.String(), appointment.AppointmentDate.Format("2006-01-02"))
+	syncReminders(c.Request.Context(), appointment)
+	syncMeeting(c.Request.Context(), appointment)
+	response := gin.H{"message": "Appointment status updated", "appointment": appointment}
+	switch appointment.Status {
+	case models.AppointmentStatusConfirmed:
+		sendCalendarInvite(appointment.ID, utils.ICalMethodRequest)
+	case models.AppointmentStatusCancelled:
+		sendCalendarInvite(appointment.ID, utils.ICalMethodCancel)
+		notifyWaitlist(appointment)
+		if suggestions := followUpCancellation(c.Request.Context(), appointment, actor, code, input.Reason); suggestions != nil {
+			response["suggestedSlots"] = suggestions
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func GetAppointmentStatusHistory(c *gin.Context) {
+	user, _ := utils.GetCurrentUser(c)
+
+	var appointment models.Appointment
+	if err := config.DB.WithContext(c.Request.Context()).First(&appointment, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Appointment not found"})
+		return
+	}
+	if !canManageAppointment(c, user, appointment) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	var history []models.AppointmentStatusHistory
+	err := metrics.DbMetrics(config.DB, "get_appointment_status_history", func(db *gorm.DB) error {
+		return db.WithContext(c.Request.Context()).
+			Where("appointment_id = ?", appointment.ID).
+			Order("created_at asc").
+			Find(&history).Error
+	})
+	if err != nil {
+		utils.Log.Errorf("GetAppointmentStatusHistory: Failed to fetch history - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch status history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": appointment.Status, "history": history})
+}
+
+// canManageAppointment reports whether the caller is the appointment's
+// patient, its doctor, or an admin.
+func canManageAppointment(c *gin.Context, user *utils.JWTClaims, appt models.Appointment) bool {
+	switch models.Role(user.Role) {
+	case models.RoleAdmin:
+		return true
+	case models.RolePatient:
+		patient, err := utils.GetPatientByUserID(user.UserID, c)
+		return err == nil && patient.ID == appt.PatientID
+	case models.RoleDoctor:
+		doctor, err := utils.GetDoctorByUserID(user.UserID, c)
+		return err == nil && doctor.ID == appt.DoctorID
+	case models.RoleReceptionist:
+		receptionist, err := utils.GetReceptionistByUserID(user.UserID, c)
+		return err == nil && utils.ReceptionistCovers(receptionist, appt)
+	}
+	return false
+}
+
+func respondTransitionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, utils.ErrTransitionNotAllowed):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, utils.ErrInvalidTransition):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update appointment status"})
+	}
 }
 
 func RescheduleAppointment(c *gin.Context, appointmentCache *cache.Cache) {
@@ -506,54 +824,85 @@ func RescheduleAppointment(c *gin.Context, appointmentCache *cache.Cache) {
 		return
 	}
 
-	if models.Role(user.Role) != models.RoleAdmin && (user.UserID != appointment.PatientID || user.UserID != appointment.DoctorID) {
+	if !canManageAppointment(c, user, appointment) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "You can only reschedule your own appointment"})
 		return
 	}
 
-	if appointment.Status != "PENDING" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only PENDING appointments can be rescheduled"})
+	if appointment.Status != models.AppointmentStatusPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only PENDING appointments can be rescheduled"})
 		return
 	}
 
+	// Either date with wall-clock times on the doctor's calendar, or absolute
+	// starts_at and ends_at instants.
 	var input struct {
-		Date      time.Time `json:"date" binding:"required"`
-		StartTime string    `json:"start_time" binding:"required"`
-		EndTime   string    `json:"end_time" binding:"required"`
-		Mode      string    `json:"mode" binding:"required,oneof=Online In-Person"`
+		Date      time.Time  `json:"date" binding:"required_without=StartsAt"`
+		StartTime string     `json:"start_time" binding:"required_without=StartsAt"`
+		EndTime   string     `json:"end_time" binding:"required_without=StartsAt"`
+		StartsAt  *time.Time `json:"starts_at"`
+		EndsAt    *time.Time `json:"ends_at" binding:"required_with=StartsAt"`
+		Mode      string     `json:"mode" binding:"required,oneof=Online In-Person"`
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 		return
 	}
 
-	appointment.AppointmentDate = input.Date
-	appointment.StartTime = input.StartTime
-	appointment.EndTime = input.EndTime
+	date, start, end, err := utils.ResolveWallClock(config.DB, appointment.DoctorID, input.Date, input.StartTime, input.EndTime, input.StartsAt, input.EndsAt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	appointment.AppointmentDate = date
+	appointment.StartTime = start
+	appointment.EndTime = end
 	appointment.Mode = input.Mode
+	appointment.UpdatedBy = &user.UserID
 
-	if err := utils.ScheduleAppointment(config.DB, appointment.DoctorID, appointment.PatientID, appointment.AppointmentDate, appointment.StartTime, appointment.EndTime, &appointment.ID); err != nil {
+	if err := utils.ScheduleAppointment(config.DB, appointment.DoctorID, appointment.PatientID, appointment.AppointmentDate, appointment.StartTime, appointment.EndTime, appointment.AppointmentType, &appointment.ID); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := utils.AssignRoom(config.DB, &appointment); err != nil {
+		if utils.IsScheduleConflict(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reschedule"})
+		return
+	}
 
-	err := metrics.DbMetrics(config.DB, "Reschedule_appointment", func(db *gorm.DB) error { return db.Save(&appointment).Error })
+	err = metrics.DbMetrics(config.DB, "Reschedule_appointment", func(db *gorm.DB) error { return utils.BookingError(db.Save(&appointment).Error) })
+	if utils.IsScheduleConflict(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reschedule"})
 		return
 	}
 
 	appointmentCache.AppointmentInvalidate(appointmentID, appointment.DoctorID.String(), appointment.PatientID.String(), appointment.AppointmentDate.Format("2006-01-02"))
+	sendCalendarInvite(appointment.ID, utils.ICalMethodRequest)
+	syncReminders(c.Request.Context(), appointment)
+	syncMeeting(c.Request.Context(), appointment)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Appointment rescheduled", "appointment": appointment})
 }
 
-func CancelAppointment(c *gin.Context) {
+// CancelAppointment cancels an appointment with a reason code. Patients
+// cancelling inside the clinic's late-cancellation window are flagged or
+// blocked. The other party is emailed, and after a cancellation from the
+// doctor's side the patient is offered other slots.
+func CancelAppointment(c *gin.Context, appointmentCache *cache.Cache) {
 	appointmentID := c.Param("id")
 	user, exists := utils.GetCurrentUser(c)
 	if exists != nil {
 		utils.Log.Warnf("CancelAppointment:Unauthorised access to the route")
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "You have not been authenticated"})
+		return
 	}
 
 	var appointment models.Appointment
@@ -562,18 +911,78 @@ func CancelAppointment(c *gin.Context) {
 		return
 	}
 
-	allowed := models.Role(user.Role) == models.RoleAdmin || user.UserID == appointment.PatientID
-	if !allowed {
+	if !canManageAppointment(c, user, appointment) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized"})
 		return
 	}
 
-	appointment.Status = "CANCELLED"
-	err := metrics.DbMetrics(config.DB, "cancel_appointment", func(db *gorm.DB) error { return db.Save(&appointment).Error })
+	var input CancelAppointmentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	// Patients cancelling at short notice are flagged, or sent to the front
+	// desk when the clinic blocks late cancellations.
+	settings, err := utils.GetClinicSettings(config.DB.WithContext(c.Request.Context()))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel"})
+		utils.Log.Errorf("CancelAppointment: Failed to load clinic settings - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel appointment"})
+		return
+	}
+	late := models.Role(user.Role) == models.RolePatient && utils.IsLateCancellation(appointment, settings, time.Now())
+	if late && settings.LateCancelAction == models.LateCancelBlock {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":                   "This appointment is too close to its start to cancel online; please contact the clinic",
+			"lateCancelWindowMinutes": settings.LateCancelWindowMinutes,
+		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Appointment cancelled"})
+	actor := utils.ActorFromClaims(user)
+	code := models.CancellationReason(input.ReasonCode)
+	err = metrics.DbMetrics(config.DB, "cancel_appointment", func(db *gorm.DB) error {
+		return db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			return cancelAppointment(tx, &appointment, actor, code, input.Reason, late)
+		})
+	})
+	if err != nil {
+		utils.Log.Warnf("CancelAppointment: %v", err)
+		respondTransitionError(c, err)
+		return
+	}
+
+	appointmentCache.AppointmentInvalidate(appointmentID, appointment.DoctorID.String(), appointment.PatientID.String(), appointment.AppointmentDate.Format("2006-01-02"))
+	sendCalendarInvite(appointment.ID, utils.ICalMethodCancel)
+	CancelReminders(c.Request.Context(), appointment.ID)
+	EndMeeting(c.Request.Context(), appointment.ID)
+	notifyWaitlist(appointment)
+	suggestions := followUpCancellation(c.Request.Context(), appointment, actor, code, input.Reason)
+
+	response := gin.H{"message": "Appointment cancelled", "lateCancellation": late}
+	if suggestions != nil {
+		response["suggestedSlots"] = suggestions
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// notifyWaitlist hands a freed slot to the worker so it can be offered to
+// patients waiting for this doctor.
+func notifyWaitlist(appointment models.Appointment) {
+	if queue.Client == nil {
+		return
+	}
+	task, err := queue.NewTask(queue.JobTypeWaitlistBackfill, queue.FreedSlotPayload{
+		DoctorID:        appointment.DoctorID,
+		AppointmentDate: appointment.AppointmentDate,
+		StartTime:       appointment.StartTime,
+		EndTime:         appointment.EndTime,
+	})
+	if err != nil {
+		utils.Log.Errorf("notifyWaitlist: Failed to create backfill task - %v", err)
+		return
+	}
+	if _, err := queue.Client.Enqueue(task, asynq.Queue("appointments"), asynq.MaxRetry(3)); err != nil {
+		utils.Log.Errorf("notifyWaitlist: Failed to enqueue backfill task - %v", err)
+	}
 }
@@ -0,0 +1,208 @@
+package appointments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/metrics"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/queue"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// noShowBatchSize bounds how many appointments one sweep marks.
+const noShowBatchSize = 200
+
+type BookingReviewInput struct {
+	Approve *bool  `json:"approve" binding:"required"`
+	Reason  string `json:"reason"`
+}
+
+// MarkNoShows moves confirmed appointments that ended more than the clinic's
+// grace period ago to NO_SHOW: in-person ones without a check-in, and Online
+// ones the patient never joined. It is run periodically by the worker and
+// returns how many appointments it marked.
+func MarkNoShows(ctx context.Context) (int, error) {
+	settings, err := utils.GetClinicSettings(config.DB.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	cutoff := time.Now().Add(-time.Duration(settings.NoShowGraceMinutes) * time.Minute)
+
+	var due []models.Appointment
+	err = config.DB.WithContext(ctx).
+		Where("status = ? AND ends_at < ?", models.AppointmentStatusConfirmed, cutoff).
+		Where("mode = ? OR (mode = ? AND patient_joined_at IS NULL)", utils.ModeInPerson, modeOnline).
+		Order("ends_at asc").
+		Limit(noShowBatchSize).
+		Find(&due).Error
+	if err != nil {
+		return 0, err
+	}
+
+	marked := 0
+	for _, appt := range due {
+		reason := "No check-in recorded"
+		if appt.Mode == modeOnline {
+			reason = "Patient did not join the video meeting"
+		}
+		err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return utils.TransitionAppointment(tx, &appt, models.AppointmentStatusNoShow, utils.StatusActor{}, reason)
+		})
+		// A concurrent check-in or cancellation wins; the appointment is
+		// simply left alone.
+		if errors.Is(err, utils.ErrInvalidTransition) {
+			continue
+		}
+		if err != nil {
+			return marked, err
+		}
+		CancelReminders(ctx, appt.ID)
+		EndMeeting(ctx, appt.ID)
+		marked++
+	}
+	if marked > 0 {
+		utils.Log.Infof("MarkNoShows: Marked %d appointments as no-shows", marked)
+	}
+	return marked, nil
+}
+
+// GetPatientAttendance returns a patient's attendance counters and their
+// standing against the clinic's no-show policy.
+func GetPatientAttendance(c *gin.Context) {
+	user, _ := utils.GetCurrentUser(c)
+	patientID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return
+	}
+	if models.Role(user.Role) == models.RolePatient {
+		patient, err := utils.GetPatientByUserID(user.UserID, c)
+		if err != nil || patient.ID != patientID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+	}
+
+	attendance := models.PatientAttendance{PatientID: patientID}
+	var standing utils.AttendanceStanding
+	err = metrics.DbMetrics(config.DB, "get_patient_attendance", func(db *gorm.DB) error {
+		db = db.WithContext(c.Request.Context())
+		if err := db.Where("patient_id = ?", patientID).Limit(1).Find(&attendance).Error; err != nil {
+			return err
+		}
+		settings, err := utils.GetClinicSettings(db)
+		if err != nil {
+			return err
+		}
+		standing, err = utils.CheckAttendancePolicy(db, patientID, settings, time.Now())
+		return err
+	})
+	if err != nil {
+		utils.Log.Errorf("GetPatientAttendance: Failed to load attendance - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch attendance"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"attendance": attendance, "policy": standing})
+}
+
+// GetPendingBookingRequests lists requests held for staff approval.
+func GetPendingBookingRequests(c *gin.Context) {
+	var requests []models.BookingRequest
+	err := metrics.DbMetrics(config.DB, "get_pending_booking_requests", func(db *gorm.DB) error {
+		return db.WithContext(c.Request.Context()).
+			Where("status = ?", models.BookingRequestAwaitingApproval).
+			Order("created_at asc").
+			Find(&requests).Error
+	})
+	if err != nil {
+		utils.Log.Errorf("GetPendingBookingRequests: Failed to fetch requests - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch booking requests"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"requests": requests})
+}
+
+// ReviewBookingRequest approves a held request, queueing it for booking, or
+// rejects it.
+func ReviewBookingRequest(c *gin.Context) {
+	user, _ := utils.GetCurrentUser(c)
+
+	var input BookingReviewInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	var request models.BookingRequest
+	err := metrics.DbMetrics(config.DB, "review_booking_request", func(db *gorm.DB) error {
+		return db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				First(&request, "id = ? AND status = ?", c.Param("id"), models.BookingRequestAwaitingApproval).Error
+			if err != nil {
+				return err
+			}
+
+			status, reason := models.BookingRequestQueued, ""
+			if !*input.Approve {
+				status, reason = models.BookingRequestRejected, input.Reason
+				if reason == "" {
+					reason = "not approved by the clinic"
+				}
+			}
+
+			now := time.Now()
+			updates := map[string]interface{}{
+				"status":      status,
+				"reason":      reason,
+				"reviewed_by": user.UserID,
+				"reviewed_at": now,
+			}
+			if status == models.BookingRequestRejected {
+				updates["processed_at"] = now
+			}
+			if err := tx.Model(&request).Updates(updates).Error; err != nil {
+				return err
+			}
+			request.Status, request.Reason = status, reason
+			return nil
+		})
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No booking request awaiting approval with that ID"})
+		return
+	}
+	if err != nil {
+		utils.Log.Errorf("ReviewBookingRequest: Failed to review request - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not review booking request"})
+		return
+	}
+
+	if request.Status == models.BookingRequestRejected {
+		notifyBookingOutcome(request)
+		c.JSON(http.StatusOK, gin.H{"message": "Booking request rejected", "request": request})
+		return
+	}
+
+	task, err := queue.NewTask(queue.JobTypeCreateAppointment, queue.BookingRequestPayload{RequestID: request.ID})
+	if err == nil {
+		_, err = queue.Client.Enqueue(task, asynq.Queue("appointments"), asynq.MaxRetry(5))
+	}
+	if err != nil {
+		utils.Log.Errorf("ReviewBookingRequest: Failed to enqueue job - %v", err)
+		settleBookingRequest(c.Request.Context(), &request, models.BookingRequestFailed, "could not be queued")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue the approved request"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Booking request approved and queued", "request": request})
+}
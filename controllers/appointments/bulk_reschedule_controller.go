@@ -0,0 +1,383 @@
+package appointments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/metrics"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/queue"
+	"github.com/AltSumpreme/Medistream.git/services/cache"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BulkRescheduleInput struct {
+	DoctorID uuid.UUID `json:"doctorId" binding:"required"`
+	From     time.Time `json:"from" binding:"required"`
+	To       time.Time `json:"to" binding:"required,gtfield=From"`
+	Reason   string    `json:"reason" binding:"max=500"`
+}
+
+type RescheduleResponseInput struct {
+	Accept *bool `json:"accept" binding:"required"`
+}
+
+// movedAppointment is an appointment a confirmed batch moved, with where it
+// was before.
+type movedAppointment struct {
+	proposal    models.RescheduleProposal
+	appointment models.Appointment
+}
+
+// ProposeBulkReschedule finds the doctor's open appointments in a period and
+// proposes the next free slot for each, with the same doctor after the
+// period or with a doctor of the same specialization. Nothing moves until the
+// batch is confirmed.
+func ProposeBulkReschedule(c *gin.Context) {
+	user, _ := utils.GetCurrentUser(c)
+
+	var input BulkRescheduleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	batch := models.RescheduleBatch{
+		DoctorID:  input.DoctorID,
+		StartsAt:  input.From,
+		EndsAt:    input.To,
+		Reason:    input.Reason,
+		Status:    models.RescheduleBatchDraft,
+		CreatedBy: &user.UserID,
+	}
+	err := metrics.DbMetrics(config.DB, "propose_bulk_reschedule", func(db *gorm.DB) error {
+		db = db.WithContext(c.Request.Context())
+		candidates, err := utils.EquivalentDoctors(db, input.DoctorID)
+		if err != nil {
+			return err
+		}
+		affected, err := utils.AffectedAppointments(db, input.DoctorID, input.From, input.To)
+		if err != nil {
+			return err
+		}
+		if len(affected) > 0 {
+			ids := make([]uuid.UUID, 0, len(affected))
+			for _, appt := range affected {
+				ids = append(ids, appt.ID)
+			}
+			// An appointment is only in one open proposal at a time, so two
+			// drafts cannot both move it.
+			var open int64
+			err := db.Model(&models.RescheduleProposal{}).
+				Where("appointment_id IN ? AND status IN ?", ids, []models.RescheduleProposalStatus{models.RescheduleProposed, models.RescheduleMoved}).
+				Count(&open).Error
+			if err != nil {
+				return err
+			}
+			if open > 0 {
+				return errAlreadyProposed
+			}
+		}
+
+		// The unavailable doctor is only offered once the period is over;
+		// colleagues can take the appointment from the start of it.
+		now := time.Now()
+		earliest := make(map[uuid.UUID]time.Time, len(candidates))
+		for _, doctor := range candidates {
+			earliest[doctor.ID] = latest(now, input.From)
+		}
+		earliest[input.DoctorID] = latest(now, input.To)
+
+		reserved := utils.Reservations{}
+		for _, appt := range affected {
+			proposal := models.RescheduleProposal{
+				AppointmentID:     appt.ID,
+				PatientID:         appt.PatientID,
+				OriginalDoctorID:  appt.DoctorID,
+				OriginalDate:      appt.AppointmentDate,
+				OriginalStartTime: appt.StartTime,
+				OriginalEndTime:   appt.EndTime,
+				Status:            models.RescheduleProposed,
+			}
+			offer, err := utils.NextFreeSlot(db, appt, candidates, earliest, reserved)
+			if err != nil {
+				return err
+			}
+			if offer == nil {
+				proposal.Status, proposal.Reason = models.RescheduleNoSlot, "no free slot with an equivalent doctor"
+			} else {
+				proposal.DoctorID = &offer.DoctorID
+				proposal.AppointmentDate = &offer.Date
+				proposal.StartTime = &offer.StartTime
+				proposal.EndTime = &offer.EndTime
+				proposal.StartsAt = &offer.StartsAt
+				reserved[offer.DoctorID] = append(reserved[offer.DoctorID], utils.TimeRange{Start: offer.StartsAt, End: offer.EndsAt})
+			}
+			batch.Proposals = append(batch.Proposals, proposal)
+		}
+		return db.Create(&batch).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Doctor not found"})
+		return
+	}
+	if errors.Is(err, errAlreadyProposed) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		utils.Log.Errorf("ProposeBulkReschedule: Failed to build proposals - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not propose rescheduling"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"batch": batch})
+}
+
+var errAlreadyProposed = errors.New("some appointments in this period already have an open reschedule proposal")
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// GetRescheduleBatch returns a batch with its proposals.
+func GetRescheduleBatch(c *gin.Context) {
+	var batch models.RescheduleBatch
+	err := metrics.DbMetrics(config.DB, "get_reschedule_batch", func(db *gorm.DB) error {
+		return db.WithContext(c.Request.Context()).
+			Preload("Proposals", func(db *gorm.DB) *gorm.DB { return db.Order("starts_at asc nulls last") }).
+			First(&batch, "id = ?", c.Param("id")).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reschedule batch not found"})
+		return
+	}
+	if err != nil {
+		utils.Log.Errorf("GetRescheduleBatch: Failed to fetch batch - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch reschedule batch"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"batch": batch})
+}
+
+// ConfirmBulkReschedule moves every proposed appointment of a draft batch.
+// Each move is checked again; one that no longer fits is marked FAILED and
+// the rest still go ahead. Moved patients are emailed to accept or decline.
+func ConfirmBulkReschedule(c *gin.Context, appointmentCache *cache.Cache) {
+	user, _ := utils.GetCurrentUser(c)
+
+	var batch models.RescheduleBatch
+	var moved []movedAppointment
+	err := metrics.DbMetrics(config.DB, "confirm_bulk_reschedule", func(db *gorm.DB) error {
+		return db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				First(&batch, "id = ? AND status = ?", c.Param("id"), models.RescheduleBatchDraft).Error
+			if err != nil {
+				return err
+			}
+			var proposals []models.RescheduleProposal
+			err = tx.Where("batch_id = ? AND status = ?", batch.ID, models.RescheduleProposed).
+				Order("starts_at asc").
+				Find(&proposals).Error
+			if err != nil {
+				return err
+			}
+
+			for _, proposal := range proposals {
+				var appt models.Appointment
+				// A savepoint per move keeps one failure from undoing the others.
+				err := tx.Transaction(func(tx *gorm.DB) error {
+					return moveAppointment(tx, proposal, &appt, user.UserID)
+				})
+				status, reason := models.RescheduleMoved, ""
+				switch {
+				case err == nil:
+					moved = append(moved, movedAppointment{proposal: proposal, appointment: appt})
+				case errors.Is(err, gorm.ErrRecordNotFound):
+					status, reason = models.RescheduleFailed, "appointment has changed or is no longer open"
+				case utils.IsScheduleConflict(err):
+					status, reason = models.RescheduleFailed, err.Error()
+				default:
+					return err
+				}
+				err = tx.Model(&models.RescheduleProposal{}).Where("id = ?", proposal.ID).
+					Updates(map[string]interface{}{"status": status, "reason": reason}).Error
+				if err != nil {
+					return err
+				}
+			}
+
+			now := time.Now()
+			batch.Status, batch.ConfirmedAt = models.RescheduleBatchConfirmed, &now
+			return tx.Model(&batch).Updates(map[string]interface{}{"status": batch.Status, "confirmed_at": now}).Error
+		})
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No draft reschedule batch with that ID"})
+		return
+	}
+	if err != nil {
+		utils.Log.Errorf("ConfirmBulkReschedule: Failed to confirm batch - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not confirm rescheduling"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	for _, m := range moved {
+		appt, proposal := m.appointment, m.proposal
+		appointmentCache.AppointmentInvalidate(appt.ID.String(), proposal.OriginalDoctorID.String(), appt.PatientID.String(), proposal.OriginalDate.Format("2006-01-02"))
+		appointmentCache.AppointmentInvalidate(appt.ID.String(), appt.DoctorID.String(), appt.PatientID.String(), appt.AppointmentDate.Format("2006-01-02"))
+		sendCalendarInvite(appt.ID, utils.ICalMethodRequest)
+		syncReminders(ctx, appt)
+		syncMeeting(ctx, appt)
+		notifyRescheduleProposal(ctx, proposal, appt)
+	}
+
+	config.DB.WithContext(ctx).Preload("Proposals").First(&batch, "id = ?", batch.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Reschedule batch confirmed", "moved": len(moved), "batch": batch})
+}
+
+// moveAppointment applies a proposal to its appointment, which must still be
+// open and where the proposal found it; one rescheduled or reassigned since
+// is left alone. The doctor, date and times change; the status does not.
+func moveAppointment(tx *gorm.DB, proposal models.RescheduleProposal, appt *models.Appointment, userID uuid.UUID) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("status IN ?", []models.AppointmentStatus{models.AppointmentStatusPending, models.AppointmentStatusConfirmed}).
+		Where("doctor_id = ? AND DATE(appointment_date) = ? AND start_time = ?",
+			proposal.OriginalDoctorID, proposal.OriginalDate.Format("2006-01-02"), proposal.OriginalStartTime).
+		First(appt, "id = ?", proposal.AppointmentID).Error
+	if err != nil {
+		return err
+	}
+
+	appt.DoctorID = *proposal.DoctorID
+	appt.AppointmentDate = *proposal.AppointmentDate
+	appt.StartTime = *proposal.StartTime
+	appt.EndTime = *proposal.EndTime
+	appt.UpdatedBy = &userID
+
+	if err := utils.ScheduleAppointment(tx, appt.DoctorID, appt.PatientID, appt.AppointmentDate, appt.StartTime, appt.EndTime, appt.AppointmentType, &appt.ID); err != nil {
+		return err
+	}
+	if err := utils.AssignRoom(tx, appt); err != nil {
+		return err
+	}
+	if err := utils.BookingError(tx.Save(appt).Error); err != nil {
+		return err
+	}
+	// Reload for the instants the database derives from the new time.
+	return tx.First(appt, "id = ?", appt.ID).Error
+}
+
+// notifyRescheduleProposal tells the patient where their appointment moved and
+// how to respond.
+func notifyRescheduleProposal(ctx context.Context, proposal models.RescheduleProposal, appt models.Appointment) {
+	if queue.Client == nil {
+		return
+	}
+	db := config.DB.WithContext(ctx)
+	contact, err := utils.GetPatientContact(db, appt.PatientID)
+	if err != nil || contact.Email == "" {
+		utils.Log.Warnf("notifyRescheduleProposal: No email for patient %s - %v", appt.PatientID, err)
+		return
+	}
+	doctorName := "another doctor"
+	if doctor, err := utils.GetDoctorContact(db, appt.DoctorID); err == nil {
+		doctorName = "Dr. " + doctor.FirstName + " " + doctor.LastName
+	}
+
+	tmpl := utils.GetRescheduleProposalTemplate(
+		proposal.OriginalDate.Format("2006-01-02"), proposal.OriginalStartTime,
+		appt.AppointmentDate.Format("2006-01-02"), appt.StartTime,
+		doctorName, proposal.ID.String(),
+	)
+	task, err := queue.NewEmailTask(contact.Email, tmpl.Subject, tmpl.Body)
+	if err != nil {
+		utils.Log.Errorf("notifyRescheduleProposal: Failed to create email task - %v", err)
+		return
+	}
+	if _, err := queue.Client.Enqueue(task, asynq.Queue("emails"), asynq.MaxRetry(3)); err != nil {
+		utils.Log.Errorf("notifyRescheduleProposal: Failed to enqueue email - %v", err)
+	}
+}
+
+// RespondToReschedule records the patient's answer to a moved appointment.
+// Declining cancels it as a doctor-side cancellation.
+func RespondToReschedule(c *gin.Context, appointmentCache *cache.Cache) {
+	user, _ := utils.GetCurrentUser(c)
+
+	var input RescheduleResponseInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	var proposal models.RescheduleProposal
+	if err := config.DB.First(&proposal, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reschedule proposal not found"})
+		return
+	}
+	var appointment models.Appointment
+	if err := config.DB.First(&appointment, "id = ?", proposal.AppointmentID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Appointment not found"})
+		return
+	}
+	if !canManageAppointment(c, user, appointment) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only respond for your own appointment"})
+		return
+	}
+
+	actor := utils.ActorFromClaims(user)
+	status := models.RescheduleAccepted
+	if !*input.Accept {
+		status = models.RescheduleDeclined
+	}
+	err := metrics.DbMetrics(config.DB, "respond_to_reschedule", func(db *gorm.DB) error {
+		return db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				First(&proposal, "id = ? AND status = ?", proposal.ID, models.RescheduleMoved).Error
+			if err != nil {
+				return err
+			}
+			if status == models.RescheduleDeclined {
+				err := cancelAppointment(tx, &appointment, actor, models.CancelReasonDoctorUnavailable, "Declined the rescheduled time", false)
+				if err != nil {
+					return err
+				}
+			}
+			now := time.Now()
+			proposal.Status, proposal.RespondedAt = status, &now
+			return tx.Model(&proposal).Updates(map[string]interface{}{"status": status, "responded_at": now}).Error
+		})
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusConflict, gin.H{"error": "This proposal is not awaiting a response"})
+		return
+	}
+	if err != nil {
+		utils.Log.Warnf("RespondToReschedule: %v", err)
+		respondTransitionError(c, err)
+		return
+	}
+
+	if status == models.RescheduleDeclined {
+		appointmentCache.AppointmentInvalidate(appointment.ID.String(), appointment.DoctorID.String(), appointment.PatientID.String(), appointment.AppointmentDate.Format("2006-01-02"))
+		sendCalendarInvite(appointment.ID, utils.ICalMethodCancel)
+		CancelReminders(c.Request.Context(), appointment.ID)
+		EndMeeting(c.Request.Context(), appointment.ID)
+		notifyWaitlist(appointment)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Response recorded", "proposal": proposal})
+}
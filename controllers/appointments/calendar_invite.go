@@ -0,0 +1,68 @@
+package appointments
+
+import (
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/queue"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+// calendarInvite builds the single-event .ics attachment for an appointment.
+// The appointment is reloaded so the attachment carries the SEQUENCE and
+// instants the database has just written.
+func calendarInvite(appointmentID uuid.UUID, method string) (models.Appointment, []utils.Contact, queue.EmailAttachment, error) {
+	var appt models.Appointment
+	if err := config.DB.First(&appt, "id = ?", appointmentID).Error; err != nil {
+		return appt, nil, queue.EmailAttachment{}, err
+	}
+
+	var contacts []utils.Contact
+	event := utils.AppointmentEvent(appt)
+	event.Organizer = utils.GetEnvWithDefault("SMTP_FROM", "")
+	if patient, err := utils.GetPatientContact(config.DB, appt.PatientID); err == nil && patient.Email != "" {
+		contacts = append(contacts, patient)
+	}
+	if doctor, err := utils.GetDoctorContact(config.DB, appt.DoctorID); err == nil && doctor.Email != "" {
+		contacts = append(contacts, doctor)
+	}
+	for _, contact := range contacts {
+		event.Attendees = append(event.Attendees, utils.CalendarAttendee{
+			Name:  contact.FirstName + " " + contact.LastName,
+			Email: contact.Email,
+		})
+	}
+
+	attachment := queue.EmailAttachment{
+		Filename:    "invite.ics",
+		ContentType: "text/calendar; method=" + method + "; charset=UTF-8",
+		Content:     utils.BuildICS(method, event),
+	}
+	return appt, contacts, attachment, nil
+}
+
+// sendCalendarInvite emails the patient and the doctor an invite, an update
+// or a cancellation for the appointment.
+func sendCalendarInvite(appointmentID uuid.UUID, method string) {
+	if queue.Client == nil {
+		return
+	}
+	appt, contacts, attachment, err := calendarInvite(appointmentID, method)
+	if err != nil {
+		utils.Log.Warnf("sendCalendarInvite: Failed to load appointment %s - %v", appointmentID, err)
+		return
+	}
+
+	tmpl := utils.GetCalendarInviteTemplate(method == utils.ICalMethodCancel, appt.AppointmentDate.Format("2006-01-02"), appt.StartTime)
+	for _, contact := range contacts {
+		task, err := queue.NewEmailTask(contact.Email, tmpl.Subject, tmpl.Body, attachment)
+		if err != nil {
+			utils.Log.Errorf("sendCalendarInvite: Failed to create email task - %v", err)
+			return
+		}
+		if _, err := queue.Client.Enqueue(task, asynq.Queue("emails"), asynq.MaxRetry(3)); err != nil {
+			utils.Log.Errorf("sendCalendarInvite: Failed to enqueue email - %v", err)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package appointments
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/metrics"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CalendarDayView is one column of the doctor's schedule grid.
+type CalendarDayView struct {
+	utils.CalendarDay
+	Appointments []DayScheduleEntry `json:"appointments"`
+}
+
+// GetDoctorCalendar returns the authenticated doctor's day or week, merging
+// working hours, breaks, time off, free slots and booked appointments with
+// patient names. date is on the doctor's calendar and defaults to today; a
+// week runs Monday to Sunday. Instants are rendered in the caller's zone.
+func GetDoctorCalendar(c *gin.Context) {
+	user, err := utils.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	doctor, err := utils.GetDoctorByUserID(user.UserID, c)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Doctor profile not found"})
+		return
+	}
+
+	view := c.DefaultQuery("view", "day")
+	if view != "day" && view != "week" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "view must be day or week"})
+		return
+	}
+	apptType := models.ApptType(c.DefaultQuery("type", string(models.ApptTypeConsultation)))
+	if !utils.IsValidApptType(apptType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type. Use CONSULTATION, FOLLOWUP, CHECKUP or EMERGENCY"})
+		return
+	}
+	callerLoc, ok := callerLocation(c)
+	if !ok {
+		return
+	}
+	doctorLoc, err := utils.DoctorLocation(config.DB, doctor.ID)
+	if err != nil {
+		utils.Log.Errorf("GetDoctorCalendar: Failed to load doctor time zone - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch the calendar"})
+		return
+	}
+
+	now := time.Now()
+	date := utils.CalendarDate(now.In(doctorLoc))
+	if d := c.Query("date"); d != "" {
+		date, err = time.Parse("2006-01-02", d)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must be YYYY-MM-DD"})
+			return
+		}
+	}
+	from, days := date, 1
+	if view == "week" {
+		from = date.AddDate(0, 0, -((int(date.Weekday()) + 6) % 7))
+		days = 7
+	}
+	to := from.AddDate(0, 0, days-1)
+
+	calendar := make([]CalendarDayView, days)
+	err = metrics.DbMetrics(config.DB, "get_doctor_calendar", func(db *gorm.DB) error {
+		db = db.WithContext(c.Request.Context())
+		var entries []DayScheduleEntry
+		err := db.Table("appointments").
+			Select(`appointments.*, pu.first_name || ' ' || pu.last_name AS patient_name`).
+			Joins("JOIN patients p ON p.id = appointments.patient_id").
+			Joins("JOIN users pu ON pu.id = p.user_id").
+			Where("appointments.doctor_id = ? AND appointments.status <> ?", doctor.ID, models.AppointmentStatusCancelled).
+			Where("DATE(appointments.appointment_date) BETWEEN ? AND ?", from.Format("2006-01-02"), to.Format("2006-01-02")).
+			Order("appointments.starts_at asc").
+			Scan(&entries).Error
+		if err != nil {
+			return err
+		}
+
+		byDate := map[string][]DayScheduleEntry{}
+		for _, e := range entries {
+			key := e.AppointmentDate.Format("2006-01-02")
+			byDate[key] = append(byDate[key], e)
+		}
+		for i := range calendar {
+			day, err := utils.GetCalendarDay(db, doctor.ID, from.AddDate(0, 0, i), apptType, now)
+			if err != nil {
+				return err
+			}
+			calendar[i] = CalendarDayView{CalendarDay: day, Appointments: byDate[day.Date]}
+			if calendar[i].Appointments == nil {
+				calendar[i].Appointments = []DayScheduleEntry{}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		utils.Log.Errorf("GetDoctorCalendar: Failed to build calendar - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch the calendar"})
+		return
+	}
+
+	for i := range calendar {
+		utils.LocalizeCalendarDay(&calendar[i].CalendarDay, callerLoc)
+		for j := range calendar[i].Appointments {
+			utils.LocalizeAppointment(&calendar[i].Appointments[j].Appointment, callerLoc)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"view":       view,
+		"from":       from.Format("2006-01-02"),
+		"to":         to.Format("2006-01-02"),
+		"type":       apptType,
+		"doctorZone": doctorLoc.String(),
+		"timeZone":   callerLoc.String(),
+		"days":       calendar,
+	})
+}
@@ -0,0 +1,76 @@
+package appointments
+
+import (
+	"context"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/queue"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+// cancelAppointment cancels appt inside tx and records the reason code and
+// whether it was a late cancellation. The status history gets the reason.
+func cancelAppointment(tx *gorm.DB, appt *models.Appointment, actor utils.StatusActor, code models.CancellationReason, note string, late bool) error {
+	if err := utils.TransitionAppointment(tx, appt, models.AppointmentStatusCancelled, actor, utils.CancellationNote(code, note)); err != nil {
+		return err
+	}
+	err := tx.Model(&models.Appointment{}).Where("id = ?", appt.ID).Updates(map[string]interface{}{
+		"cancellation_reason": code,
+		"late_cancellation":   late,
+	}).Error
+	if err != nil {
+		return err
+	}
+	appt.CancellationReason, appt.LateCancellation = &code, late
+	return nil
+}
+
+// followUpCancellation tells the other party about a cancellation. When the
+// doctor's side cancelled, the patient is offered other slots with the same
+// doctor, which are also returned.
+func followUpCancellation(ctx context.Context, appt models.Appointment, actor utils.StatusActor, code models.CancellationReason, note string) []utils.Slot {
+	db := config.DB.WithContext(ctx)
+
+	var suggestions []utils.Slot
+	if utils.DoctorInitiated(actor, code) {
+		slots, err := utils.RebookingSlots(db, appt, time.Now())
+		if err != nil {
+			utils.Log.Warnf("followUpCancellation: Failed to find rebooking slots - %v", err)
+		}
+		suggestions = slots
+	}
+
+	if queue.Client == nil {
+		return suggestions
+	}
+	var contact utils.Contact
+	var err error
+	if actor.Role == models.RolePatient {
+		contact, err = utils.GetDoctorContact(db, appt.DoctorID)
+	} else {
+		contact, err = utils.GetPatientContact(db, appt.PatientID)
+	}
+	if err != nil || contact.Email == "" {
+		utils.Log.Warnf("followUpCancellation: No email for the other party of %s - %v", appt.ID, err)
+		return suggestions
+	}
+
+	var times []string
+	for _, slot := range suggestions {
+		times = append(times, slot.StartsAt.Format("2006-01-02 15:04"))
+	}
+	tmpl := utils.GetCancellationNoticeTemplate(appt.AppointmentDate.Format("2006-01-02"), appt.StartTime, utils.CancellationNote(code, note), times)
+	task, err := queue.NewEmailTask(contact.Email, tmpl.Subject, tmpl.Body)
+	if err != nil {
+		utils.Log.Errorf("followUpCancellation: Failed to create email task - %v", err)
+		return suggestions
+	}
+	if _, err := queue.Client.Enqueue(task, asynq.Queue("emails"), asynq.MaxRetry(3)); err != nil {
+		utils.Log.Errorf("followUpCancellation: Failed to enqueue email - %v", err)
+	}
+	return suggestions
+}
@@ -0,0 +1,236 @@
+package appointments
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/metrics"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/services/cache"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	errQueueEmpty       = errors.New("no patient is waiting")
+	errConsultationOpen = errors.New("finish the current consultation before calling the next patient")
+	errCheckInNotFound  = errors.New("no appointment to check in with this code")
+	errBadDate          = errors.New("date must be YYYY-MM-DD")
+)
+
+type SelfCheckInInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// checkIn moves a confirmed in-person appointment into the waiting room.
+func checkIn(c *gin.Context, appointmentCache *cache.Cache, appt models.Appointment, user *utils.JWTClaims) {
+	err := metrics.DbMetrics(config.DB, "check_in_appointment", func(db *gorm.DB) error {
+		return db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			return utils.TransitionAppointment(tx, &appt, models.AppointmentStatusCheckedIn, utils.ActorFromClaims(user), "")
+		})
+	})
+	if err != nil {
+		utils.Log.Warnf("CheckIn: %v", err)
+		respondTransitionError(c, err)
+		return
+	}
+
+	appointmentCache.AppointmentInvalidate(appt.ID.String(), appt.DoctorID.String(), appt.PatientID.String(), appt.AppointmentDate.Format("2006-01-02"))
+	CancelReminders(c.Request.Context(), appt.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Patient checked in", "appointment": appt})
+}
+
+// CheckInAppointment lets front-desk staff check a patient in on arrival.
+func CheckInAppointment(c *gin.Context, appointmentCache *cache.Cache) {
+	user, err := utils.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var appt models.Appointment
+	if err := config.DB.WithContext(c.Request.Context()).First(&appt, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Appointment not found"})
+		return
+	}
+	if !canManageAppointment(c, user, appt) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot check in this appointment"})
+		return
+	}
+	checkIn(c, appointmentCache, appt, user)
+}
+
+// SelfCheckIn checks the caller in with the code of one of their confirmed
+// appointments.
+func SelfCheckIn(c *gin.Context, appointmentCache *cache.Cache) {
+	user, err := utils.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	var input SelfCheckInInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	patient, err := utils.GetPatientByUserID(user.UserID, c)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Patient profile not found"})
+		return
+	}
+
+	var appt models.Appointment
+	err = config.DB.WithContext(c.Request.Context()).
+		Where("patient_id = ? AND check_in_code = ? AND status = ?", patient.ID, strings.ToUpper(strings.TrimSpace(input.Code)), models.AppointmentStatusConfirmed).
+		Order("starts_at asc").
+		First(&appt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": errCheckInNotFound.Error()})
+		return
+	}
+	if err != nil {
+		utils.Log.Errorf("SelfCheckIn: Database error - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not check in"})
+		return
+	}
+	checkIn(c, appointmentCache, appt, user)
+}
+
+// queueDoctorID returns the doctor whose queue is addressed: doctors use
+// their own, staff name one with doctor_id.
+func queueDoctorID(c *gin.Context, user *utils.JWTClaims, requested string) (uuid.UUID, int, error) {
+	if models.Role(user.Role) == models.RoleDoctor {
+		doctor, err := utils.GetDoctorByUserID(user.UserID, c)
+		if err != nil {
+			return uuid.Nil, http.StatusForbidden, errors.New("doctor profile not found")
+		}
+		if requested != "" && requested != doctor.ID.String() {
+			return uuid.Nil, http.StatusForbidden, errors.New("you can only manage your own queue")
+		}
+		return doctor.ID, http.StatusOK, nil
+	}
+	id, err := uuid.Parse(requested)
+	if err != nil {
+		return uuid.Nil, http.StatusBadRequest, errors.New("a valid doctor ID is required")
+	}
+	return id, http.StatusOK, nil
+}
+
+// GetDoctorQueue shows a doctor's waiting room for a day, today by default,
+// with estimated waits.
+func GetDoctorQueue(c *gin.Context) {
+	user, err := utils.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	doctorID, status, err := queueDoctorID(c, user, c.Param("id"))
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	var queue utils.DoctorQueue
+	err = metrics.DbMetrics(config.DB, "get_doctor_queue", func(db *gorm.DB) error {
+		db = db.WithContext(c.Request.Context())
+		now := time.Now()
+		date, err := utils.QueueDate(db, doctorID, now)
+		if err != nil {
+			return err
+		}
+		if d := c.Query("date"); d != "" {
+			if date, err = time.Parse("2006-01-02", d); err != nil {
+				return errBadDate
+			}
+		}
+		queue, err = utils.GetDoctorQueue(db, doctorID, date, now)
+		return err
+	})
+	if errors.Is(err, errBadDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Doctor not found"})
+		return
+	}
+	if err != nil {
+		utils.Log.Errorf("GetDoctorQueue: Failed to load queue - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch the queue"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"queue": queue})
+}
+
+// CallNextPatient starts the consultation of the next patient waiting for
+// the doctor today. A doctor sees one patient at a time.
+func CallNextPatient(c *gin.Context, appointmentCache *cache.Cache) {
+	user, err := utils.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	doctorID, status, err := queueDoctorID(c, user, c.Query("doctor_id"))
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	var appt models.Appointment
+	err = metrics.DbMetrics(config.DB, "call_next_patient", func(db *gorm.DB) error {
+		return db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			date, err := utils.QueueDate(tx, doctorID, time.Now())
+			if err != nil {
+				return err
+			}
+			// The doctor row lock serialises concurrent calls for the
+			// same doctor.
+			if err := tx.Exec("SELECT 1 FROM doctors WHERE id = ? FOR UPDATE", doctorID).Error; err != nil {
+				return err
+			}
+			var open int64
+			err = tx.Model(&models.Appointment{}).
+				Where("doctor_id = ? AND status = ?", doctorID, models.AppointmentStatusInConsultation).
+				Count(&open).Error
+			if err != nil {
+				return err
+			}
+			if open > 0 {
+				return errConsultationOpen
+			}
+
+			appt, err = utils.NextInQueue(tx, doctorID, date)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errQueueEmpty
+			}
+			if err != nil {
+				return err
+			}
+			return utils.TransitionAppointment(tx, &appt, models.AppointmentStatusInConsultation, utils.ActorFromClaims(user), "")
+		})
+	})
+	switch {
+	case errors.Is(err, errQueueEmpty):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, errConsultationOpen):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Doctor not found"})
+		return
+	case err != nil:
+		utils.Log.Warnf("CallNextPatient: %v", err)
+		respondTransitionError(c, err)
+		return
+	}
+
+	appointmentCache.AppointmentInvalidate(appt.ID.String(), appt.DoctorID.String(), appt.PatientID.String(), appt.AppointmentDate.Format("2006-01-02"))
+	c.JSON(http.StatusOK, gin.H{"message": "Patient called in", "appointment": appt})
+}
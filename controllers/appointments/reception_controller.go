@@ -0,0 +1,115 @@
+package appointments
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/metrics"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DayScheduleEntry is an appointment with its patient's and doctor's names,
+// as listed on the front-desk day view and in search results.
+type DayScheduleEntry struct {
+	models.Appointment
+	PatientName string `json:"patientName"`
+	DoctorName  string `json:"doctorName"`
+}
+
+// GetDaySchedule lists one day's appointments for the front desk, in start
+// order. Receptionists tied to a location only see that location; admins may
+// filter by location_id. Both may filter by doctor_id. Times are rendered in
+// the location's zone, or the clinic's, unless the caller names one.
+func GetDaySchedule(c *gin.Context) {
+	user, err := utils.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	var locationID *uuid.UUID
+	if models.Role(user.Role) == models.RoleReceptionist {
+		receptionist, err := utils.GetReceptionistByUserID(user.UserID, c)
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Receptionist profile not found"})
+			return
+		}
+		locationID = receptionist.LocationID
+	} else if l := c.Query("location_id"); l != "" {
+		id, err := uuid.Parse(l)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+			return
+		}
+		locationID = &id
+	}
+
+	// The front desk works on the site's calendar unless it asks for
+	// another zone.
+	var site *time.Location
+	if locationID != nil {
+		site, err = utils.SiteLocation(config.DB.WithContext(c.Request.Context()), *locationID)
+	} else {
+		site, err = utils.ClinicLocation(config.DB.WithContext(c.Request.Context()))
+	}
+	if err != nil {
+		utils.Log.Errorf("GetDaySchedule: Failed to load time zone - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch the day's schedule"})
+		return
+	}
+	loc, err := utils.CallerLocationOr(c, site)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	date := utils.CalendarDate(time.Now().In(loc))
+	if d := c.Query("date"); d != "" {
+		date, err = time.Parse("2006-01-02", d)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must be YYYY-MM-DD"})
+			return
+		}
+	}
+
+	query := config.DB.WithContext(c.Request.Context()).
+		Table("appointments").
+		Select(`appointments.*,
+			pu.first_name || ' ' || pu.last_name AS patient_name,
+			du.first_name || ' ' || du.last_name AS doctor_name`).
+		Joins("JOIN patients p ON p.id = appointments.patient_id").
+		Joins("JOIN users pu ON pu.id = p.user_id").
+		Joins("JOIN doctors d ON d.id = appointments.doctor_id").
+		Joins("JOIN users du ON du.id = d.user_id").
+		Where("DATE(appointments.appointment_date) = ?", date.Format("2006-01-02"))
+	if locationID != nil {
+		query = query.Where("appointments.location_id = ?", *locationID)
+	}
+	if d := c.Query("doctor_id"); d != "" {
+		doctorID, err := uuid.Parse(d)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid doctor ID"})
+			return
+		}
+		query = query.Where("appointments.doctor_id = ?", doctorID)
+	}
+
+	var entries []DayScheduleEntry
+	err = metrics.DbMetrics(query, "get_day_schedule", func(db *gorm.DB) error {
+		return db.Order("appointments.starts_at asc").Scan(&entries).Error
+	})
+	if err != nil {
+		utils.Log.Errorf("GetDaySchedule: Failed to fetch appointments - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch the day's schedule"})
+		return
+	}
+
+	for i := range entries {
+		utils.LocalizeAppointment(&entries[i].Appointment, loc)
+	}
+	c.JSON(http.StatusOK, gin.H{"date": date.Format("2006-01-02"), "appointments": entries, "timeZone": loc.String()})
+}
@@ -0,0 +1,169 @@
+package appointments
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/queue"
+	"github.com/AltSumpreme/Medistream.git/services/mail"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+const reminderQueue = "emails"
+
+// syncReminders makes the queued reminders match the appointment: confirmed
+// appointments get a fresh set, anything else loses them.
+func syncReminders(ctx context.Context, appt models.Appointment) {
+	CancelReminders(ctx, appt.ID)
+	if appt.Status == models.AppointmentStatusConfirmed {
+		ScheduleReminders(ctx, appt.ID)
+	}
+}
+
+// ScheduleReminders queues one delayed reminder per configured offset. Offsets
+// that have already passed are skipped.
+func ScheduleReminders(ctx context.Context, appointmentID uuid.UUID) {
+	if queue.Client == nil {
+		return
+	}
+
+	// Reload so the database-derived start instant is current.
+	var appt models.Appointment
+	if err := config.DB.WithContext(ctx).First(&appt, "id = ?", appointmentID).Error; err != nil {
+		utils.Log.Warnf("ScheduleReminders: Appointment %s not found - %v", appointmentID, err)
+		return
+	}
+	settings, err := utils.GetClinicSettings(config.DB.WithContext(ctx))
+	if err != nil {
+		utils.Log.Errorf("ScheduleReminders: Failed to load clinic settings - %v", err)
+		return
+	}
+
+	start := utils.AppointmentStart(appt)
+	for _, minutes := range settings.ReminderOffsetsMinutes {
+		sendAt := start.Add(-time.Duration(minutes) * time.Minute)
+		if !sendAt.After(time.Now()) {
+			continue
+		}
+
+		id := uuid.New()
+		reminder := models.AppointmentReminder{
+			ID:            id,
+			AppointmentID: appt.ID,
+			OffsetMinutes: int(minutes),
+			SendAt:        sendAt,
+			TaskID:        "reminder:" + id.String(),
+			Status:        models.ReminderScheduled,
+		}
+		if err := config.DB.WithContext(ctx).Create(&reminder).Error; err != nil {
+			utils.Log.Errorf("ScheduleReminders: Failed to store reminder - %v", err)
+			continue
+		}
+
+		task, err := queue.NewTask(queue.JobTypeAppointmentReminder, queue.ReminderPayload{ReminderID: reminder.ID})
+		if err == nil {
+			_, err = queue.Client.Enqueue(task,
+				asynq.Queue(reminderQueue),
+				asynq.TaskID(reminder.TaskID),
+				asynq.ProcessAt(sendAt),
+				asynq.MaxRetry(3),
+			)
+		}
+		if err != nil {
+			utils.Log.Errorf("ScheduleReminders: Failed to enqueue reminder - %v", err)
+			config.DB.WithContext(ctx).Delete(&reminder)
+		}
+	}
+}
+
+// CancelReminders deletes an appointment's pending reminder tasks. A task
+// that cannot be deleted is harmless: the worker re-checks the reminder's
+// status before sending.
+func CancelReminders(ctx context.Context, appointmentID uuid.UUID) {
+	var reminders []models.AppointmentReminder
+	err := config.DB.WithContext(ctx).
+		Where("appointment_id = ? AND status = ?", appointmentID, models.ReminderScheduled).
+		Find(&reminders).Error
+	if err != nil {
+		utils.Log.Errorf("CancelReminders: Failed to load reminders - %v", err)
+		return
+	}
+
+	for _, reminder := range reminders {
+		if queue.Inspector != nil {
+			err := queue.Inspector.DeleteTask(reminderQueue, reminder.TaskID)
+			if err != nil && !errors.Is(err, asynq.ErrTaskNotFound) && !errors.Is(err, asynq.ErrQueueNotFound) {
+				utils.Log.Warnf("CancelReminders: Failed to delete task %s - %v", reminder.TaskID, err)
+			}
+		}
+		err := config.DB.WithContext(ctx).Model(&reminder).
+			Where("status = ?", models.ReminderScheduled).
+			Update("status", models.ReminderCancelled).Error
+		if err != nil {
+			utils.Log.Errorf("CancelReminders: Failed to cancel reminder %s - %v", reminder.ID, err)
+		}
+	}
+}
+
+// SendAppointmentReminder emails the patient if the reminder is still wanted.
+// Appointments that are no longer confirmed, or that moved since the reminder
+// was queued, are skipped.
+func SendAppointmentReminder(ctx context.Context, payload queue.ReminderPayload) error {
+	var reminder models.AppointmentReminder
+	err := config.DB.WithContext(ctx).First(&reminder, "id = ?", payload.ReminderID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if reminder.Status != models.ReminderScheduled {
+		return nil
+	}
+
+	var appt models.Appointment
+	err = config.DB.WithContext(ctx).First(&appt, "id = ?", reminder.AppointmentID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	start := utils.AppointmentStart(appt)
+	expected := reminder.SendAt.Add(time.Duration(reminder.OffsetMinutes) * time.Minute)
+	if err != nil || appt.Status != models.AppointmentStatusConfirmed || !start.Equal(expected) || !time.Now().Before(start) {
+		utils.Log.Infof("SendAppointmentReminder: Skipping reminder %s, appointment changed", reminder.ID)
+		return settleReminder(ctx, reminder.ID, models.ReminderSkipped)
+	}
+
+	contact, err := utils.GetPatientContact(config.DB.WithContext(ctx), appt.PatientID)
+	if err != nil {
+		return err
+	}
+	if contact.Email == "" {
+		return settleReminder(ctx, reminder.ID, models.ReminderSkipped)
+	}
+
+	timeZone := time.UTC.String()
+	if loc, err := utils.DoctorLocation(config.DB.WithContext(ctx), appt.DoctorID); err == nil {
+		timeZone = loc.String()
+	}
+	tmpl := utils.GetAppointmentReminderTemplate(appt.AppointmentDate.Format("2006-01-02"), appt.StartTime, timeZone)
+	if err := mail.SendEmail(contact.Email, tmpl.Subject, tmpl.Body); err != nil {
+		return err
+	}
+	return settleReminder(ctx, reminder.ID, models.ReminderSent)
+}
+
+func settleReminder(ctx context.Context, reminderID uuid.UUID, status models.ReminderStatus) error {
+	updates := map[string]interface{}{"status": status}
+	if status == models.ReminderSent {
+		updates["sent_at"] = time.Now()
+	}
+	return config.DB.WithContext(ctx).Model(&models.AppointmentReminder{}).
+		Where("id = ? AND status = ?", reminderID, models.ReminderScheduled).
+		Updates(updates).Error
+}
@@ -0,0 +1,181 @@
+package appointments
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/metrics"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AppointmentSearchInput holds the search filters. status and type may be
+// repeated; sort is a comma-separated list of fields, each optionally
+// prefixed with "-" for descending order.
+type AppointmentSearchInput struct {
+	From       string   `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To         string   `form:"to" binding:"omitempty,datetime=2006-01-02"`
+	Status     []string `form:"status" binding:"omitempty,dive,oneof=PENDING CONFIRMED CHECKED_IN IN_CONSULTATION CANCELLED COMPLETED NO_SHOW"`
+	Type       []string `form:"type" binding:"omitempty,dive,oneof=CONSULTATION FOLLOWUP CHECKUP EMERGENCY"`
+	Mode       string   `form:"mode" binding:"omitempty,oneof=Online In-Person"`
+	LocationID string   `form:"location_id" binding:"omitempty,uuid"`
+	DoctorID   string   `form:"doctor_id" binding:"omitempty,uuid"`
+	PatientID  string   `form:"patient_id" binding:"omitempty,uuid"`
+	Query      string   `form:"q" binding:"omitempty,max=100"`
+	Sort       string   `form:"sort" binding:"omitempty,max=200"`
+	Page       int      `form:"page,default=1" binding:"min=1"`
+	Limit      int      `form:"limit,default=20" binding:"min=1,max=100"`
+}
+
+// searchSortColumns maps the sort fields clients may use to columns.
+var searchSortColumns = map[string]string{
+	"date":    "appointments.starts_at",
+	"created": "appointments.created_at",
+	"updated": "appointments.updated_at",
+	"status":  "appointments.status",
+	"type":    "appointments.appointment_type",
+	"mode":    "appointments.mode",
+	"patient": "patient_name",
+	"doctor":  "doctor_name",
+}
+
+const defaultSearchSort = "-date"
+
+// searchOrder turns a sort parameter into an ORDER BY clause. Unknown or
+// repeated fields are rejected. The appointment ID breaks ties so pages do
+// not overlap.
+func searchOrder(sort string) (string, bool) {
+	if sort == "" {
+		sort = defaultSearchSort
+	}
+	seen := map[string]bool{}
+	var clauses []string
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		direction := "asc"
+		if strings.HasPrefix(field, "-") {
+			field, direction = field[1:], "desc"
+		}
+		column, ok := searchSortColumns[field]
+		if !ok || seen[field] {
+			return "", false
+		}
+		seen[field] = true
+		clauses = append(clauses, column+" "+direction)
+	}
+	return strings.Join(append(clauses, "appointments.id asc"), ", "), true
+}
+
+// SearchAppointments filters, sorts and pages appointments and returns the
+// total number of matches. Doctors only see their own appointments and
+// receptionists tied to a location only see that location.
+func SearchAppointments(c *gin.Context) {
+	user, err := utils.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	loc, ok := callerLocation(c)
+	if !ok {
+		return
+	}
+
+	var input AppointmentSearchInput
+	if err := c.ShouldBindQuery(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filters", "details": err.Error()})
+		return
+	}
+	order, ok := searchOrder(input.Sort)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort. Use date, created, updated, status, type, mode, patient or doctor, optionally prefixed with -"})
+		return
+	}
+
+	query := config.DB.WithContext(c.Request.Context()).
+		Table("appointments").
+		Joins("JOIN patients p ON p.id = appointments.patient_id").
+		Joins("JOIN users pu ON pu.id = p.user_id").
+		Joins("JOIN doctors d ON d.id = appointments.doctor_id").
+		Joins("JOIN users du ON du.id = d.user_id")
+
+	switch models.Role(user.Role) {
+	case models.RoleDoctor:
+		doctor, err := utils.GetDoctorByUserID(user.UserID, c)
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Doctor profile not found"})
+			return
+		}
+		query = query.Where("appointments.doctor_id = ?", doctor.ID)
+	case models.RoleReceptionist:
+		receptionist, err := utils.GetReceptionistByUserID(user.UserID, c)
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Receptionist profile not found"})
+			return
+		}
+		if receptionist.LocationID != nil {
+			query = query.Where("appointments.location_id = ?", *receptionist.LocationID)
+		}
+	}
+
+	// Dates were validated by binding. appointment_date holds midnight of
+	// the doctor's calendar day, so the range compares it directly.
+	if input.From != "" {
+		from, _ := time.Parse("2006-01-02", input.From)
+		query = query.Where("appointments.appointment_date >= ?", from)
+	}
+	if input.To != "" {
+		to, _ := time.Parse("2006-01-02", input.To)
+		query = query.Where("appointments.appointment_date < ?", to.AddDate(0, 0, 1))
+	}
+	if len(input.Status) > 0 {
+		query = query.Where("appointments.status IN ?", input.Status)
+	}
+	if len(input.Type) > 0 {
+		query = query.Where("appointments.appointment_type IN ?", input.Type)
+	}
+	if input.Mode != "" {
+		query = query.Where("appointments.mode = ?", input.Mode)
+	}
+	if input.LocationID != "" {
+		query = query.Where("appointments.location_id = ?", input.LocationID)
+	}
+	if input.DoctorID != "" {
+		query = query.Where("appointments.doctor_id = ?", input.DoctorID)
+	}
+	if input.PatientID != "" {
+		query = query.Where("appointments.patient_id = ?", input.PatientID)
+	}
+	if q := strings.TrimSpace(input.Query); q != "" {
+		// Matches the trigram index on users' full names.
+		query = query.Where("(pu.first_name || ' ' || pu.last_name) ILIKE ?", utils.ContainsPattern(q))
+	}
+
+	var total int64
+	entries := []DayScheduleEntry{}
+	err = metrics.DbMetrics(query, "search_appointments", func(db *gorm.DB) error {
+		if err := db.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return err
+		}
+		return db.Select(`appointments.*,
+			pu.first_name || ' ' || pu.last_name AS patient_name,
+			du.first_name || ' ' || du.last_name AS doctor_name`).
+			Order(order).
+			Limit(input.Limit).
+			Offset((input.Page - 1) * input.Limit).
+			Scan(&entries).Error
+	})
+	if err != nil {
+		utils.Log.Errorf("SearchAppointments: Failed to search appointments - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not search appointments"})
+		return
+	}
+
+	for i := range entries {
+		utils.LocalizeAppointment(&entries[i].Appointment, loc)
+	}
+	c.JSON(http.StatusOK, gin.H{"appointments": entries, "total": total, "page": input.Page, "limit": input.Limit})
+}
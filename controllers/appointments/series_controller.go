@@ -0,0 +1,453 @@
+package appointments
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/metrics"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/services/cache"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AppointmentSeriesInput struct {
+	PatientID       *uuid.UUID `json:"patientId"`
+	DoctorID        uuid.UUID  `json:"doctorId" binding:"required"`
+	StartDate       time.Time  `json:"startDate" binding:"required"`
+	StartTime       string     `json:"startTime" binding:"required"`
+	EndTime         string     `json:"endTime" binding:"required"`
+	AppointmentType string     `json:"appointmentType" binding:"required,oneof=CONSULTATION FOLLOWUP CHECKUP EMERGENCY"`
+	Mode            string     `json:"mode" binding:"required,oneof=Online In-Person"`
+	Notes           string     `json:"notes"`
+
+	// Either an RRULE string or the structured fields below.
+	RRule     string     `json:"rrule"`
+	Frequency string     `json:"frequency" binding:"omitempty,oneof=WEEKLY MONTHLY"`
+	Interval  int        `json:"interval" binding:"omitempty,min=1"`
+	Count     int        `json:"count" binding:"omitempty,min=1"`
+	Until     *time.Time `json:"until"`
+}
+
+type SeriesOccurrenceInput struct {
+	Scope         string    `json:"scope" binding:"required,oneof=this following"`
+	AppointmentID uuid.UUID `json:"appointmentId" binding:"required"`
+	StartTime     string    `json:"startTime"`
+	EndTime       string    `json:"endTime"`
+	Mode          string    `json:"mode" binding:"omitempty,oneof=Online In-Person"`
+	Notes         string    `json:"notes"`
+}
+
+var errLateCancelBlocked = errors.New("late cancellation blocked")
+
+// CancelSeriesInput picks the occurrences to cancel and, as for single
+// appointments, the reason.
+type CancelSeriesInput struct {
+	SeriesOccurrenceInput
+	ReasonCode string `json:"reasonCode" binding:"required,oneof=PATIENT_REQUEST PATIENT_ILLNESS SCHEDULE_CONFLICT DOCTOR_UNAVAILABLE CLINIC_CLOSURE DUPLICATE_BOOKING OTHER"`
+	Reason     string `json:"reason" binding:"required_if=ReasonCode OTHER,max=500"`
+}
+
+type SeriesConflict struct {
+	Index  int       `json:"index"`
+	Date   time.Time `json:"date"`
+	Reason string    `json:"reason"`
+}
+
+// seriesPatientID returns the patient a new series is booked for: patients
+// book for themselves, admins must name an existing patient. The status is
+// the one to reply with when err is set.
+func seriesPatientID(c *gin.Context, user *utils.JWTClaims, requested *uuid.UUID) (uuid.UUID, int, error) {
+	if models.Role(user.Role) == models.RolePatient {
+		patient, err := utils.GetPatientByUserID(user.UserID, c)
+		if err != nil {
+			return uuid.Nil, http.StatusForbidden, errors.New("patient profile not found")
+		}
+		return patient.ID, http.StatusOK, nil
+	}
+	if requested == nil {
+		return uuid.Nil, http.StatusBadRequest, errors.New("patientId is required")
+	}
+	var patient models.Patient
+	if err := config.DB.WithContext(c).Select("id").First(&patient, "id = ?", *requested).Error; err != nil {
+		return uuid.Nil, http.StatusNotFound, errors.New("patient not found")
+	}
+	return patient.ID, http.StatusOK, nil
+}
+
+// canManageSeries reports whether the caller is the series' patient, its
+// doctor, or an admin.
+func canManageSeries(c *gin.Context, user *utils.JWTClaims, series models.AppointmentSeries) bool {
+	switch models.Role(user.Role) {
+	case models.RoleAdmin:
+		return true
+	case models.RolePatient:
+		patient, err := utils.GetPatientByUserID(user.UserID, c)
+		return err == nil && patient.ID == series.PatientID
+	case models.RoleDoctor:
+		doctor, err := utils.GetDoctorByUserID(user.UserID, c)
+		return err == nil && doctor.ID == series.DoctorID
+	}
+	return false
+}
+
+func CreateAppointmentSeries(c *gin.Context, appointmentCache *cache.Cache) {
+	user, err := utils.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var input AppointmentSeriesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Log.Warnf("CreateAppointmentSeries: Invalid input - %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	rule := utils.RecurrenceRule{
+		Frequency: models.RecurrenceFrequency(input.Frequency),
+		Interval:  max(input.Interval, 1),
+		Count:     input.Count,
+		Until:     input.Until,
+	}
+	if input.RRule != "" {
+		if rule, err = utils.ParseRRule(input.RRule); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rrule: " + err.Error()})
+			return
+		}
+	}
+	// startDate is a day on the doctor's calendar, so occurrences keep the
+	// same wall-clock time across daylight saving changes.
+	input.StartDate = utils.CalendarDate(input.StartDate)
+	dates, err := rule.Expand(input.StartDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	patientID, status, err := seriesPatientID(c, user, input.PatientID)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	var doctor models.Doctor
+	if err := config.DB.WithContext(c).Select("id").First(&doctor, "id = ?", input.DoctorID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "doctor not found"})
+		return
+	}
+
+	series := models.AppointmentSeries{
+		PatientID:       patientID,
+		DoctorID:        input.DoctorID,
+		Frequency:       rule.Frequency,
+		Interval:        rule.Interval,
+		Until:           rule.Until,
+		StartDate:       input.StartDate,
+		StartTime:       input.StartTime,
+		EndTime:         input.EndTime,
+		Mode:            input.Mode,
+		AppointmentType: models.ApptType(input.AppointmentType),
+		Notes:           input.Notes,
+	}
+	if rule.Count > 0 {
+		series.Count = &rule.Count
+	}
+
+	booked := []models.Appointment{}
+	conflicts := []SeriesConflict{}
+
+	err = metrics.DbMetrics(config.DB, "create_appointment_series", func(db *gorm.DB) error {
+		return db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&series).Error; err != nil {
+				return err
+			}
+			for i, date := range dates {
+				if err := utils.ScheduleAppointment(tx, input.DoctorID, patientID, date, input.StartTime, input.EndTime, models.ApptType(input.AppointmentType), nil); err != nil {
+					if utils.IsScheduleConflict(err) {
+						conflicts = append(conflicts, SeriesConflict{Index: i, Date: date, Reason: err.Error()})
+						continue
+					}
+					return err
+				}
+				index := i
+				appt := models.Appointment{
+					PatientID:       patientID,
+					DoctorID:        input.DoctorID,
+					AppointmentDate: date,
+					StartTime:       input.StartTime,
+					EndTime:         input.EndTime,
+					Status:          models.AppointmentStatusPending,
+					Mode:            input.Mode,
+					AppointmentType: series.AppointmentType,
+					Notes:           input.Notes,
+					SeriesID:        &series.ID,
+					SeriesIndex:     &index,
+					BookedBy:        &user.UserID,
+				}
+				if err := utils.AssignRoom(tx, &appt); err != nil {
+					if utils.IsScheduleConflict(err) {
+						conflicts = append(conflicts, SeriesConflict{Index: i, Date: date, Reason: err.Error()})
+						continue
+					}
+					return err
+				}
+				// A savepoint keeps a constraint violation from aborting the
+				// rest of the series.
+				if err := tx.Transaction(func(sp *gorm.DB) error { return sp.Create(&appt).Error }); err != nil {
+					if err = utils.BookingError(err); utils.IsScheduleConflict(err) {
+						conflicts = append(conflicts, SeriesConflict{Index: i, Date: date, Reason: err.Error()})
+						continue
+					}
+					return err
+				}
+				booked = append(booked, appt)
+			}
+			if len(booked) == 0 {
+				return errSeriesConflict
+			}
+			return nil
+		})
+	})
+	if errors.Is(err, errSeriesConflict) {
+		utils.Log.Warnf("CreateAppointmentSeries: Every occurrence conflicted")
+		c.JSON(http.StatusConflict, gin.H{"error": "No occurrence of the series could be booked", "conflicts": conflicts})
+		return
+	}
+	if err != nil {
+		utils.Log.Errorf("CreateAppointmentSeries: Database error - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create appointment series"})
+		return
+	}
+
+	for _, appt := range booked {
+		appointmentCache.AppointmentInvalidate(appt.ID.String(), appt.DoctorID.String(), appt.PatientID.String(), appt.AppointmentDate.Format("2006-01-02"))
+	}
+
+	utils.Log.Infof("CreateAppointmentSeries: Series %s created with %d occurrences and %d conflicts", series.ID, len(booked), len(conflicts))
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "Appointment series created",
+		"series":    series,
+		"booked":    booked,
+		"conflicts": conflicts,
+	})
+}
+
+var errSeriesConflict = errors.New("series occurrences conflict")
+
+func GetAppointmentSeries(c *gin.Context) {
+	user, _ := utils.GetCurrentUser(c)
+
+	var series models.AppointmentSeries
+	err := metrics.DbMetrics(config.DB, "get_appointment_series", func(db *gorm.DB) error {
+		return db.WithContext(c.Request.Context()).
+			Preload("Appointments", func(db *gorm.DB) *gorm.DB { return db.Order("series_index asc") }).
+			First(&series, "id = ?", c.Param("id")).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Appointment series not found"})
+		return
+	}
+	if !canManageSeries(c, user, series) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"series": series})
+}
+
+// seriesOccurrences loads the occurrences an edit applies to: the chosen one,
+// or it and every later one that is still open.
+func seriesOccurrences(tx *gorm.DB, series models.AppointmentSeries, input SeriesOccurrenceInput) ([]models.Appointment, error) {
+	var anchor models.Appointment
+	if err := tx.First(&anchor, "id = ? AND series_id = ?", input.AppointmentID, series.ID).Error; err != nil {
+		return nil, errors.New("appointment is not part of this series")
+	}
+	if input.Scope == "this" {
+		return []models.Appointment{anchor}, nil
+	}
+
+	var occurrences []models.Appointment
+	err := tx.Where("series_id = ? AND series_index >= ?", series.ID, *anchor.SeriesIndex).
+		Where("status NOT IN ?", utils.TerminalStatuses()).
+		Order("series_index asc").
+		Find(&occurrences).Error
+	return occurrences, err
+}
+
+func loadManagedSeries(c *gin.Context) (models.AppointmentSeries, bool) {
+	user, _ := utils.GetCurrentUser(c)
+
+	var series models.AppointmentSeries
+	if err := config.DB.WithContext(c.Request.Context()).First(&series, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Appointment series not found"})
+		return series, false
+	}
+	if !canManageSeries(c, user, series) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return series, false
+	}
+	return series, true
+}
+
+func UpdateAppointmentSeries(c *gin.Context, appointmentCache *cache.Cache) {
+	series, ok := loadManagedSeries(c)
+	if !ok {
+		return
+	}
+
+	var input SeriesOccurrenceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	user, _ := utils.GetCurrentUser(c)
+	var updated []models.Appointment
+	conflicts := []SeriesConflict{}
+
+	err := config.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		occurrences, err := seriesOccurrences(tx, series, input)
+		if err != nil {
+			return err
+		}
+		for _, appt := range occurrences {
+			if input.StartTime != "" {
+				appt.StartTime = input.StartTime
+			}
+			if input.EndTime != "" {
+				appt.EndTime = input.EndTime
+			}
+			if input.Mode != "" {
+				appt.Mode = input.Mode
+			}
+			if input.Notes != "" {
+				appt.Notes = input.Notes
+			}
+			appt.UpdatedBy = &user.UserID
+			if err := utils.ScheduleAppointment(tx, appt.DoctorID, appt.PatientID, appt.AppointmentDate, appt.StartTime, appt.EndTime, appt.AppointmentType, &appt.ID); err != nil {
+				conflicts = append(conflicts, SeriesConflict{Index: *appt.SeriesIndex, Date: appt.AppointmentDate, Reason: err.Error()})
+				continue
+			}
+			if err := utils.AssignRoom(tx, &appt); err != nil {
+				if utils.IsScheduleConflict(err) {
+					conflicts = append(conflicts, SeriesConflict{Index: *appt.SeriesIndex, Date: appt.AppointmentDate, Reason: err.Error()})
+					continue
+				}
+				return err
+			}
+			if err := tx.Save(&appt).Error; err != nil {
+				return utils.BookingError(err)
+			}
+			updated = append(updated, appt)
+		}
+		if len(conflicts) > 0 {
+			return errSeriesConflict
+		}
+		return nil
+	})
+	if errors.Is(err, errSeriesConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Some occurrences conflict; nothing was changed", "conflicts": conflicts})
+		return
+	}
+	if utils.IsScheduleConflict(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		utils.Log.Warnf("UpdateAppointmentSeries: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	for _, appt := range updated {
+		appointmentCache.AppointmentInvalidate(appt.ID.String(), appt.DoctorID.String(), appt.PatientID.String(), appt.AppointmentDate.Format("2006-01-02"))
+		if appt.Status == models.AppointmentStatusConfirmed {
+			sendCalendarInvite(appt.ID, utils.ICalMethodRequest)
+			syncReminders(c.Request.Context(), appt)
+			syncMeeting(c.Request.Context(), appt)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Appointment series updated", "appointments": updated})
+}
+
+// CancelAppointmentSeries cancels one occurrence or the rest of a series under
+// the same rules as single cancellations: a reason code is required, patients
+// are held to the late-cancellation policy for every occurrence, and the
+// other party is told about each one.
+func CancelAppointmentSeries(c *gin.Context, appointmentCache *cache.Cache) {
+	user, _ := utils.GetCurrentUser(c)
+	series, ok := loadManagedSeries(c)
+	if !ok {
+		return
+	}
+
+	var input CancelSeriesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	settings, err := utils.GetClinicSettings(config.DB.WithContext(c.Request.Context()))
+	if err != nil {
+		utils.Log.Errorf("CancelAppointmentSeries: Failed to load clinic settings - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel appointment series"})
+		return
+	}
+
+	actor := utils.ActorFromClaims(user)
+	code := models.CancellationReason(input.ReasonCode)
+	now := time.Now()
+	var cancelled []models.Appointment
+	err = config.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		occurrences, err := seriesOccurrences(tx, series, input.SeriesOccurrenceInput)
+		if err != nil {
+			return err
+		}
+		for _, appt := range occurrences {
+			late := models.Role(user.Role) == models.RolePatient && utils.IsLateCancellation(appt, settings, now)
+			if late && settings.LateCancelAction == models.LateCancelBlock {
+				return errLateCancelBlocked
+			}
+			if err := cancelAppointment(tx, &appt, actor, code, input.Reason, late); err != nil {
+				return err
+			}
+			cancelled = append(cancelled, appt)
+		}
+		if input.Scope == "following" && len(occurrences) > 0 && *occurrences[0].SeriesIndex == 0 {
+			return tx.Model(&series).Update("cancelled_at", &now).Error
+		}
+		return nil
+	})
+	if errors.Is(err, errLateCancelBlocked) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":                   "An occurrence is too close to its start to cancel online; please contact the clinic",
+			"lateCancelWindowMinutes": settings.LateCancelWindowMinutes,
+		})
+		return
+	}
+	if err != nil {
+		utils.Log.Warnf("CancelAppointmentSeries: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lateCount := 0
+	for _, appt := range cancelled {
+		appointmentCache.AppointmentInvalidate(appt.ID.String(), appt.DoctorID.String(), appt.PatientID.String(), appt.AppointmentDate.Format("2006-01-02"))
+		sendCalendarInvite(appt.ID, utils.ICalMethodCancel)
+		CancelReminders(c.Request.Context(), appt.ID)
+		EndMeeting(c.Request.Context(), appt.ID)
+		notifyWaitlist(appt)
+		followUpCancellation(c.Request.Context(), appt, actor, code, input.Reason)
+		if appt.LateCancellation {
+			lateCount++
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Appointment series cancelled", "cancelled": len(cancelled), "lateCancellations": lateCount})
+}
@@ -0,0 +1,165 @@
+package appointments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/metrics"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/services/telehealth"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// joinLead is how long before the start the join link is handed out.
+const joinLead = 10 * time.Minute
+
+const modeOnline = "Online"
+
+// syncMeeting makes the video meeting match the appointment: confirmed Online
+// appointments get a fresh meeting, so links issued for an earlier time stop
+// mattering, and anything else loses it.
+func syncMeeting(ctx context.Context, appt models.Appointment) {
+	EndMeeting(ctx, appt.ID)
+	if appt.Status != models.AppointmentStatusConfirmed || appt.Mode != modeOnline || telehealth.Default == nil {
+		return
+	}
+
+	// Reload so the database-derived instants are current.
+	if err := config.DB.WithContext(ctx).First(&appt, "id = ?", appt.ID).Error; err != nil {
+		utils.Log.Warnf("syncMeeting: Appointment %s not found - %v", appt.ID, err)
+		return
+	}
+	start := utils.AppointmentStart(appt)
+	end := start
+	if appt.EndsAt != nil {
+		end = *appt.EndsAt
+	}
+
+	meeting, err := telehealth.Default.CreateMeeting(ctx, appt.ID.String(), start, end)
+	if err != nil {
+		utils.Log.Errorf("syncMeeting: Failed to create meeting - %v", err)
+		return
+	}
+	row := models.TelehealthMeeting{AppointmentID: appt.ID, Provider: meeting.Provider, ExternalID: meeting.ExternalID}
+	if err := config.DB.WithContext(ctx).Create(&row).Error; err != nil {
+		utils.Log.Errorf("syncMeeting: Failed to store meeting - %v", err)
+		telehealth.Default.DeleteMeeting(ctx, meeting)
+	}
+}
+
+// EndMeeting removes an appointment's video meeting, if it has one.
+func EndMeeting(ctx context.Context, appointmentID uuid.UUID) {
+	var row models.TelehealthMeeting
+	err := config.DB.WithContext(ctx).Where("appointment_id = ?", appointmentID).Limit(1).Find(&row).Error
+	if err != nil {
+		utils.Log.Errorf("EndMeeting: Failed to load meeting - %v", err)
+		return
+	}
+	if row.ID == uuid.Nil {
+		return
+	}
+	if telehealth.Default != nil && telehealth.Default.Name() == row.Provider {
+		meeting := telehealth.Meeting{Provider: row.Provider, ExternalID: row.ExternalID}
+		if err := telehealth.Default.DeleteMeeting(ctx, meeting); err != nil {
+			utils.Log.Warnf("EndMeeting: Failed to delete meeting %s - %v", row.ExternalID, err)
+		}
+	}
+	if err := config.DB.WithContext(ctx).Delete(&row).Error; err != nil {
+		utils.Log.Errorf("EndMeeting: Failed to delete meeting row - %v", err)
+	}
+}
+
+// GetJoinLink hands the appointment's patient or doctor a personal join link.
+// Links are only issued from shortly before the start until the end, and
+// expire at the end.
+func GetJoinLink(c *gin.Context) {
+	user, err := utils.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var appt models.Appointment
+	var row models.TelehealthMeeting
+	err = metrics.DbMetrics(config.DB, "get_join_link", func(db *gorm.DB) error {
+		db = db.WithContext(c.Request.Context())
+		if err := db.First(&appt, "id = ?", c.Param("id")).Error; err != nil {
+			return err
+		}
+		return db.Where("appointment_id = ?", appt.ID).Limit(1).Find(&row).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Appointment not found"})
+		return
+	}
+	if err != nil {
+		utils.Log.Errorf("GetJoinLink: Database error - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch the join link"})
+		return
+	}
+
+	// Admins manage appointments but do not join the call.
+	if models.Role(user.Role) == models.RoleAdmin || !canManageAppointment(c, user, appt) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the patient and doctor can join this appointment"})
+		return
+	}
+	if appt.Mode != modeOnline || appt.Status != models.AppointmentStatusConfirmed || row.ID == uuid.Nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "This appointment has no video meeting"})
+		return
+	}
+
+	start := utils.AppointmentStart(appt)
+	end := start
+	if appt.EndsAt != nil {
+		end = *appt.EndsAt
+	}
+	opensAt := start.Add(-joinLead)
+	now := time.Now()
+	if now.Before(opensAt) || !now.Before(end) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "The meeting can only be joined during the appointment", "opensAt": opensAt, "closesAt": end})
+		return
+	}
+	if telehealth.Default == nil || telehealth.Default.Name() != row.Provider {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Video meetings are not available"})
+		return
+	}
+
+	var participant telehealth.Participant
+	var contact utils.Contact
+	if models.Role(user.Role) == models.RoleDoctor {
+		participant.Role = "doctor"
+		contact, err = utils.GetDoctorContact(config.DB.WithContext(c.Request.Context()), appt.DoctorID)
+	} else {
+		participant.Role = "patient"
+		contact, err = utils.GetPatientContact(config.DB.WithContext(c.Request.Context()), appt.PatientID)
+	}
+	if err == nil {
+		participant.Name = contact.FirstName + " " + contact.LastName
+	}
+
+	meeting := telehealth.Meeting{Provider: row.Provider, ExternalID: row.ExternalID}
+	url, err := telehealth.Default.JoinURL(meeting, participant, end)
+	if err != nil {
+		utils.Log.Errorf("GetJoinLink: Failed to issue join link - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch the join link"})
+		return
+	}
+
+	// The patient's first join is their attendance, see MarkNoShows.
+	if participant.Role == "patient" {
+		err := config.DB.WithContext(c.Request.Context()).Model(&models.Appointment{}).
+			Where("id = ? AND patient_joined_at IS NULL", appt.ID).
+			Update("patient_joined_at", now).Error
+		if err != nil {
+			utils.Log.Warnf("GetJoinLink: Failed to record join for %s - %v", appt.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"joinUrl": url, "expiresAt": end})
+}
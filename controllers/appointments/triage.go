@@ -0,0 +1,50 @@
+package appointments
+
+import (
+	"context"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/queue"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+// notifyEmergencyDelays emails the patients whose visits an emergency pushes
+// back. Their bookings keep their times; the emergency is seen first.
+func notifyEmergencyDelays(ctx context.Context, emergencyID uuid.UUID) {
+	var emergency models.Appointment
+	if err := config.DB.WithContext(ctx).First(&emergency, "id = ?", emergencyID).Error; err != nil {
+		utils.Log.Warnf("notifyEmergencyDelays: Appointment %s not found - %v", emergencyID, err)
+		return
+	}
+	delayed, err := utils.EmergencyDelays(config.DB.WithContext(ctx), emergency)
+	if err != nil {
+		utils.Log.Errorf("notifyEmergencyDelays: Failed to load affected appointments - %v", err)
+		return
+	}
+	if len(delayed) > 0 {
+		utils.Log.Infof("notifyEmergencyDelays: Emergency %s delays %d appointments", emergencyID, len(delayed))
+	}
+	if queue.Client == nil {
+		return
+	}
+
+	for _, d := range delayed {
+		contact, err := utils.GetPatientContact(config.DB.WithContext(ctx), d.Appointment.PatientID)
+		if err != nil || contact.Email == "" {
+			utils.Log.Warnf("notifyEmergencyDelays: No email for patient %s - %v", d.Appointment.PatientID, err)
+			continue
+		}
+		tmpl := utils.GetEmergencyDelayTemplate(d.Appointment.AppointmentDate.Format("2006-01-02"), d.Appointment.StartTime, d.Delay)
+		task, err := queue.NewEmailTask(contact.Email, tmpl.Subject, tmpl.Body)
+		if err != nil {
+			utils.Log.Errorf("notifyEmergencyDelays: Failed to create email task - %v", err)
+			continue
+		}
+		if _, err := queue.Client.Enqueue(task, asynq.Queue("emails"), asynq.MaxRetry(3)); err != nil {
+			utils.Log.Errorf("notifyEmergencyDelays: Failed to enqueue email - %v", err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package appointments
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/metrics"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/services/cache"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errVisitAlreadyRecorded = errors.New("a medical record already exists for this appointment")
+
+// CompleteVisitInput is the clinical outcome of a visit.
+type CompleteVisitInput struct {
+	Diagnosis     string                   `json:"diagnosis" binding:"required"`
+	Notes         string                   `json:"notes"`
+	Vitals        []VisitVitalInput        `json:"vitals" binding:"dive"`
+	Prescriptions []VisitPrescriptionInput `json:"prescriptions" binding:"dive"`
+}
+
+// VisitVitalInput is a vital sign taken at the visit. RecordedAt defaults to
+// the time the visit is completed.
+type VisitVitalInput struct {
+	Type       string     `json:"type" binding:"required,oneof=BLOOD_PRESSURE HEART_RATE WEIGHT BMI TEMPERATURE RESPIRATORY_RATE OXYGEN_SATURATION"`
+	Value      string     `json:"value" binding:"required"`
+	Status     string     `json:"status" binding:"required"`
+	RecordedAt *time.Time `json:"recorded_at"`
+}
+
+type VisitPrescriptionInput struct {
+	Medication   string `json:"medication" binding:"required"`
+	Dosage       string `json:"dosage" binding:"required"`
+	Instructions string `json:"instructions"`
+}
+
+// CompleteVisit marks an appointment COMPLETED and, in the same transaction,
+// writes its medical record with the vitals taken and prescriptions issued,
+// all linked to the appointment. Only the appointment's doctor or an admin
+// may complete it, and a visit is recorded once.
+func CompleteVisit(c *gin.Context, appointmentCache *cache.Cache) {
+	user, err := utils.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var input CompleteVisitInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	var appointment models.Appointment
+	if err := config.DB.WithContext(c.Request.Context()).First(&appointment, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Appointment not found"})
+		return
+	}
+	if !canManageAppointment(c, user, appointment) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	now := time.Now()
+	record := models.MedicalRecord{
+		PatientID:     appointment.PatientID,
+		DoctorID:      appointment.DoctorID,
+		Diagnosis:     input.Diagnosis,
+		Notes:         input.Notes,
+		AppointmentID: &appointment.ID,
+	}
+	prescriptions := make([]models.Prescription, 0, len(input.Prescriptions))
+	err = metrics.DbMetrics(config.DB, "complete_visit", func(db *gorm.DB) error {
+		return db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&appointment, "id = ?", appointment.ID).Error; err != nil {
+				return err
+			}
+			var existing int64
+			if err := tx.Model(&models.MedicalRecord{}).Where("appointment_id = ? AND deleted_at IS NULL", appointment.ID).Count(&existing).Error; err != nil {
+				return err
+			}
+			if existing > 0 {
+				return errVisitAlreadyRecorded
+			}
+
+			if err := utils.TransitionAppointment(tx, &appointment, models.AppointmentStatusCompleted, utils.ActorFromClaims(user), "Visit completed"); err != nil {
+				return err
+			}
+			if err := tx.Create(&record).Error; err != nil {
+				return err
+			}
+
+			for _, v := range input.Vitals {
+				recordedAt := now
+				if v.RecordedAt != nil {
+					recordedAt = *v.RecordedAt
+				}
+				record.Vitals = append(record.Vitals, models.Vital{
+					ID:              uuid.New(),
+					PatientID:       appointment.PatientID,
+					Type:            models.VitalType(v.Type),
+					Value:           v.Value,
+					Status:          v.Status,
+					RecordedAt:      recordedAt,
+					MedicalRecordID: &record.ID,
+					AppointmentID:   &appointment.ID,
+				})
+			}
+			if len(record.Vitals) > 0 {
+				if err := tx.Omit(clause.Associations).Create(&record.Vitals).Error; err != nil {
+					return err
+				}
+			}
+
+			for _, p := range input.Prescriptions {
+				prescriptions = append(prescriptions, models.Prescription{
+					ID:              uuid.New(),
+					PatientID:       appointment.PatientID,
+					DoctorID:        appointment.DoctorID,
+					Medication:      p.Medication,
+					Dosage:          p.Dosage,
+					Instructions:    p.Instructions,
+					IssuedAt:        now,
+					MedicalRecordID: &record.ID,
+					AppointmentID:   &appointment.ID,
+				})
+			}
+			if len(prescriptions) > 0 {
+				return tx.Omit(clause.Associations).Create(&prescriptions).Error
+			}
+			return nil
+		})
+	})
+	if errors.Is(err, errVisitAlreadyRecorded) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		utils.Log.Warnf("CompleteVisit: %v", err)
+		respondTransitionError(c, err)
+		return
+	}
+
+	patientID := appointment.PatientID.String()
+	appointmentCache.AppointmentInvalidate(appointment.ID.String(), appointment.DoctorID.String(), patientID, appointment.AppointmentDate.Format("2006-01-02"))
+	appointmentCache.MedicalRecordInvalidate(record.ID.String(), patientID)
+	appointmentCache.VitalsInvalidate(patientID)
+	appointmentCache.PrescriptionInvalidate(patientID)
+	syncReminders(c.Request.Context(), appointment)
+	syncMeeting(c.Request.Context(), appointment)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":        "Visit completed",
+		"appointment":    appointment,
+		"medical_record": record,
+		"prescriptions":  prescriptions,
+	})
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 	"time"
@@ -16,6 +17,15 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	// emailVerificationTTL and passwordResetTTL are how long the codes sent
+	// for each flow stay valid.
+	emailVerificationTTL = 15 * time.Minute
+	passwordResetTTL     = 10 * time.Minute
+	// otpSendCooldown is the least time between two codes for one email.
+	otpSendCooldown = time.Minute
+)
+
 func SignUp(c *gin.Context) {
 	var input struct {
 		FirstName string `json:"firstname" binding:"required"`
@@ -105,8 +115,128 @@ func SignUp(c *gin.Context) {
 		}
 	}
 
+	if err := sendVerificationCode(input.Email, false); err != nil {
+		utils.Log.Errorf("SignUp: Failed to send verification code - %v", err)
+	}
+
 	utils.Log.Infof("SignUp: User successfully signed up")
-	c.JSON(http.StatusOK, gin.H{"message": "User signed up successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "User signed up successfully; check your email for a verification code"})
+}
+
+// sendVerificationCode stores a fresh verification code for email and queues
+// it for delivery. Any earlier code stops working. Nothing is sent while the
+// email is in its send cooldown.
+func sendVerificationCode(email string, resend bool) error {
+	tmpl := utils.GetEmailVerificationTemplate
+	if resend {
+		tmpl = utils.GetResendVerificationTemplate
+	}
+	return sendOTP(cache.OTPEmailVerification, email, emailVerificationTTL, tmpl)
+}
+
+// sendOTP issues a code for purpose and queues it rendered with tmpl.
+func sendOTP(purpose, email string, ttl time.Duration, tmpl func(string, time.Duration) utils.EmailTemplate) error {
+	allowed, err := cache.AllowOTPSend(purpose, email, otpSendCooldown)
+	if err != nil || !allowed {
+		return err
+	}
+
+	otp, err := utils.GenerateOTP(6)
+	if err != nil {
+		return err
+	}
+	if err := cache.SaveOTP(purpose, email, utils.HashOTP(email, otp), ttl); err != nil {
+		return err
+	}
+
+	message := tmpl(otp, ttl)
+	task, err := queue.NewEmailTask(email, message.Subject, message.Body)
+	if err != nil {
+		return err
+	}
+	_, err = queue.Client.Enqueue(task, asynq.Queue("emails"), asynq.MaxRetry(3))
+	return err
+}
+
+// respondOTPError maps a rejected code to a reply.
+func respondOTPError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, cache.ErrOTPLocked):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, cache.ErrOTPInvalid):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	default:
+		utils.Log.Errorf("respondOTPError: Failed to check code - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check code"})
+	}
+}
+
+// VerifyEmail marks the address verified when the code matches.
+func VerifyEmail(c *gin.Context) {
+	var input struct {
+		Email string `json:"email" binding:"required,email"`
+		OTP   string `json:"otp" binding:"required,len=6,numeric"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var auth models.Auth
+	err := metrics.DbMetrics(config.DB, "VerifyEmail", func(db *gorm.DB) error {
+		return db.Where("email = ?", input.Email).First(&auth).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email or code"})
+		return
+	}
+	if auth.VerifiedAt != nil {
+		c.JSON(http.StatusOK, gin.H{"message": "Email already verified"})
+		return
+	}
+
+	restoreCode, err := cache.VerifyOTP(cache.OTPEmailVerification, input.Email, utils.HashOTP(input.Email, input.OTP))
+	if err != nil {
+		utils.Log.Warnf("VerifyEmail: Rejected code - %v", err)
+		respondOTPError(c, err)
+		return
+	}
+
+	err = metrics.DbMetrics(config.DB, "VerifyEmail", func(db *gorm.DB) error {
+		return db.Model(&auth).Where("verified_at IS NULL").Update("verified_at", time.Now()).Error
+	})
+	if err != nil {
+		restoreCode()
+		utils.Log.Errorf("VerifyEmail: Failed to mark email verified - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
+}
+
+// ResendVerification sends a new code to an unverified address. The reply is
+// the same whether or not the address is registered.
+func ResendVerification(c *gin.Context) {
+	var input struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var auth models.Auth
+	err := metrics.DbMetrics(config.DB, "ResendVerification", func(db *gorm.DB) error {
+		return db.Where("email = ?", input.Email).First(&auth).Error
+	})
+	if err == nil && auth.VerifiedAt == nil {
+		if err := sendVerificationCode(input.Email, true); err != nil {
+			utils.Log.Errorf("ResendVerification: Failed to send verification code - %v", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If the address is awaiting verification, a new code has been sent"})
 }
 
 func Login(c *gin.Context) {
@@ -136,6 +266,12 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	if auth.VerifiedAt == nil {
+		utils.Log.Warnf("Login: Email not verified")
+		c.JSON(http.StatusForbidden, gin.H{"error": "Email address not verified", "code": "EMAIL_NOT_VERIFIED"})
+		return
+	}
+
 	var user models.User
 	if err := metrics.DbMetrics(config.DB, "Login", func(db *gorm.DB) error {
 		return db.Where("auth_id = ?", auth.ID).First(&user).Error
@@ -267,98 +403,48 @@ func Logout(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "User logged out successfully"})
 }
 
+// ForgotPassword emails a password reset code. The reply is the same whether
+// or not the address has an account.
 func ForgotPassword(c *gin.Context) {
-	var input struct {
-		Email string `json:"email" binding:"required,email"`
-	}
-
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	var auth models.Auth
-	err := metrics.DbMetrics(config.DB, "ForgotPassword", func(db *gorm.DB) error {
-		return db.Where("email = ?", input.Email).First(&auth).Error
-	})
-
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Email not found"})
-		return
-	}
-
-	otp, err := utils.GenerateOTP(6)
-	if err != nil {
-		utils.Log.Errorf("ForgotPassword: Failed to generate OTP - %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate OTP"})
-		return
-	}
-	if err := cache.SaveOTP(input.Email, otp, 10); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store OTP"})
-		return
-	}
-
-	task, err := queue.NewOTPEmailTask(input.Email, otp)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task"})
-		return
-	}
-
-	_, err = queue.Client.Enqueue(task, asynq.Queue("emails"), asynq.MaxRetry(3))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enqueue email"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "OTP sent to your email"})
+	requestPasswordReset(c, "ForgotPassword")
 }
 
+// ResendOTP replaces the reset code with a new one, subject to the same
+// cooldown as the first.
 func ResendOTP(c *gin.Context) {
+	requestPasswordReset(c, "ResendOTP")
+}
+
+func requestPasswordReset(c *gin.Context, caller string) {
 	var input struct {
 		Email string `json:"email" binding:"required,email"`
 	}
-
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	var auth models.Auth
-	err := metrics.DbMetrics(config.DB, "ResendOTP", func(db *gorm.DB) error {
+	err := metrics.DbMetrics(config.DB, caller, func(db *gorm.DB) error {
 		return db.Where("email = ?", input.Email).First(&auth).Error
 	})
-
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Email not found"})
-		return
-	}
-
-	otp, err := utils.GenerateOTP(6)
-	if err != nil {
-		utils.Log.Errorf("ResendOTP: Failed to generate OTP - %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate OTP"})
-		return
-	}
-	if err := cache.SaveOTP(input.Email, otp, 10); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update OTP"})
-		return
-	}
-
-	task, err := queue.NewOTPEmailTask(input.Email, otp)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create email task"})
-		return
-	}
-
-	_, err = queue.Client.Enqueue(task, asynq.Queue("emails"), asynq.MaxRetry(3))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enqueue email"})
-		return
+	switch {
+	case err == nil:
+		// Failures are only logged, since reporting them would reveal
+		// that the account exists.
+		if err := sendOTP(cache.OTPPasswordReset, auth.Email, passwordResetTTL, utils.GetForgotPasswordOTPTemplate); err != nil {
+			utils.Log.Errorf("%s: Failed to send reset code - %v", caller, err)
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		utils.Log.Errorf("%s: Failed to look up account - %v", caller, err)
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "OTP resent successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "If an account exists for this address, a reset code has been sent"})
 }
 
+// ResetPassword sets a new password with a reset code. A successful reset
+// verifies the address, since the code was delivered to it, and signs the
+// account out everywhere by revoking its refresh tokens.
 func ResetPassword(c *gin.Context) {
 	var req struct {
 		Email       string `json:"email" binding:"required,email"`
@@ -371,29 +457,46 @@ func ResetPassword(c *gin.Context) {
 		return
 	}
 
-	// Fetch user from DB
-	var auth models.Auth
-	if err := config.DB.Where("email = ?", req.Email).First(&auth).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email or token"})
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
 		return
 	}
 
-	// Verify OTP token from Redis
-	if err := cache.VerifyOTP(req.Email, req.ResetToken); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	// The code is checked before the account is looked up so unknown emails
+	// fail the same way. It is put back if the reset cannot be saved.
+	restoreCode, err := cache.VerifyOTP(cache.OTPPasswordReset, req.Email, utils.HashOTP(req.Email, req.ResetToken))
+	if err != nil {
+		utils.Log.Warnf("ResetPassword: Rejected code - %v", err)
+		respondOTPError(c, err)
 		return
 	}
 
-	// Hash the new password
-	hashedPassword, err := utils.HashPassword(req.NewPassword)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+	var auth models.Auth
+	err = metrics.DbMetrics(config.DB, "ResetPassword", func(db *gorm.DB) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("email = ?", req.Email).First(&auth).Error; err != nil {
+				return err
+			}
+			err := tx.Model(&auth).Updates(map[string]interface{}{
+				"password":    hashedPassword,
+				"verified_at": gorm.Expr("COALESCE(verified_at, ?)", time.Now()),
+			}).Error
+			if err != nil {
+				return err
+			}
+			return tx.Model(&models.RefreshToken{}).
+				Where("revoked = false AND user_id IN (?)", tx.Model(&models.User{}).Select("id").Where("auth_id = ?", auth.ID)).
+				Update("revoked", true).Error
+		})
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": cache.ErrOTPInvalid.Error()})
 		return
 	}
-
-	// Update user password in DB
-	auth.Password = hashedPassword
-	if err := config.DB.Save(&auth).Error; err != nil {
+	if err != nil {
+		restoreCode()
+		utils.Log.Errorf("ResetPassword: Failed to update password - %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
 		return
 	}
@@ -401,11 +504,11 @@ func ResetPassword(c *gin.Context) {
 	// Push password reset confirmation email to queue
 	emailTemplate := utils.GetPasswordResetSuccessTemplate()
 	task, err := queue.ResetEmailTask(auth.Email, emailTemplate.Subject, emailTemplate.Body)
-	if err != nil {
-		utils.Log.Warnf("ResetPassword: failed to create email task for %s: %v", auth.Email, err)
+	if err == nil {
+		_, err = queue.Client.Enqueue(task, asynq.Queue("emails"), asynq.MaxRetry(3))
 	}
-	if _, err := queue.Client.Enqueue(task, asynq.Queue("emails"), asynq.MaxRetry(3)); err != nil {
-		utils.Log.Warnf("ResetPassword: failed to enqueue email for %s: %v", auth.Email, err)
+	if err != nil {
+		utils.Log.Warnf("ResetPassword: failed to queue confirmation email - %v", err)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
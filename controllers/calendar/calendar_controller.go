@@ -0,0 +1,111 @@
+package calendar
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/metrics"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// feedHistory is how far back a feed reaches, so recent visits stay visible
+// in calendar apps.
+const feedHistory = 30 * 24 * time.Hour
+
+// CreateFeedToken issues the caller a feed URL. Any earlier token is replaced,
+// which is also how a leaked URL is rotated.
+func CreateFeedToken(c *gin.Context) {
+	user, err := utils.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	token, err := utils.GenerateRefreshToken()
+	if err != nil {
+		utils.Log.Errorf("CreateFeedToken: Failed to generate token - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create feed token"})
+		return
+	}
+
+	feedToken := models.CalendarFeedToken{UserID: user.UserID, TokenHash: utils.HashToken(token), CreatedAt: time.Now()}
+	err = metrics.DbMetrics(config.DB, "create_calendar_feed_token", func(db *gorm.DB) error {
+		return db.WithContext(c.Request.Context()).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"token_hash", "created_at"}),
+		}).Create(&feedToken).Error
+	})
+	if err != nil {
+		utils.Log.Errorf("CreateFeedToken: Failed to store token - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create feed token"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Calendar feed created; the previous feed URL no longer works",
+		"url":     "/calendar/feed/" + token + ".ics",
+	})
+}
+
+func RevokeFeedToken(c *gin.Context) {
+	user, err := utils.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	err = metrics.DbMetrics(config.DB, "revoke_calendar_feed_token", func(db *gorm.DB) error {
+		return db.WithContext(c.Request.Context()).Where("user_id = ?", user.UserID).Delete(&models.CalendarFeedToken{}).Error
+	})
+	if err != nil {
+		utils.Log.Errorf("RevokeFeedToken: Failed to delete token - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not revoke feed token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Calendar feed revoked"})
+}
+
+// GetCalendarFeed serves a user's appointments as an iCalendar subscription.
+// The token in the URL is the only credential, as calendar apps cannot send
+// an Authorization header.
+func GetCalendarFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	var feedToken models.CalendarFeedToken
+	err := metrics.DbMetrics(config.DB, "get_calendar_feed_token", func(db *gorm.DB) error {
+		return db.WithContext(c.Request.Context()).First(&feedToken, "token_hash = ?", utils.HashToken(token)).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Calendar feed not found"})
+		return
+	}
+
+	var appts []models.Appointment
+	err = metrics.DbMetrics(config.DB, "get_calendar_feed", func(db *gorm.DB) error {
+		return db.WithContext(c.Request.Context()).
+			Where("patient_id IN (SELECT id FROM patients WHERE user_id = ?) OR doctor_id IN (SELECT id FROM doctors WHERE user_id = ?)", feedToken.UserID, feedToken.UserID).
+			Where("starts_at >= ?", time.Now().Add(-feedHistory)).
+			Order("starts_at asc").
+			Find(&appts).Error
+	})
+	if err != nil {
+		utils.Log.Errorf("GetCalendarFeed: Failed to fetch appointments - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not build calendar feed"})
+		return
+	}
+
+	events := make([]utils.CalendarEvent, 0, len(appts))
+	for _, appt := range appts {
+		events = append(events, utils.AppointmentEvent(appt))
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", utils.BuildICS("", events...))
+}
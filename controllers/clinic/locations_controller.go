@@ -0,0 +1,236 @@
+package clinic
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/metrics"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LocationInput describes a site. TimeZone is left empty for sites on the
+// clinic's zone.
+type LocationInput struct {
+	Name     string `json:"name" binding:"required"`
+	Address  string `json:"address"`
+	Phone    string `json:"phone"`
+	TimeZone string `json:"time_zone"`
+}
+
+type RoomInput struct {
+	Name     string `json:"name" binding:"required"`
+	IsActive *bool  `json:"is_active"`
+}
+
+func validLocationZone(c *gin.Context, input LocationInput) bool {
+	if input.TimeZone == "" {
+		return true
+	}
+	if _, err := utils.LoadTimeZone(input.TimeZone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// GetLocations lists the clinic's locations with their rooms.
+func GetLocations(c *gin.Context) {
+	var locations []models.Location
+	err := metrics.DbMetrics(config.DB, "get_locations", func(db *gorm.DB) error {
+		return db.WithContext(c.Request.Context()).
+			Preload("Rooms", func(db *gorm.DB) *gorm.DB { return db.Order("name asc") }).
+			Order("name asc").
+			Find(&locations).Error
+	})
+	if err != nil {
+		utils.Log.Errorf("GetLocations: Failed to fetch locations - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch locations"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"locations": locations})
+}
+
+func CreateLocation(c *gin.Context) {
+	var input LocationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	if !validLocationZone(c, input) {
+		return
+	}
+
+	location := models.Location{Name: input.Name, Address: input.Address, Phone: input.Phone, TimeZone: input.TimeZone}
+	err := metrics.DbMetrics(config.DB, "create_location", func(db *gorm.DB) error {
+		return db.WithContext(c.Request.Context()).Create(&location).Error
+	})
+	if utils.IsUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A location with this name already exists"})
+		return
+	}
+	if err != nil {
+		utils.Log.Errorf("CreateLocation: Failed to create location - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create location"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Location created", "location": location})
+}
+
+func UpdateLocation(c *gin.Context) {
+	var input LocationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	if !validLocationZone(c, input) {
+		return
+	}
+
+	var location models.Location
+	err := metrics.DbMetrics(config.DB, "update_location", func(db *gorm.DB) error {
+		db = db.WithContext(c.Request.Context())
+		if err := db.First(&location, "id = ?", c.Param("id")).Error; err != nil {
+			return err
+		}
+		location.Name, location.Address, location.Phone, location.TimeZone = input.Name, input.Address, input.Phone, input.TimeZone
+		return db.Save(&location).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Location not found"})
+		return
+	}
+	if utils.IsUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A location with this name already exists"})
+		return
+	}
+	if err != nil {
+		utils.Log.Errorf("UpdateLocation: Failed to update location - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update location"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Location updated", "location": location})
+}
+
+// DeleteLocation removes a location and its rooms. Working hours and
+// appointments there keep their times but lose the location.
+func DeleteLocation(c *gin.Context) {
+	var result *gorm.DB
+	err := metrics.DbMetrics(config.DB, "delete_location", func(db *gorm.DB) error {
+		result = db.WithContext(c.Request.Context()).Delete(&models.Location{}, "id = ?", c.Param("id"))
+		return result.Error
+	})
+	if err != nil {
+		utils.Log.Errorf("DeleteLocation: Failed to delete location - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not delete location"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Location not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Location deleted"})
+}
+
+func CreateRoom(c *gin.Context) {
+	locationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+		return
+	}
+	var input RoomInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	room := models.Room{LocationID: locationID, Name: input.Name, IsActive: true}
+	if input.IsActive != nil {
+		room.IsActive = *input.IsActive
+	}
+	err = metrics.DbMetrics(config.DB, "create_room", func(db *gorm.DB) error {
+		db = db.WithContext(c.Request.Context())
+		if err := db.First(&models.Location{}, "id = ?", locationID).Error; err != nil {
+			return err
+		}
+		// Create skips zero-valued fields that have a default, so an
+		// inactive room is written explicitly.
+		if err := db.Create(&room).Error; err != nil {
+			return err
+		}
+		return db.Model(&room).Update("is_active", room.IsActive).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Location not found"})
+		return
+	}
+	if utils.IsUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "This location already has a room with that name"})
+		return
+	}
+	if err != nil {
+		utils.Log.Errorf("CreateRoom: Failed to create room - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create room"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Room created", "room": room})
+}
+
+// UpdateRoom renames a room or takes it out of use. Appointments already in
+// an inactive room keep it; new bookings skip it.
+func UpdateRoom(c *gin.Context) {
+	var input RoomInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	var room models.Room
+	err := metrics.DbMetrics(config.DB, "update_room", func(db *gorm.DB) error {
+		db = db.WithContext(c.Request.Context())
+		if err := db.First(&room, "id = ?", c.Param("id")).Error; err != nil {
+			return err
+		}
+		room.Name = input.Name
+		if input.IsActive != nil {
+			room.IsActive = *input.IsActive
+		}
+		return db.Save(&room).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+		return
+	}
+	if utils.IsUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "This location already has a room with that name"})
+		return
+	}
+	if err != nil {
+		utils.Log.Errorf("UpdateRoom: Failed to update room - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update room"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Room updated", "room": room})
+}
+
+func DeleteRoom(c *gin.Context) {
+	var result *gorm.DB
+	err := metrics.DbMetrics(config.DB, "delete_room", func(db *gorm.DB) error {
+		result = db.WithContext(c.Request.Context()).Delete(&models.Room{}, "id = ?", c.Param("id"))
+		return result.Error
+	})
+	if err != nil {
+		utils.Log.Errorf("DeleteRoom: Failed to delete room - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not delete room"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Room deleted"})
+}
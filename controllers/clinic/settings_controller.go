@@ -0,0 +1,117 @@
+package clinic
+
+import (
+	"net/http"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/metrics"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/services/cache"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ClinicSettingsInput updates the fields that are present and leaves the
+// rest unchanged.
+type ClinicSettingsInput struct {
+	// ReminderOffsetsMinutes replaces the reminder schedule. An empty list
+	// turns reminders off.
+	ReminderOffsetsMinutes *[]int64 `json:"reminderOffsetsMinutes"`
+	NoShowGraceMinutes     *int     `json:"noShowGraceMinutes" binding:"omitempty,min=0,max=1440"`
+	// NoShowLimit of 0 turns the attendance policy off.
+	NoShowLimit      *int    `json:"noShowLimit" binding:"omitempty,min=0,max=100"`
+	NoShowWindowDays *int    `json:"noShowWindowDays" binding:"omitempty,min=1,max=365"`
+	NoShowAction     *string `json:"noShowAction" binding:"omitempty,oneof=REQUIRE_APPROVAL BLOCK"`
+	// LateCancelWindowMinutes of 0 turns the late-cancellation policy off.
+	LateCancelWindowMinutes *int    `json:"lateCancelWindowMinutes" binding:"omitempty,min=0,max=20160"`
+	LateCancelAction        *string `json:"lateCancelAction" binding:"omitempty,oneof=FLAG BLOCK"`
+	// TimeZone is the IANA zone of the clinic's calendar.
+	TimeZone *string `json:"timeZone"`
+}
+
+func GetClinicSettings(c *gin.Context) {
+	var settings models.ClinicSettings
+	err := metrics.DbMetrics(config.DB, "get_clinic_settings", func(db *gorm.DB) error {
+		var err error
+		settings, err = utils.GetClinicSettings(db.WithContext(c.Request.Context()))
+		return err
+	})
+	if err != nil {
+		utils.Log.Errorf("GetClinicSettings: Failed to load settings - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch clinic settings"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"settings": settings})
+}
+
+// UpdateClinicSettings changes clinic-wide settings. New reminder offsets
+// apply to appointments confirmed afterwards; the attendance and cancellation
+// policies apply to the next booking or cancellation. Changing the time zone
+// moves holidays to the new calendar but leaves doctors' zones alone.
+func UpdateClinicSettings(c *gin.Context, scheduleCache *cache.Cache) {
+	var input ClinicSettingsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	settings, err := utils.GetClinicSettings(config.DB.WithContext(c.Request.Context()))
+	if err != nil {
+		utils.Log.Errorf("UpdateClinicSettings: Failed to load settings - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update clinic settings"})
+		return
+	}
+
+	if input.ReminderOffsetsMinutes != nil {
+		offsets, err := utils.NormalizeReminderOffsets(*input.ReminderOffsetsMinutes)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		settings.ReminderOffsetsMinutes = offsets
+	}
+	if input.NoShowGraceMinutes != nil {
+		settings.NoShowGraceMinutes = *input.NoShowGraceMinutes
+	}
+	if input.NoShowLimit != nil {
+		settings.NoShowLimit = *input.NoShowLimit
+	}
+	if input.NoShowWindowDays != nil {
+		settings.NoShowWindowDays = *input.NoShowWindowDays
+	}
+	if input.NoShowAction != nil {
+		settings.NoShowAction = models.NoShowAction(*input.NoShowAction)
+	}
+	if input.LateCancelWindowMinutes != nil {
+		settings.LateCancelWindowMinutes = *input.LateCancelWindowMinutes
+	}
+	if input.LateCancelAction != nil {
+		settings.LateCancelAction = models.LateCancelAction(*input.LateCancelAction)
+	}
+	zoneChanged := false
+	if input.TimeZone != nil {
+		loc, err := utils.LoadTimeZone(*input.TimeZone)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		zoneChanged = loc.String() != settings.TimeZone
+		settings.TimeZone = loc.String()
+	}
+
+	err = metrics.DbMetrics(config.DB, "update_clinic_settings", func(db *gorm.DB) error {
+		return db.WithContext(c.Request.Context()).Clauses(clause.OnConflict{UpdateAll: true}).Create(&settings).Error
+	})
+	if err != nil {
+		utils.Log.Errorf("UpdateClinicSettings: Failed to save settings - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update clinic settings"})
+		return
+	}
+
+	if zoneChanged {
+		scheduleCache.AllSchedulesInvalidate()
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Clinic settings updated", "settings": settings})
+}
@@ -0,0 +1,266 @@
+package schedule
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/metrics"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/services/cache"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const dateLayout = "2006-01-02"
+
+type ScheduleExceptionInput struct {
+	DoctorID  *uuid.UUID `json:"doctor_id"`
+	Type      string     `json:"type" binding:"required,oneof=TIME_OFF EXTRA_HOURS"`
+	StartDate string     `json:"start_date" binding:"required"`
+	EndDate   string     `json:"end_date"`
+	StartTime string     `json:"start_time"`
+	EndTime   string     `json:"end_time"`
+	Reason    string     `json:"reason"`
+}
+
+type ClinicHolidayInput struct {
+	Date string `json:"date" binding:"required"`
+	Name string `json:"name" binding:"required"`
+}
+
+func CreateScheduleException(c *gin.Context, scheduleCache *cache.Cache) {
+	user, _ := utils.GetCurrentUser(c)
+
+	var input ScheduleExceptionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Log.Warnf("CreateScheduleException: Invalid input - %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	doctorID, status, err := resolveDoctorID(c, input.DoctorID)
+	if err != nil {
+		utils.Log.Warnf("CreateScheduleException: %v", err)
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	startDate, err := time.Parse(dateLayout, input.StartDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date. Use YYYY-MM-DD"})
+		return
+	}
+	endDate := startDate
+	if input.EndDate != "" {
+		if endDate, err = time.Parse(dateLayout, input.EndDate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date. Use YYYY-MM-DD"})
+			return
+		}
+	}
+	if endDate.Before(startDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must not be before start_date"})
+		return
+	}
+
+	exception := models.ScheduleException{
+		DoctorID:  doctorID,
+		Type:      models.ScheduleExceptionType(input.Type),
+		StartDate: startDate,
+		EndDate:   endDate,
+		Reason:    input.Reason,
+		CreatedBy: &user.UserID,
+	}
+
+	if input.StartTime != "" || input.EndTime != "" {
+		start, err1 := utils.ParseClock(input.StartTime)
+		end, err2 := utils.ParseClock(input.EndTime)
+		if err1 != nil || err2 != nil || !end.After(start) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "start_time and end_time must be valid HH:MM times with end after start"})
+			return
+		}
+		exception.StartTime = &input.StartTime
+		exception.EndTime = &input.EndTime
+	} else if exception.Type == models.ScheduleExceptionExtraHours {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_time and end_time are required for extra hours"})
+		return
+	}
+
+	err = metrics.DbMetrics(config.DB, "create_schedule_exception", func(db *gorm.DB) error {
+		return db.WithContext(c).Create(&exception).Error
+	})
+	if err != nil {
+		utils.Log.Errorf("CreateScheduleException: Database error - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create schedule exception"})
+		return
+	}
+
+	// Bookings that already sit inside new time off are reported so they can
+	// be rescheduled; they are not cancelled automatically.
+	var affected []models.Appointment
+	if exception.Type == models.ScheduleExceptionTimeOff {
+		query := config.DB.WithContext(c).
+			Where("doctor_id = ? AND status NOT IN ?", doctorID, utils.TerminalStatuses()).
+			Where("DATE(appointment_date) BETWEEN ? AND ?", startDate.Format(dateLayout), endDate.Format(dateLayout))
+		if exception.StartTime != nil {
+			query = query.Where("start_time < ? AND end_time > ?", *exception.EndTime, *exception.StartTime)
+		}
+		if err := query.Find(&affected).Error; err != nil {
+			utils.Log.Warnf("CreateScheduleException: Failed to look up affected appointments - %v", err)
+		}
+	}
+
+	scheduleCache.DoctorScheduleInvalidate(doctorID.String())
+	c.JSON(http.StatusCreated, gin.H{
+		"message":               "Schedule exception created successfully",
+		"exception":             exception,
+		"affected_appointments": affected,
+	})
+}
+
+func GetScheduleExceptionsByDoctorID(c *gin.Context) {
+	doctorID := c.Param("id")
+	if _, err := uuid.Parse(doctorID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid doctor ID"})
+		return
+	}
+
+	query := config.DB.WithContext(c).Where("doctor_id = ?", doctorID)
+	if from := c.Query("from"); from != "" {
+		if _, err := time.Parse(dateLayout, from); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date. Use YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("end_date >= ?", from)
+	}
+	if to := c.Query("to"); to != "" {
+		if _, err := time.Parse(dateLayout, to); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date. Use YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("start_date <= ?", to)
+	}
+
+	var exceptions []models.ScheduleException
+	err := metrics.DbMetrics(query, "get_schedule_exceptions", func(db *gorm.DB) error {
+		return db.Order("start_date asc").Find(&exceptions).Error
+	})
+	if err != nil {
+		utils.Log.Errorf("GetScheduleExceptionsByDoctorID: Failed to fetch exceptions - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch schedule exceptions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"exceptions": exceptions})
+}
+
+func DeleteScheduleException(c *gin.Context, scheduleCache *cache.Cache) {
+	var exception models.ScheduleException
+	if err := config.DB.WithContext(c).First(&exception, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Schedule exception not found"})
+		return
+	}
+	if _, status, err := resolveDoctorID(c, &exception.DoctorID); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := metrics.DbMetrics(config.DB, "delete_schedule_exception", func(db *gorm.DB) error {
+		return db.WithContext(c).Delete(&exception).Error
+	})
+	if err != nil {
+		utils.Log.Errorf("DeleteScheduleException: Failed to delete exception - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete schedule exception"})
+		return
+	}
+
+	scheduleCache.DoctorScheduleInvalidate(exception.DoctorID.String())
+	c.JSON(http.StatusOK, gin.H{"message": "Schedule exception deleted"})
+}
+
+func CreateClinicHoliday(c *gin.Context, scheduleCache *cache.Cache) {
+	var input ClinicHolidayInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Log.Warnf("CreateClinicHoliday: Invalid input - %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	date, err := time.Parse(dateLayout, input.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date. Use YYYY-MM-DD"})
+		return
+	}
+
+	holiday := models.ClinicHoliday{Date: date, Name: input.Name}
+	err = metrics.DbMetrics(config.DB, "create_clinic_holiday", func(db *gorm.DB) error {
+		return db.WithContext(c).Create(&holiday).Error
+	})
+	if utils.IsUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A holiday already exists on this date"})
+		return
+	}
+	if err != nil {
+		utils.Log.Errorf("CreateClinicHoliday: Database error - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create clinic holiday"})
+		return
+	}
+
+	scheduleCache.AllSchedulesInvalidate()
+	c.JSON(http.StatusCreated, gin.H{"message": "Clinic holiday created successfully", "holiday": holiday})
+}
+
+// GetClinicHolidays lists holidays, which are dates on the clinic's calendar.
+func GetClinicHolidays(c *gin.Context) {
+	query := config.DB.WithContext(c)
+	if from := c.Query("from"); from != "" {
+		if _, err := time.Parse(dateLayout, from); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date. Use YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("date >= ?", from)
+	}
+	if to := c.Query("to"); to != "" {
+		if _, err := time.Parse(dateLayout, to); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date. Use YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("date <= ?", to)
+	}
+
+	var holidays []models.ClinicHoliday
+	err := metrics.DbMetrics(query, "get_clinic_holidays", func(db *gorm.DB) error {
+		return db.Order("date asc").Find(&holidays).Error
+	})
+	if err != nil {
+		utils.Log.Errorf("GetClinicHolidays: Failed to fetch holidays - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch holidays"})
+		return
+	}
+
+	clinicLoc, err := utils.ClinicLocation(config.DB.WithContext(c))
+	if err != nil {
+		utils.Log.Errorf("GetClinicHolidays: Failed to load clinic time zone - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch holidays"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"holidays": holidays, "timeZone": clinicLoc.String()})
+}
+
+func DeleteClinicHoliday(c *gin.Context, scheduleCache *cache.Cache) {
+	result := config.DB.WithContext(c).Delete(&models.ClinicHoliday{}, "id = ?", c.Param("id"))
+	if result.Error != nil {
+		utils.Log.Errorf("DeleteClinicHoliday: Failed to delete holiday - %v", result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete holiday"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Holiday not found"})
+		return
+	}
+
+	scheduleCache.AllSchedulesInvalidate()
+	c.JSON(http.StatusOK, gin.H{"message": "Clinic holiday deleted"})
+}
@@ -0,0 +1,131 @@
+package schedule
+
+import (
+	"net/http"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/metrics"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/services/cache"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SchedulingRulesInput replaces a doctor's pacing rules. A zero turns the
+// matching rule off.
+type SchedulingRulesInput struct {
+	DoctorID              *uuid.UUID `json:"doctor_id"`
+	BufferMinutes         int        `json:"buffer_minutes" binding:"min=0,max=120"`
+	BreakAfterConsecutive int        `json:"break_after_consecutive" binding:"min=0,max=50"`
+	BreakMinutes          int        `json:"break_minutes" binding:"min=0,max=240,required_with=BreakAfterConsecutive"`
+}
+
+// DailyCapInput sets the daily limit for one appointment type. A limit of
+// zero removes it.
+type DailyCapInput struct {
+	DoctorID        *uuid.UUID `json:"doctor_id"`
+	AppointmentType string     `json:"appointment_type" binding:"required,oneof=CONSULTATION FOLLOWUP CHECKUP EMERGENCY"`
+	MaxPerDay       int        `json:"max_per_day" binding:"min=0,max=100"`
+}
+
+func SetSchedulingRules(c *gin.Context, scheduleCache *cache.Cache) {
+	var input SchedulingRulesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Log.Warnf("SetSchedulingRules: Invalid input - %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	doctorID, status, err := resolveDoctorID(c, input.DoctorID)
+	if err != nil {
+		utils.Log.Warnf("SetSchedulingRules: %v", err)
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	rules := models.DoctorSchedulingRules{
+		DoctorID:              doctorID,
+		BufferMinutes:         input.BufferMinutes,
+		BreakAfterConsecutive: input.BreakAfterConsecutive,
+		BreakMinutes:          input.BreakMinutes,
+	}
+	err = metrics.DbMetrics(config.DB, "upsert_scheduling_rules", func(db *gorm.DB) error {
+		return db.WithContext(c).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "doctor_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"buffer_minutes", "break_after_consecutive", "break_minutes", "updated_at"}),
+		}).Create(&rules).Error
+	})
+	if err != nil {
+		utils.Log.Errorf("SetSchedulingRules: Database error - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save scheduling rules"})
+		return
+	}
+
+	scheduleCache.DoctorScheduleInvalidate(doctorID.String())
+	c.JSON(http.StatusOK, gin.H{"message": "Scheduling rules saved", "scheduling_rules": rules})
+}
+
+func SetDailyCap(c *gin.Context, scheduleCache *cache.Cache) {
+	var input DailyCapInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Log.Warnf("SetDailyCap: Invalid input - %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	doctorID, status, err := resolveDoctorID(c, input.DoctorID)
+	if err != nil {
+		utils.Log.Warnf("SetDailyCap: %v", err)
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	dailyCap := models.DoctorDailyCap{
+		DoctorID:        doctorID,
+		AppointmentType: models.ApptType(input.AppointmentType),
+		MaxPerDay:       input.MaxPerDay,
+	}
+	err = metrics.DbMetrics(config.DB, "upsert_daily_cap", func(db *gorm.DB) error {
+		db = db.WithContext(c)
+		if input.MaxPerDay == 0 {
+			return db.Where("doctor_id = ? AND appointment_type = ?", doctorID, dailyCap.AppointmentType).Delete(&models.DoctorDailyCap{}).Error
+		}
+		return db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "doctor_id"}, {Name: "appointment_type"}},
+			DoUpdates: clause.AssignmentColumns([]string{"max_per_day", "updated_at"}),
+		}).Create(&dailyCap).Error
+	})
+	if err != nil {
+		utils.Log.Errorf("SetDailyCap: Database error - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save daily cap"})
+		return
+	}
+
+	scheduleCache.DoctorScheduleInvalidate(doctorID.String())
+	c.JSON(http.StatusOK, gin.H{"message": "Daily cap saved", "daily_cap": dailyCap})
+}
+
+func GetSchedulingRulesByDoctorID(c *gin.Context) {
+	doctorID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid doctor ID"})
+		return
+	}
+
+	var rules utils.SchedulingRules
+	err = metrics.DbMetrics(config.DB, "get_scheduling_rules", func(db *gorm.DB) error {
+		var rulesErr error
+		rules, rulesErr = utils.GetSchedulingRules(db.WithContext(c), doctorID)
+		return rulesErr
+	})
+	if err != nil {
+		utils.Log.Errorf("GetSchedulingRulesByDoctorID: Failed to fetch scheduling rules - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch scheduling rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"doctor_id": doctorID, "scheduling_rules": rules})
+}
@@ -0,0 +1,79 @@
+package schedule
+
+import (
+	"net/http"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/metrics"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/services/cache"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SlotDurationInput struct {
+	DoctorID        *uuid.UUID `json:"doctor_id"`
+	AppointmentType string     `json:"appointment_type" binding:"required,oneof=CONSULTATION FOLLOWUP CHECKUP EMERGENCY"`
+	DurationMinutes int        `json:"duration_minutes" binding:"required,min=5,max=480"`
+}
+
+func SetSlotDuration(c *gin.Context, scheduleCache *cache.Cache) {
+	var input SlotDurationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Log.Warnf("SetSlotDuration: Invalid input - %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	doctorID, status, err := resolveDoctorID(c, input.DoctorID)
+	if err != nil {
+		utils.Log.Warnf("SetSlotDuration: %v", err)
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	duration := models.DoctorSlotDuration{
+		DoctorID:        doctorID,
+		AppointmentType: models.ApptType(input.AppointmentType),
+		DurationMinutes: input.DurationMinutes,
+	}
+	err = metrics.DbMetrics(config.DB, "upsert_slot_duration", func(db *gorm.DB) error {
+		return db.WithContext(c).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "doctor_id"}, {Name: "appointment_type"}},
+			DoUpdates: clause.AssignmentColumns([]string{"duration_minutes", "updated_at"}),
+		}).Create(&duration).Error
+	})
+	if err != nil {
+		utils.Log.Errorf("SetSlotDuration: Database error - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save slot duration"})
+		return
+	}
+
+	scheduleCache.DoctorScheduleInvalidate(doctorID.String())
+	c.JSON(http.StatusOK, gin.H{"message": "Slot duration saved", "slot_duration": duration})
+}
+
+func GetSlotDurationsByDoctorID(c *gin.Context) {
+	doctorID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid doctor ID"})
+		return
+	}
+
+	var durations map[models.ApptType]int
+	err = metrics.DbMetrics(config.DB, "get_slot_durations", func(db *gorm.DB) error {
+		var durationErr error
+		durations, durationErr = utils.GetSlotDurations(db.WithContext(c), doctorID)
+		return durationErr
+	})
+	if err != nil {
+		utils.Log.Errorf("GetSlotDurationsByDoctorID: Failed to fetch slot durations - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch slot durations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"doctor_id": doctorID, "slot_durations": durations})
+}
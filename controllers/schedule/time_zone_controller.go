@@ -0,0 +1,66 @@
+package schedule
+
+import (
+	"net/http"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/metrics"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/services/cache"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type TimeZoneInput struct {
+	DoctorID *uuid.UUID `json:"doctor_id"`
+	TimeZone string     `json:"time_zone" binding:"required"`
+}
+
+// SetDoctorTimeZone moves a doctor to another IANA zone. Working hours stay
+// on the wall clock, while upcoming appointments keep their instants and have
+// their wall-clock fields rewritten in the new zone.
+func SetDoctorTimeZone(c *gin.Context, scheduleCache *cache.Cache) {
+	var input TimeZoneInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Log.Warnf("SetDoctorTimeZone: Invalid input - %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	doctorID, status, err := resolveDoctorID(c, input.DoctorID)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	loc, err := utils.LoadTimeZone(input.TimeZone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	zone := loc.String()
+
+	err = metrics.DbMetrics(config.DB, "set_doctor_time_zone", func(db *gorm.DB) error {
+		return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&models.Doctor{}).Where("id = ?", doctorID).Update("time_zone", zone).Error; err != nil {
+				return err
+			}
+			return tx.Exec(`
+				UPDATE appointments SET
+					appointment_date = (starts_at AT TIME ZONE ?)::date,
+					start_time = to_char(starts_at AT TIME ZONE ?, 'HH24:MI'),
+					end_time = to_char(ends_at AT TIME ZONE ?, 'HH24:MI')
+				WHERE doctor_id = ? AND starts_at > NOW() AND status NOT IN ?
+			`, zone, zone, zone, doctorID, utils.TerminalStatuses()).Error
+		})
+	})
+	if err != nil {
+		utils.Log.Errorf("SetDoctorTimeZone: Database error - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update time zone"})
+		return
+	}
+
+	scheduleCache.DoctorScheduleInvalidate(doctorID.String())
+	c.JSON(http.StatusOK, gin.H{"message": "Time zone updated", "doctor_id": doctorID, "time_zone": zone})
+}
@@ -0,0 +1,227 @@
+package schedule
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/metrics"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/services/cache"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WorkingHoursInput struct {
+	DoctorID  *uuid.UUID `json:"doctor_id"`
+	Weekday   *int       `json:"weekday" binding:"required,min=0,max=6"`
+	StartTime string     `json:"start_time" binding:"required"`
+	EndTime   string     `json:"end_time" binding:"required"`
+	// LocationID is where the doctor sees patients during the block.
+	LocationID *uuid.UUID `json:"location_id"`
+}
+
+type WorkingHoursUpdateInput struct {
+	Weekday    *int       `json:"weekday" binding:"omitempty,min=0,max=6"`
+	StartTime  string     `json:"start_time" binding:"omitempty"`
+	EndTime    string     `json:"end_time" binding:"omitempty"`
+	LocationID *uuid.UUID `json:"location_id"`
+}
+
+// resolveDoctorID returns the doctor a schedule request applies to. Doctors
+// always act on their own profile, admins must name the doctor explicitly.
+func resolveDoctorID(c *gin.Context, requested *uuid.UUID) (uuid.UUID, int, error) {
+	user, err := utils.GetCurrentUser(c)
+	if err != nil {
+		return uuid.Nil, http.StatusUnauthorized, err
+	}
+
+	if models.Role(user.Role) == models.RoleDoctor {
+		doctor, err := utils.GetDoctorByUserID(user.UserID, c)
+		if err != nil {
+			return uuid.Nil, http.StatusForbidden, errors.New("doctor profile not found")
+		}
+		if requested != nil && *requested != doctor.ID {
+			return uuid.Nil, http.StatusForbidden, errors.New("you can only manage your own schedule")
+		}
+		return doctor.ID, http.StatusOK, nil
+	}
+
+	if requested == nil {
+		return uuid.Nil, http.StatusBadRequest, errors.New("doctor_id is required")
+	}
+	var doctor models.Doctor
+	if err := config.DB.WithContext(c).Select("id").First(&doctor, "id = ?", *requested).Error; err != nil {
+		return uuid.Nil, http.StatusNotFound, errors.New("doctor not found")
+	}
+	return doctor.ID, http.StatusOK, nil
+}
+
+// checkLocation verifies that an optional location exists.
+func checkLocation(c *gin.Context, locationID *uuid.UUID) error {
+	if locationID == nil {
+		return nil
+	}
+	if err := config.DB.WithContext(c).Select("id").First(&models.Location{}, "id = ?", *locationID).Error; err != nil {
+		return errors.New("location not found")
+	}
+	return nil
+}
+
+// loadOwnedWorkingHours fetches a block and checks the caller may modify it.
+func loadOwnedWorkingHours(c *gin.Context, id string) (models.Doctor_working_hours, int, error) {
+	var block models.Doctor_working_hours
+	if err := config.DB.WithContext(c).First(&block, "id = ?", id).Error; err != nil {
+		return block, http.StatusNotFound, errors.New("working hours not found")
+	}
+	if _, status, err := resolveDoctorID(c, &block.DoctorID); err != nil {
+		return block, status, err
+	}
+	return block, http.StatusOK, nil
+}
+
+func CreateWorkingHours(c *gin.Context, scheduleCache *cache.Cache) {
+	var input WorkingHoursInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Log.Warnf("CreateWorkingHours: Invalid input - %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	doctorID, status, err := resolveDoctorID(c, input.DoctorID)
+	if err != nil {
+		utils.Log.Warnf("CreateWorkingHours: %v", err)
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := utils.ValidateWorkingHours(config.DB, doctorID, *input.Weekday, input.StartTime, input.EndTime, nil); err != nil {
+		utils.Log.Warnf("CreateWorkingHours: Invalid block - %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := checkLocation(c, input.LocationID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	block := models.Doctor_working_hours{
+		DoctorID:   doctorID,
+		Weekday:    *input.Weekday,
+		StartTime:  input.StartTime,
+		EndTime:    input.EndTime,
+		IsActive:   true,
+		LocationID: input.LocationID,
+	}
+	err = metrics.DbMetrics(config.DB, "create_working_hours", func(db *gorm.DB) error {
+		return db.WithContext(c).Create(&block).Error
+	})
+	if err != nil {
+		utils.Log.Errorf("CreateWorkingHours: Database error - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create working hours"})
+		return
+	}
+
+	scheduleCache.DoctorScheduleInvalidate(doctorID.String())
+	c.JSON(http.StatusCreated, gin.H{"message": "Working hours created successfully", "working_hours": block})
+}
+
+func GetWorkingHoursByDoctorID(c *gin.Context) {
+	doctorID := c.Param("id")
+	if _, err := uuid.Parse(doctorID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid doctor ID"})
+		return
+	}
+
+	query := config.DB.WithContext(c).Where("doctor_id = ?", doctorID)
+	if c.Query("active") == "true" {
+		query = query.Where("is_active = true")
+	}
+
+	var blocks []models.Doctor_working_hours
+	err := metrics.DbMetrics(query, "get_working_hours_by_doctor", func(db *gorm.DB) error {
+		return db.Order("weekday asc").Order("start_time asc").Find(&blocks).Error
+	})
+	if err != nil {
+		utils.Log.Errorf("GetWorkingHoursByDoctorID: Failed to fetch working hours - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch working hours"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"working_hours": blocks})
+}
+
+func UpdateWorkingHours(c *gin.Context, scheduleCache *cache.Cache) {
+	block, status, err := loadOwnedWorkingHours(c, c.Param("id"))
+	if err != nil {
+		utils.Log.Warnf("UpdateWorkingHours: %v", err)
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	var input WorkingHoursUpdateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	if input.Weekday != nil {
+		block.Weekday = *input.Weekday
+	}
+	if input.StartTime != "" {
+		block.StartTime = input.StartTime
+	}
+	if input.EndTime != "" {
+		block.EndTime = input.EndTime
+	}
+	if input.LocationID != nil {
+		if err := checkLocation(c, input.LocationID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		block.LocationID = input.LocationID
+	}
+
+	if block.IsActive {
+		if err := utils.ValidateWorkingHours(config.DB, block.DoctorID, block.Weekday, block.StartTime, block.EndTime, &block.ID); err != nil {
+			utils.Log.Warnf("UpdateWorkingHours: Invalid block - %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	err = metrics.DbMetrics(config.DB, "update_working_hours", func(db *gorm.DB) error {
+		return db.WithContext(c).Save(&block).Error
+	})
+	if err != nil {
+		utils.Log.Errorf("UpdateWorkingHours: Failed to update working hours - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update working hours"})
+		return
+	}
+
+	scheduleCache.DoctorScheduleInvalidate(block.DoctorID.String())
+	c.JSON(http.StatusOK, gin.H{"message": "Working hours updated successfully", "working_hours": block})
+}
+
+func DeactivateWorkingHours(c *gin.Context, scheduleCache *cache.Cache) {
+	block, status, err := loadOwnedWorkingHours(c, c.Param("id"))
+	if err != nil {
+		utils.Log.Warnf("DeactivateWorkingHours: %v", err)
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = metrics.DbMetrics(config.DB, "deactivate_working_hours", func(db *gorm.DB) error {
+		return db.WithContext(c).Model(&block).Update("is_active", false).Error
+	})
+	if err != nil {
+		utils.Log.Errorf("DeactivateWorkingHours: Failed to deactivate working hours - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to deactivate working hours"})
+		return
+	}
+
+	scheduleCache.DoctorScheduleInvalidate(block.DoctorID.String())
+	c.JSON(http.StatusOK, gin.H{"message": "Working hours deactivated"})
+}
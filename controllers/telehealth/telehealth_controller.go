@@ -0,0 +1,34 @@
+package telehealth
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/services/telehealth"
+	"github.com/gin-gonic/gin"
+)
+
+// JoinLocalMeeting is where links from the built-in provider land. It checks
+// the signature and expiry and returns the room the participant may enter.
+func JoinLocalMeeting(c *gin.Context) {
+	provider, ok := telehealth.Default.(*telehealth.LocalProvider)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Local meetings are not enabled"})
+		return
+	}
+
+	room := c.Param("room")
+	role := c.Query("role")
+	err := provider.Verify(room, role, c.Query("expires"), c.Query("sig"), time.Now())
+	if errors.Is(err, telehealth.ErrLinkExpired) {
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"room": room, "role": role, "name": c.Query("name")})
+}
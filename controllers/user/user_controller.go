@@ -2,6 +2,8 @@ package user
 
 import (
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/AltSumpreme/Medistream.git/config"
 	"github.com/AltSumpreme/Medistream.git/metrics"
@@ -176,6 +178,7 @@ func PromotePatienttoDoctor(c *gin.Context) {
 		ID:             uuid.New(),
 		UserID:         user.ID,
 		Specialization: specialization,
+		TimeZone:       utils.ClinicTimeZone(config.DB),
 	}
 	if err := metrics.DbMetrics(config.DB, "create_doctor_profile", func(db *gorm.DB) error {
 		return db.Create(&doctor).Error
@@ -187,3 +190,123 @@ func PromotePatienttoDoctor(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "User promoted to doctor successfully"})
 }
+
+type ReceptionistInput struct {
+	LocationID *uuid.UUID `json:"location_id"`
+}
+
+// PromotePatientToReceptionist makes a user front-desk staff, optionally
+// limited to one location.
+func PromotePatientToReceptionist(c *gin.Context) {
+	var input ReceptionistInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.WithContext(c).Where("id = ?", c.Param("id")).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.Role != models.RolePatient {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User is not a patient"})
+		return
+	}
+	if input.LocationID != nil {
+		if err := config.DB.WithContext(c).Select("id").First(&models.Location{}, "id = ?", *input.LocationID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Location not found"})
+			return
+		}
+	}
+
+	receptionist := models.Receptionist{UserID: user.ID, LocationID: input.LocationID}
+	err := metrics.DbMetrics(config.DB, "promote_user_to_receptionist", func(db *gorm.DB) error {
+		return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&user).Update("role", models.RoleReceptionist).Error; err != nil {
+				return err
+			}
+			return tx.Omit("User", "Location").Create(&receptionist).Error
+		})
+	})
+	if err != nil {
+		utils.Log.Errorf("PromotePatientToReceptionist: Failed to promote user - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to promote user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User promoted to receptionist successfully", "receptionist_id": receptionist.ID})
+}
+
+// VerifyUserEmail lets an admin mark a user's email address verified without
+// a code, for example after confirming it by phone.
+func VerifyUserEmail(c *gin.Context) {
+	var user models.User
+	if err := config.DB.WithContext(c).Where("id = ?", c.Param("id")).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var verified bool
+	err := metrics.DbMetrics(config.DB, "verify_user_email", func(db *gorm.DB) error {
+		result := db.WithContext(c).Model(&models.Auth{}).
+			Where("id = ? AND verified_at IS NULL", user.AuthID).
+			Update("verified_at", time.Now())
+		verified = result.RowsAffected > 0
+		return result.Error
+	})
+	if err != nil {
+		utils.Log.Errorf("VerifyUserEmail: Failed to verify email - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+	if !verified {
+		c.JSON(http.StatusOK, gin.H{"message": "Email already verified"})
+		return
+	}
+
+	admin, _ := utils.GetCurrentUser(c)
+	if admin != nil {
+		utils.Log.Infof("VerifyUserEmail: Admin %s verified the email of user %s", admin.UserID, user.ID)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Email marked as verified"})
+}
+
+type PatientSearchResult struct {
+	PatientID uuid.UUID `json:"patient_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Email     string    `json:"email"`
+	Phone     string    `json:"phone"`
+}
+
+// SearchPatients finds patients by name, email or phone for front-desk
+// booking.
+func SearchPatients(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if len(q) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q must be at least 2 characters"})
+		return
+	}
+	pattern := utils.ContainsPattern(q)
+
+	var results []PatientSearchResult
+	err := metrics.DbMetrics(config.DB, "search_patients", func(db *gorm.DB) error {
+		return db.WithContext(c).Table("patients").
+			Select("patients.id AS patient_id, users.id AS user_id, users.first_name, users.last_name, auth.email, users.phone").
+			Joins("JOIN users ON users.id = patients.user_id").
+			Joins("JOIN auth ON auth.id = users.auth_id").
+			Where("users.first_name || ' ' || users.last_name ILIKE ? OR auth.email ILIKE ? OR users.phone ILIKE ?", pattern, pattern, pattern).
+			Order("users.last_name asc, users.first_name asc").
+			Limit(20).
+			Scan(&results).Error
+	})
+	if err != nil {
+		utils.Log.Errorf("SearchPatients: Failed to search patients - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not search patients"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"patients": results})
+}
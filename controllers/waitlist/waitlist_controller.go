@@ -0,0 +1,412 @@
+package waitlist
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/metrics"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/queue"
+	"github.com/AltSumpreme/Medistream.git/services/cache"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const dateLayout = "2006-01-02"
+
+type WaitlistInput struct {
+	DoctorID        uuid.UUID `json:"doctorId" binding:"required"`
+	AppointmentType string    `json:"appointmentType" binding:"required,oneof=CONSULTATION FOLLOWUP CHECKUP EMERGENCY"`
+	Mode            string    `json:"mode" binding:"required,oneof=Online In-Person"`
+	EarliestDate    string    `json:"earliestDate" binding:"required"`
+	LatestDate      string    `json:"latestDate" binding:"required"`
+	WindowStart     string    `json:"windowStart" binding:"required"`
+	WindowEnd       string    `json:"windowEnd" binding:"required"`
+}
+
+type ClaimInput struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// offerTTL is how long a patient has to claim an offered slot.
+func offerTTL() time.Duration {
+	minutes, err := strconv.Atoi(utils.GetEnvWithDefault("WAITLIST_OFFER_TTL_MINUTES", "30"))
+	if err != nil || minutes <= 0 {
+		minutes = 30
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+func currentPatient(c *gin.Context) (models.Patient, bool) {
+	user, err := utils.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return models.Patient{}, false
+	}
+	patient, err := utils.GetPatientByUserID(user.UserID, c)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Patient profile not found"})
+		return patient, false
+	}
+	return patient, true
+}
+
+func JoinWaitlist(c *gin.Context) {
+	patient, ok := currentPatient(c)
+	if !ok {
+		return
+	}
+
+	var input WaitlistInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Log.Warnf("JoinWaitlist: Invalid input - %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	earliest, err1 := time.Parse(dateLayout, input.EarliestDate)
+	latest, err2 := time.Parse(dateLayout, input.LatestDate)
+	if err1 != nil || err2 != nil || latest.Before(earliest) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "earliestDate and latestDate must be YYYY-MM-DD with latestDate not before earliestDate"})
+		return
+	}
+	windowStart, err1 := utils.ParseClock(input.WindowStart)
+	windowEnd, err2 := utils.ParseClock(input.WindowEnd)
+	if err1 != nil || err2 != nil || !windowEnd.After(windowStart) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "windowStart and windowEnd must be HH:MM with windowEnd after windowStart"})
+		return
+	}
+
+	var doctor models.Doctor
+	if err := config.DB.WithContext(c).Select("id").First(&doctor, "id = ?", input.DoctorID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Doctor not found"})
+		return
+	}
+
+	entry := models.WaitlistEntry{
+		PatientID:       patient.ID,
+		DoctorID:        input.DoctorID,
+		AppointmentType: models.ApptType(input.AppointmentType),
+		Mode:            input.Mode,
+		EarliestDate:    earliest,
+		LatestDate:      latest,
+		WindowStart:     windowStart.Format(utils.ClockLayout),
+		WindowEnd:       windowEnd.Format(utils.ClockLayout),
+		Status:          models.WaitlistWaiting,
+	}
+	err := metrics.DbMetrics(config.DB, "join_waitlist", func(db *gorm.DB) error {
+		return db.WithContext(c).Create(&entry).Error
+	})
+	if err != nil {
+		utils.Log.Errorf("JoinWaitlist: Database error - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join waitlist"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Added to waitlist", "entry": entry})
+}
+
+func GetMyWaitlistEntries(c *gin.Context) {
+	patient, ok := currentPatient(c)
+	if !ok {
+		return
+	}
+
+	var entries []models.WaitlistEntry
+	err := metrics.DbMetrics(config.DB, "get_my_waitlist", func(db *gorm.DB) error {
+		return db.WithContext(c).Where("patient_id = ?", patient.ID).Order("created_at desc").Find(&entries).Error
+	})
+	if err != nil {
+		utils.Log.Errorf("GetMyWaitlistEntries: Failed to fetch entries - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch waitlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
+}
+
+func GetWaitlistByDoctorID(c *gin.Context) {
+	doctorID := c.Param("id")
+	user, _ := utils.GetCurrentUser(c)
+	if models.Role(user.Role) == models.RoleDoctor {
+		doctor, err := utils.GetDoctorByUserID(user.UserID, c)
+		if err != nil || doctor.ID.String() != doctorID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+	}
+
+	var entries []models.WaitlistEntry
+	err := metrics.DbMetrics(config.DB, "get_waitlist_by_doctor", func(db *gorm.DB) error {
+		return db.WithContext(c).
+			Where("doctor_id = ? AND status IN ?", doctorID, []models.WaitlistStatus{models.WaitlistWaiting, models.WaitlistOffered}).
+			Order("created_at asc").
+			Find(&entries).Error
+	})
+	if err != nil {
+		utils.Log.Errorf("GetWaitlistByDoctorID: Failed to fetch entries - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch waitlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
+}
+
+func LeaveWaitlist(c *gin.Context) {
+	patient, ok := currentPatient(c)
+	if !ok {
+		return
+	}
+
+	result := config.DB.WithContext(c).Model(&models.WaitlistEntry{}).
+		Where("id = ? AND patient_id = ? AND status IN ?", c.Param("id"), patient.ID, []models.WaitlistStatus{models.WaitlistWaiting, models.WaitlistOffered}).
+		Updates(map[string]interface{}{"status": models.WaitlistCancelled, "offer_token": nil})
+	if result.Error != nil {
+		utils.Log.Errorf("LeaveWaitlist: Database error - %v", result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to leave waitlist"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Active waitlist entry not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Removed from waitlist"})
+}
+
+var errOfferUnavailable = errors.New("offer is no longer available")
+
+// ClaimWaitlistOffer books the offered slot. The entry row is locked so an
+// offer can only be claimed once, and the slot is re-validated before insert.
+func ClaimWaitlistOffer(c *gin.Context, appointmentCache *cache.Cache) {
+	patient, ok := currentPatient(c)
+	if !ok {
+		return
+	}
+
+	var input ClaimInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	var appointment models.Appointment
+	err := metrics.DbMetrics(config.DB, "claim_waitlist_offer", func(db *gorm.DB) error {
+		return db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+			var entry models.WaitlistEntry
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				First(&entry, "id = ? AND patient_id = ?", c.Param("id"), patient.ID).Error; err != nil {
+				return errOfferUnavailable
+			}
+			if entry.Status != models.WaitlistOffered || entry.OfferToken == nil || *entry.OfferToken != input.Token ||
+				entry.OfferExpiresAt == nil || time.Now().After(*entry.OfferExpiresAt) {
+				return errOfferUnavailable
+			}
+
+			if err := utils.ScheduleAppointment(tx, entry.DoctorID, entry.PatientID, *entry.OfferDate, *entry.OfferStartTime, *entry.OfferEndTime, entry.AppointmentType, nil); err != nil {
+				return errOfferUnavailable
+			}
+
+			appointment = models.Appointment{
+				PatientID:       entry.PatientID,
+				DoctorID:        entry.DoctorID,
+				AppointmentDate: *entry.OfferDate,
+				StartTime:       *entry.OfferStartTime,
+				EndTime:         *entry.OfferEndTime,
+				Status:          models.AppointmentStatusPending,
+				Mode:            entry.Mode,
+				AppointmentType: entry.AppointmentType,
+				Notes:           "Booked from waitlist",
+				BookedBy:        &patient.UserID,
+			}
+			if err := utils.AssignRoom(tx, &appointment); err != nil {
+				if utils.IsScheduleConflict(err) {
+					return errOfferUnavailable
+				}
+				return err
+			}
+			if err := tx.Create(&appointment).Error; err != nil {
+				if utils.IsScheduleConflict(utils.BookingError(err)) {
+					return errOfferUnavailable
+				}
+				return err
+			}
+
+			return tx.Model(&entry).Updates(map[string]interface{}{
+				"status":         models.WaitlistBooked,
+				"appointment_id": appointment.ID,
+				"offer_token":    nil,
+			}).Error
+		})
+	})
+	if errors.Is(err, errOfferUnavailable) {
+		c.JSON(http.StatusConflict, gin.H{"error": "This offer has expired or the slot is no longer available"})
+		return
+	}
+	if err != nil {
+		utils.Log.Errorf("ClaimWaitlistOffer: Database error - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to claim offer"})
+		return
+	}
+
+	appointmentCache.AppointmentInvalidate(appointment.ID.String(), appointment.DoctorID.String(), appointment.PatientID.String(), appointment.AppointmentDate.Format(dateLayout))
+	c.JSON(http.StatusCreated, gin.H{"message": "Slot claimed", "appointment": appointment})
+}
+
+// BackfillSlot offers a freed slot to the first eligible waitlisted patient.
+// It is run by the worker after a cancellation.
+func BackfillSlot(ctx context.Context, slot queue.FreedSlotPayload) error {
+	start, err1 := utils.ParseClock(slot.StartTime)
+	end, err2 := utils.ParseClock(slot.EndTime)
+	if err1 != nil || err2 != nil {
+		return errors.New("freed slot has malformed times")
+	}
+	slotDate := slot.AppointmentDate.Format(dateLayout)
+
+	loc, err := utils.DoctorLocation(config.DB, slot.DoctorID)
+	if err != nil {
+		return err
+	}
+	if utils.Instant(slot.AppointmentDate, start, loc).Before(time.Now()) {
+		return nil
+	}
+
+	var entries []models.WaitlistEntry
+	err = config.DB.WithContext(ctx).
+		Where("doctor_id = ? AND status = ?", slot.DoctorID, models.WaitlistWaiting).
+		Where("earliest_date <= ? AND latest_date >= ?", slotDate, slotDate).
+		Where("window_start <= ? AND window_end > ?", start.Format(utils.ClockLayout), start.Format(utils.ClockLayout)).
+		Order("created_at asc").
+		Find(&entries).Error
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if slices.Contains(slot.SkipEntryIDs, entry.ID) {
+			continue
+		}
+
+		length, err := utils.GetSlotDuration(config.DB, slot.DoctorID, entry.AppointmentType)
+		if err != nil {
+			return err
+		}
+		offerEnd := start.Add(length)
+		window, _ := utils.ParseClock(entry.WindowEnd)
+		if offerEnd.After(end) || offerEnd.After(window) {
+			continue
+		}
+
+		startStr, endStr := start.Format(utils.ClockLayout), offerEnd.Format(utils.ClockLayout)
+		if err := utils.ScheduleAppointment(config.DB, slot.DoctorID, entry.PatientID, slot.AppointmentDate, startStr, endStr, entry.AppointmentType, nil); err != nil {
+			continue
+		}
+
+		token, err := utils.GenerateOTP(6)
+		if err != nil {
+			return err
+		}
+		expiresAt := time.Now().Add(offerTTL())
+		offerDate := slot.AppointmentDate
+
+		result := config.DB.WithContext(ctx).Model(&models.WaitlistEntry{}).
+			Where("id = ? AND status = ?", entry.ID, models.WaitlistWaiting).
+			Updates(map[string]interface{}{
+				"status":           models.WaitlistOffered,
+				"offer_token":      token,
+				"offer_date":       offerDate,
+				"offer_start_time": startStr,
+				"offer_end_time":   endStr,
+				"offer_expires_at": expiresAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		sendOffer(entry, slotDate, startStr, token, expiresAt, slot.SkipEntryIDs)
+		utils.Log.Infof("BackfillSlot: Offered %s %s to waitlist entry %s", slotDate, startStr, entry.ID)
+		return nil
+	}
+	return nil
+}
+
+// ExpireWaitlistOffer returns an unclaimed offer to the waitlist and passes
+// the slot on to the next patient in line.
+func ExpireWaitlistOffer(ctx context.Context, offer queue.WaitlistOfferPayload) error {
+	var entry models.WaitlistEntry
+	if err := config.DB.WithContext(ctx).First(&entry, "id = ?", offer.EntryID).Error; err != nil {
+		return nil
+	}
+	if entry.Status != models.WaitlistOffered || entry.OfferToken == nil || *entry.OfferToken != offer.Token {
+		return nil
+	}
+
+	freed := queue.FreedSlotPayload{
+		DoctorID:        entry.DoctorID,
+		AppointmentDate: *entry.OfferDate,
+		StartTime:       *entry.OfferStartTime,
+		EndTime:         *entry.OfferEndTime,
+		SkipEntryIDs:    append(offer.SkipEntryIDs, entry.ID),
+	}
+
+	result := config.DB.WithContext(ctx).Model(&models.WaitlistEntry{}).
+		Where("id = ? AND status = ? AND offer_token = ?", entry.ID, models.WaitlistOffered, offer.Token).
+		Updates(map[string]interface{}{
+			"status":           models.WaitlistWaiting,
+			"offer_token":      nil,
+			"offer_date":       nil,
+			"offer_start_time": nil,
+			"offer_end_time":   nil,
+			"offer_expires_at": nil,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	return BackfillSlot(ctx, freed)
+}
+
+// sendOffer emails the offer and schedules its expiry. skip is carried into
+// the expiry so the slot keeps moving down the line instead of returning to
+// entries that already let it lapse.
+func sendOffer(entry models.WaitlistEntry, date, startTime, token string, expiresAt time.Time, skip []uuid.UUID) {
+	if queue.Client == nil {
+		return
+	}
+
+	contact, err := utils.GetPatientContact(config.DB, entry.PatientID)
+	if err != nil || contact.Email == "" {
+		utils.Log.Warnf("BackfillSlot: No email for patient %s - %v", entry.PatientID, err)
+	} else {
+		tmpl := utils.GetWaitlistOfferTemplate(date, startTime, token, time.Until(expiresAt).Round(time.Minute))
+		if task, err := queue.NewEmailTask(contact.Email, tmpl.Subject, tmpl.Body); err == nil {
+			if _, err := queue.Client.Enqueue(task, asynq.Queue("emails"), asynq.MaxRetry(3)); err != nil {
+				utils.Log.Errorf("BackfillSlot: Failed to enqueue offer email - %v", err)
+			}
+		}
+	}
+
+	expire, err := queue.NewTask(queue.JobTypeWaitlistOfferExpire, queue.WaitlistOfferPayload{EntryID: entry.ID, Token: token, SkipEntryIDs: skip})
+	if err != nil {
+		utils.Log.Errorf("BackfillSlot: Failed to create expiry task - %v", err)
+		return
+	}
+	if _, err := queue.Client.Enqueue(expire, asynq.Queue("appointments"), asynq.ProcessAt(expiresAt)); err != nil {
+		utils.Log.Errorf("BackfillSlot: Failed to enqueue expiry task - %v", err)
+	}
+}
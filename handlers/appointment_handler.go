@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/models"
 	"github.com/AltSumpreme/Medistream.git/queue"
 	"github.com/AltSumpreme/Medistream.git/utils"
 	"github.com/gin-gonic/gin"
@@ -11,19 +15,38 @@ import (
 	"github.com/hibiken/asynq"
 )
 
+// AppointmentInput takes either appointmentDate with startTime and endTime on
+// the doctor's wall clock, or startsAt and endsAt as absolute instants.
 type AppointmentInput struct {
-	UserID          uuid.UUID `json:"userId" binding:"required"`
-	AppointmentDate time.Time `json:"appointmentDate" binding:"required"`
-	AppointmentType string    `json:"appointmentType" binding:"required,oneof=CONSULTATION FOLLOWUP CHECKUP EMERGENCY"`
-	StartTime       string    `json:"startTime" binding:"required"`
-	EndTime         string    `json:"endTime" binding:"required"`
-	Mode            string    `json:"mode" binding:"required,oneof=Online In-Person"`
-	Notes           string    `json:"notes"`
-	DoctorID        uuid.UUID `json:"doctorId" binding:"required"`
+	AppointmentDate time.Time  `json:"appointmentDate" binding:"required_without=StartsAt"`
+	AppointmentType string     `json:"appointmentType" binding:"required,oneof=CONSULTATION FOLLOWUP CHECKUP EMERGENCY"`
+	StartTime       string     `json:"startTime" binding:"required_without=StartsAt"`
+	EndTime         string     `json:"endTime" binding:"required_without=StartsAt"`
+	StartsAt        *time.Time `json:"startsAt"`
+	EndsAt          *time.Time `json:"endsAt" binding:"required_with=StartsAt"`
+	Mode            string     `json:"mode" binding:"required,oneof=Online In-Person"`
+	Notes           string     `json:"notes"`
+	DoctorID        uuid.UUID  `json:"doctorId" binding:"required"`
+	NotifyByEmail   bool       `json:"notifyByEmail"`
+	// PatientID names the patient when staff book on their behalf. Patients
+	// always book for themselves.
+	PatientID *uuid.UUID `json:"patientId"`
+	// TriageLevel is required for emergencies, which only staff book. Its
+	// range is checked against the models.TriageLevel constants.
+	TriageLevel *int `json:"triageLevel"`
 }
 
+// Emergencies are booked from their own worker queue, weighted above routine
+// bookings, so they are not held up behind them.
+const (
+	bookingQueue   = "appointments"
+	emergencyQueue = "emergencies"
+)
+
+// HandleUserCreateAppointment records a booking request and queues it. The
+// booking itself happens in the worker; clients poll the returned request ID.
 func HandleUserCreateAppointment(c *gin.Context, client *asynq.Client) {
-	_, err := utils.GetCurrentUser(c)
+	user, err := utils.GetCurrentUser(c)
 	if err != nil {
 		utils.Log.Warnf("CreateAppointment: Failed to get current user - %v", err)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
@@ -36,17 +59,165 @@ func HandleUserCreateAppointment(c *gin.Context, client *asynq.Client) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
 		return
 	}
-	task, err := queue.NewTask(queue.JobTypeCreateAppointment, input)
+
+	patient, code, err := bookingPatient(c, user, input.PatientID)
+	if err != nil {
+		utils.Log.Warnf("CreateAppointment: %v", err)
+		c.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+	selfBooking := models.Role(user.Role) == models.RolePatient
+
+	emergency := models.ApptType(input.AppointmentType) == models.ApptTypeEmergency
+	if emergency && selfBooking {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Emergencies are booked by clinic staff; please call the clinic"})
+		return
+	}
+	if emergency != (input.TriageLevel != nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "triageLevel is required for emergencies and only allowed for them"})
+		return
+	}
+	if emergency && (*input.TriageLevel < models.TriageLevelImmediate || *input.TriageLevel > models.TriageLevelLowest) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("triageLevel must be between %d and %d", models.TriageLevelImmediate, models.TriageLevelLowest)})
+		return
+	}
+
+	date, start, end, err := utils.ResolveWallClock(config.DB, input.DoctorID, input.AppointmentDate, input.StartTime, input.EndTime, input.StartsAt, input.EndsAt)
 	if err != nil {
-		utils.Log.Errorf("CreateAppointment: Failed to create task - %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if code, err := receptionistCoversSlot(c, user, input.DoctorID, date, start, end); err != nil {
+		utils.Log.Warnf("CreateAppointment: %v", err)
+		c.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Patients over the clinic's no-show limit are blocked or need staff
+	// approval before the request is queued. Staff booking for them have
+	// already made that call.
+	status := models.BookingRequestQueued
+	var standing utils.AttendanceStanding
+	if selfBooking {
+		var settings models.ClinicSettings
+		settings, err = utils.GetClinicSettings(config.DB.WithContext(c))
+		if err == nil {
+			standing, err = utils.CheckAttendancePolicy(config.DB.WithContext(c), patient.ID, settings, time.Now())
+		}
+	}
+	if err != nil {
+		utils.Log.Errorf("CreateAppointment: Failed to check attendance policy - %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process appointment"})
+		return
+	}
+	switch standing.Action {
+	case models.NoShowBlock:
+		c.JSON(http.StatusForbidden, gin.H{"error": "New bookings are blocked after repeated missed appointments; please contact the clinic", "attendance": standing})
+		return
+	case models.NoShowRequireApproval:
+		status = models.BookingRequestAwaitingApproval
+	}
+
+	request := models.BookingRequest{
+		UserID:          user.UserID,
+		PatientID:       patient.ID,
+		DoctorID:        input.DoctorID,
+		AppointmentDate: date,
+		StartTime:       start,
+		EndTime:         end,
+		AppointmentType: models.ApptType(input.AppointmentType),
+		Mode:            input.Mode,
+		Notes:           input.Notes,
+		TriageLevel:     input.TriageLevel,
+		NotifyByEmail:   input.NotifyByEmail,
+		Status:          status,
+	}
+	if err := config.DB.WithContext(c).Create(&request).Error; err != nil {
+		utils.Log.Errorf("CreateAppointment: Failed to record booking request - %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process appointment"})
 		return
 	}
-	_, err = client.Enqueue(task, asynq.Queue("appoinment"), asynq.MaxRetry(5))
+
+	if request.Status == models.BookingRequestAwaitingApproval {
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":   "Appointment request is awaiting approval by clinic staff",
+			"requestId": request.ID,
+			"status":    request.Status,
+		})
+		return
+	}
+
+	task, err := queue.NewTask(queue.JobTypeCreateAppointment, queue.BookingRequestPayload{RequestID: request.ID})
+	if err == nil {
+		queueName := bookingQueue
+		if emergency {
+			queueName = emergencyQueue
+		}
+		_, err = client.Enqueue(task, asynq.Queue(queueName), asynq.MaxRetry(5))
+	}
 	if err != nil {
-		utils.Log.Errorf("SignUp: Failed to enqueue job - %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process signup"})
+		utils.Log.Errorf("CreateAppointment: Failed to enqueue job - %v", err)
+		config.DB.WithContext(c).Model(&request).Updates(map[string]interface{}{
+			"status": models.BookingRequestFailed,
+			"reason": "could not be queued",
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process appointment"})
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{"message": "user created appointment successfully"})
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":   "Appointment request received",
+		"requestId": request.ID,
+		"status":    request.Status,
+	})
+}
+
+// bookingPatient returns the patient a booking is for. Patients book for
+// themselves; staff must name the patient.
+func bookingPatient(c *gin.Context, user *utils.JWTClaims, requested *uuid.UUID) (models.Patient, int, error) {
+	if models.Role(user.Role) == models.RolePatient {
+		patient, err := utils.GetPatientByUserID(user.UserID, c)
+		if err != nil {
+			return patient, http.StatusForbidden, errors.New("patient profile not found")
+		}
+		return patient, http.StatusOK, nil
+	}
+
+	var patient models.Patient
+	if requested == nil {
+		return patient, http.StatusBadRequest, errors.New("patientId is required when booking for a patient")
+	}
+	if err := config.DB.WithContext(c).First(&patient, "id = ?", *requested).Error; err != nil {
+		return patient, http.StatusNotFound, errors.New("patient not found")
+	}
+	return patient, http.StatusOK, nil
+}
+
+// receptionistCoversSlot checks that a receptionist tied to a location only
+// books slots the doctor works at that location. Other callers pass.
+func receptionistCoversSlot(c *gin.Context, user *utils.JWTClaims, doctorID uuid.UUID, date time.Time, start, end string) (int, error) {
+	if models.Role(user.Role) != models.RoleReceptionist {
+		return http.StatusOK, nil
+	}
+	receptionist, err := utils.GetReceptionistByUserID(user.UserID, c)
+	if err != nil {
+		return http.StatusForbidden, errors.New("receptionist profile not found")
+	}
+	if receptionist.LocationID == nil {
+		return http.StatusOK, nil
+	}
+
+	startTime, err1 := utils.ParseClock(start)
+	endTime, err2 := utils.ParseClock(end)
+	if err1 != nil || err2 != nil {
+		return http.StatusBadRequest, errors.New("invalid time format, expected HH:MM")
+	}
+	locationID, err := utils.WorkingLocation(config.DB.WithContext(c), doctorID, date, utils.TimeBlock{Start: startTime, End: endTime})
+	if err != nil {
+		return http.StatusInternalServerError, errors.New("failed to process appointment")
+	}
+	if !utils.ReceptionistCovers(receptionist, models.Appointment{LocationID: locationID}) {
+		return http.StatusForbidden, errors.New("the doctor does not work at your location at this time")
+	}
+	return http.StatusOK, nil
 }
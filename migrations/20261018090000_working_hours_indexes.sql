@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_working_hours_doctor_weekday ON doctor_working_hours(doctor_id, weekday) WHERE is_active = true;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_working_hours_doctor_weekday;
+-- +goose StatementEnd
@@ -0,0 +1,7 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+ALTER TYPE appt_type ADD VALUE IF NOT EXISTS 'FOLLOWUP';
+ALTER TYPE appt_type ADD VALUE IF NOT EXISTS 'EMERGENCY';
+
+-- +goose Down
+-- Postgres cannot drop enum values; FOLLOWUP and EMERGENCY are left in place.
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS doctor_slot_durations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    doctor_id UUID NOT NULL,
+    appointment_type appt_type NOT NULL,
+    duration_minutes INT NOT NULL CHECK (duration_minutes > 0 AND duration_minutes <= 480),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_slot_duration_doctor FOREIGN KEY(doctor_id) REFERENCES doctors(id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    CONSTRAINT uq_slot_duration_doctor_type UNIQUE (doctor_id, appointment_type)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS doctor_slot_durations;
@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE schedule_exception_type AS ENUM ('TIME_OFF', 'EXTRA_HOURS');
+
+CREATE TABLE IF NOT EXISTS schedule_exceptions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    doctor_id UUID NOT NULL,
+    type schedule_exception_type NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    start_time TIME,
+    end_time TIME,
+    reason TEXT,
+    created_by UUID,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_exception_doctor FOREIGN KEY(doctor_id) REFERENCES doctors(id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    CONSTRAINT chk_exception_dates CHECK (end_date >= start_date),
+    CONSTRAINT chk_exception_times CHECK (
+        (start_time IS NULL AND end_time IS NULL) OR (start_time IS NOT NULL AND end_time IS NOT NULL AND end_time > start_time)
+    ),
+    CONSTRAINT chk_extra_hours_times CHECK (type <> 'EXTRA_HOURS' OR start_time IS NOT NULL)
+);
+
+CREATE INDEX idx_schedule_exceptions_doctor_dates ON schedule_exceptions(doctor_id, start_date, end_date);
+
+CREATE TABLE IF NOT EXISTS clinic_holidays (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    date DATE NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS clinic_holidays;
+DROP TABLE IF EXISTS schedule_exceptions;
+DROP TYPE IF EXISTS schedule_exception_type;
+-- +goose StatementEnd
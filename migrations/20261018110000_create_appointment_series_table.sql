@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS appointment_series (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    patient_id UUID NOT NULL,
+    doctor_id UUID NOT NULL,
+    frequency VARCHAR(10) NOT NULL CHECK (frequency IN ('WEEKLY', 'MONTHLY')),
+    repeat_interval INT NOT NULL DEFAULT 1 CHECK (repeat_interval > 0),
+    repeat_count INT CHECK (repeat_count > 0),
+    repeat_until DATE,
+    start_date DATE NOT NULL,
+    start_time VARCHAR(10) NOT NULL,
+    end_time VARCHAR(10) NOT NULL,
+    mode TEXT NOT NULL DEFAULT 'Online',
+    appointment_type appt_type NOT NULL DEFAULT 'CONSULTATION',
+    notes TEXT,
+    cancelled_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT fk_series_patient FOREIGN KEY(patient_id) REFERENCES patients(id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    CONSTRAINT fk_series_doctor FOREIGN KEY(doctor_id) REFERENCES doctors(id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+ALTER TABLE appointments
+    ADD COLUMN IF NOT EXISTS series_id UUID REFERENCES appointment_series(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS series_index INT;
+
+CREATE INDEX IF NOT EXISTS idx_appointments_series ON appointments(series_id, series_index);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_appointments_series;
+ALTER TABLE appointments
+    DROP COLUMN IF EXISTS series_index,
+    DROP COLUMN IF EXISTS series_id;
+DROP TABLE IF EXISTS appointment_series;
+-- +goose StatementEnd
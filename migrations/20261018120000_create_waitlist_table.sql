@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE waitlist_status AS ENUM ('WAITING', 'OFFERED', 'BOOKED', 'CANCELLED');
+
+CREATE TABLE IF NOT EXISTS waitlist_entries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    patient_id UUID NOT NULL,
+    doctor_id UUID NOT NULL,
+    appointment_type appt_type NOT NULL DEFAULT 'CONSULTATION',
+    mode TEXT NOT NULL DEFAULT 'Online',
+    earliest_date DATE NOT NULL,
+    latest_date DATE NOT NULL,
+    window_start VARCHAR(10) NOT NULL,
+    window_end VARCHAR(10) NOT NULL,
+    status waitlist_status NOT NULL DEFAULT 'WAITING',
+    offer_token TEXT,
+    offer_date DATE,
+    offer_start_time VARCHAR(10),
+    offer_end_time VARCHAR(10),
+    offer_expires_at TIMESTAMP WITH TIME ZONE,
+    appointment_id UUID REFERENCES appointments(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT fk_waitlist_patient FOREIGN KEY(patient_id) REFERENCES patients(id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    CONSTRAINT fk_waitlist_doctor FOREIGN KEY(doctor_id) REFERENCES doctors(id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    CONSTRAINT chk_waitlist_dates CHECK (latest_date >= earliest_date),
+    CONSTRAINT chk_waitlist_window CHECK (window_end > window_start)
+);
+
+CREATE INDEX idx_waitlist_doctor_status ON waitlist_entries(doctor_id, status, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS waitlist_entries;
+DROP TYPE IF EXISTS waitlist_status;
+-- +goose StatementEnd
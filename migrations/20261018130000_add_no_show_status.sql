@@ -0,0 +1,6 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+ALTER TYPE appointment_status ADD VALUE IF NOT EXISTS 'NO_SHOW';
+
+-- +goose Down
+-- Postgres cannot drop enum values; NO_SHOW is left in place.
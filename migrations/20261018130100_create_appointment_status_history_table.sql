@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS appointment_status_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    appointment_id UUID NOT NULL,
+    from_status appointment_status NOT NULL,
+    to_status appointment_status NOT NULL,
+    changed_by UUID,
+    actor_role VARCHAR(20),
+    reason TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_status_history_appointment FOREIGN KEY(appointment_id) REFERENCES appointments(id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    CONSTRAINT fk_status_history_user FOREIGN KEY(changed_by) REFERENCES users(id)
+        ON DELETE SET NULL
+);
+
+CREATE INDEX idx_status_history_appointment ON appointment_status_history(appointment_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS appointment_status_history;
+-- +goose StatementEnd
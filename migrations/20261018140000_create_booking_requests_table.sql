@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE booking_request_status AS ENUM ('QUEUED', 'PROCESSING', 'BOOKED', 'REJECTED', 'FAILED');
+
+CREATE TABLE IF NOT EXISTS booking_requests (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    patient_id UUID NOT NULL,
+    doctor_id UUID NOT NULL,
+    appointment_date TIMESTAMP NOT NULL,
+    start_time VARCHAR(10) NOT NULL,
+    end_time VARCHAR(10) NOT NULL,
+    appointment_type appt_type NOT NULL DEFAULT 'CONSULTATION',
+    mode TEXT NOT NULL DEFAULT 'Online',
+    notes TEXT,
+    notify_by_email BOOLEAN NOT NULL DEFAULT FALSE,
+    status booking_request_status NOT NULL DEFAULT 'QUEUED',
+    reason TEXT,
+    appointment_id UUID REFERENCES appointments(id) ON DELETE SET NULL,
+    processed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT fk_booking_request_user FOREIGN KEY(user_id) REFERENCES users(id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    CONSTRAINT fk_booking_request_patient FOREIGN KEY(patient_id) REFERENCES patients(id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    CONSTRAINT fk_booking_request_doctor FOREIGN KEY(doctor_id) REFERENCES doctors(id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_booking_requests_user ON booking_requests(user_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS booking_requests;
+DROP TYPE IF EXISTS booking_request_status;
+-- +goose StatementEnd
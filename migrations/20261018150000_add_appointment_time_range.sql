@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- time_range is derived from the date and HH:MM columns by a trigger so the
+-- exclusion constraints below can reject overlapping bookings atomically.
+ALTER TABLE appointments ADD COLUMN IF NOT EXISTS time_range TSTZRANGE;
+
+CREATE OR REPLACE FUNCTION set_appointment_time_range() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.time_range := tstzrange(
+        (NEW.appointment_date::date + NEW.start_time::time) AT TIME ZONE 'UTC',
+        (NEW.appointment_date::date + NEW.end_time::time) AT TIME ZONE 'UTC',
+        '[)'
+    );
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_appointment_time_range
+    BEFORE INSERT OR UPDATE OF appointment_date, start_time, end_time ON appointments
+    FOR EACH ROW EXECUTE FUNCTION set_appointment_time_range();
+
+UPDATE appointments SET time_range = tstzrange(
+    (appointment_date::date + start_time::time) AT TIME ZONE 'UTC',
+    (appointment_date::date + end_time::time) AT TIME ZONE 'UTC',
+    '[)'
+);
+
+ALTER TABLE appointments ALTER COLUMN time_range SET NOT NULL;
+
+-- Existing overlapping bookings must be resolved before this migration runs.
+ALTER TABLE appointments ADD CONSTRAINT excl_appointments_doctor_overlap
+    EXCLUDE USING gist (doctor_id WITH =, time_range WITH &&) WHERE (status <> 'CANCELLED');
+ALTER TABLE appointments ADD CONSTRAINT excl_appointments_patient_overlap
+    EXCLUDE USING gist (patient_id WITH =, time_range WITH &&) WHERE (status <> 'CANCELLED');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE appointments DROP CONSTRAINT IF EXISTS excl_appointments_patient_overlap;
+ALTER TABLE appointments DROP CONSTRAINT IF EXISTS excl_appointments_doctor_overlap;
+DROP TRIGGER IF EXISTS trg_appointment_time_range ON appointments;
+DROP FUNCTION IF EXISTS set_appointment_time_range();
+ALTER TABLE appointments DROP COLUMN IF EXISTS time_range;
+-- +goose StatementEnd
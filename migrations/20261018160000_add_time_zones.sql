@@ -0,0 +1,58 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE doctors ADD COLUMN IF NOT EXISTS time_zone TEXT NOT NULL DEFAULT 'UTC';
+
+-- appointment_date, start_time and end_time are wall-clock values in the
+-- doctor's time zone; starts_at and ends_at are the absolute instants.
+ALTER TABLE appointments ADD COLUMN IF NOT EXISTS starts_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE appointments ADD COLUMN IF NOT EXISTS ends_at TIMESTAMP WITH TIME ZONE;
+
+CREATE OR REPLACE FUNCTION set_appointment_time_range() RETURNS TRIGGER AS $$
+DECLARE
+    tz TEXT;
+BEGIN
+    SELECT time_zone INTO tz FROM doctors WHERE id = NEW.doctor_id;
+    tz := COALESCE(tz, 'UTC');
+    NEW.starts_at := (NEW.appointment_date::date + NEW.start_time::time) AT TIME ZONE tz;
+    NEW.ends_at := (NEW.appointment_date::date + NEW.end_time::time) AT TIME ZONE tz;
+    NEW.time_range := tstzrange(NEW.starts_at, NEW.ends_at, '[)');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_appointment_time_range ON appointments;
+CREATE TRIGGER trg_appointment_time_range
+    BEFORE INSERT OR UPDATE OF appointment_date, start_time, end_time, doctor_id ON appointments
+    FOR EACH ROW EXECUTE FUNCTION set_appointment_time_range();
+
+-- Touching doctor_id fires the trigger, filling the new columns.
+UPDATE appointments SET doctor_id = doctor_id;
+
+ALTER TABLE appointments ALTER COLUMN starts_at SET NOT NULL;
+ALTER TABLE appointments ALTER COLUMN ends_at SET NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_appointments_starts_at ON appointments(starts_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION set_appointment_time_range() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.time_range := tstzrange(
+        (NEW.appointment_date::date + NEW.start_time::time) AT TIME ZONE 'UTC',
+        (NEW.appointment_date::date + NEW.end_time::time) AT TIME ZONE 'UTC',
+        '[)'
+    );
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_appointment_time_range ON appointments;
+CREATE TRIGGER trg_appointment_time_range
+    BEFORE INSERT OR UPDATE OF appointment_date, start_time, end_time ON appointments
+    FOR EACH ROW EXECUTE FUNCTION set_appointment_time_range();
+
+DROP INDEX IF EXISTS idx_appointments_starts_at;
+ALTER TABLE appointments DROP COLUMN IF EXISTS ends_at;
+ALTER TABLE appointments DROP COLUMN IF EXISTS starts_at;
+ALTER TABLE doctors DROP COLUMN IF EXISTS time_zone;
+-- +goose StatementEnd
@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+-- ics_sequence is the iCalendar SEQUENCE of the appointment's event. It is
+-- bumped whenever a change that calendar clients must pick up is made.
+ALTER TABLE appointments ADD COLUMN IF NOT EXISTS ics_sequence INTEGER NOT NULL DEFAULT 0;
+
+CREATE OR REPLACE FUNCTION bump_appointment_ics_sequence() RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.status IS DISTINCT FROM OLD.status
+        OR NEW.appointment_date IS DISTINCT FROM OLD.appointment_date
+        OR NEW.start_time IS DISTINCT FROM OLD.start_time
+        OR NEW.end_time IS DISTINCT FROM OLD.end_time
+        OR NEW.location IS DISTINCT FROM OLD.location
+        OR NEW.mode IS DISTINCT FROM OLD.mode THEN
+        NEW.ics_sequence := OLD.ics_sequence + 1;
+    ELSE
+        NEW.ics_sequence := OLD.ics_sequence;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_appointment_ics_sequence
+    BEFORE UPDATE ON appointments
+    FOR EACH ROW EXECUTE FUNCTION bump_appointment_ics_sequence();
+
+CREATE TABLE IF NOT EXISTS calendar_feed_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL UNIQUE,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_calendar_feed_user FOREIGN KEY(user_id) REFERENCES users(id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS calendar_feed_tokens;
+DROP TRIGGER IF EXISTS trg_appointment_ics_sequence ON appointments;
+DROP FUNCTION IF EXISTS bump_appointment_ics_sequence();
+ALTER TABLE appointments DROP COLUMN IF EXISTS ics_sequence;
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+-- clinic_settings holds clinic-wide configuration in a single row.
+CREATE TABLE IF NOT EXISTS clinic_settings (
+    id SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    reminder_offsets_minutes INTEGER[] NOT NULL DEFAULT '{1440,60}',
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+INSERT INTO clinic_settings (id) VALUES (1) ON CONFLICT DO NOTHING;
+
+CREATE TYPE reminder_status AS ENUM ('SCHEDULED', 'SENT', 'SKIPPED', 'CANCELLED');
+
+CREATE TABLE IF NOT EXISTS appointment_reminders (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    appointment_id UUID NOT NULL,
+    offset_minutes INTEGER NOT NULL,
+    send_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    task_id TEXT NOT NULL UNIQUE,
+    status reminder_status NOT NULL DEFAULT 'SCHEDULED',
+    sent_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT fk_reminder_appointment FOREIGN KEY(appointment_id) REFERENCES appointments(id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_appointment_reminders_scheduled ON appointment_reminders(appointment_id) WHERE status = 'SCHEDULED';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS appointment_reminders;
+DROP TYPE IF EXISTS reminder_status;
+DROP TABLE IF EXISTS clinic_settings;
+-- +goose StatementEnd
@@ -0,0 +1,7 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+ALTER TYPE booking_request_status ADD VALUE IF NOT EXISTS 'AWAITING_APPROVAL';
+ALTER TYPE role ADD VALUE IF NOT EXISTS 'RECEPTIONIST';
+
+-- +goose Down
+-- Postgres cannot drop enum values; they are left in place.
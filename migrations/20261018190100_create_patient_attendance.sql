@@ -0,0 +1,81 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Attendance policy. A no_show_limit of 0 turns the policy off.
+ALTER TABLE clinic_settings
+    ADD COLUMN IF NOT EXISTS no_show_grace_minutes INTEGER NOT NULL DEFAULT 30,
+    ADD COLUMN IF NOT EXISTS no_show_limit INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS no_show_window_days INTEGER NOT NULL DEFAULT 90,
+    ADD COLUMN IF NOT EXISTS no_show_action TEXT NOT NULL DEFAULT 'REQUIRE_APPROVAL'
+        CHECK (no_show_action IN ('REQUIRE_APPROVAL', 'BLOCK'));
+
+ALTER TABLE booking_requests
+    ADD COLUMN IF NOT EXISTS reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP WITH TIME ZONE;
+
+-- patient_attendance keeps lifetime outcome counters per patient. They are
+-- maintained by a trigger so every status change path is counted.
+CREATE TABLE IF NOT EXISTS patient_attendance (
+    patient_id UUID PRIMARY KEY,
+    completed_count INTEGER NOT NULL DEFAULT 0,
+    no_show_count INTEGER NOT NULL DEFAULT 0,
+    cancelled_count INTEGER NOT NULL DEFAULT 0,
+    last_no_show_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT fk_attendance_patient FOREIGN KEY(patient_id) REFERENCES patients(id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+CREATE OR REPLACE FUNCTION count_appointment_attendance() RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.status IS NOT DISTINCT FROM OLD.status
+        OR NEW.status NOT IN ('COMPLETED', 'NO_SHOW', 'CANCELLED') THEN
+        RETURN NEW;
+    END IF;
+
+    INSERT INTO patient_attendance (patient_id) VALUES (NEW.patient_id)
+        ON CONFLICT (patient_id) DO NOTHING;
+
+    UPDATE patient_attendance SET
+        completed_count = completed_count + (NEW.status = 'COMPLETED')::int,
+        no_show_count = no_show_count + (NEW.status = 'NO_SHOW')::int,
+        cancelled_count = cancelled_count + (NEW.status = 'CANCELLED')::int,
+        last_no_show_at = CASE WHEN NEW.status = 'NO_SHOW'
+            THEN GREATEST(last_no_show_at, NEW.starts_at) ELSE last_no_show_at END,
+        updated_at = NOW()
+    WHERE patient_id = NEW.patient_id;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_appointment_attendance
+    AFTER UPDATE OF status ON appointments
+    FOR EACH ROW EXECUTE FUNCTION count_appointment_attendance();
+
+INSERT INTO patient_attendance (patient_id, completed_count, no_show_count, cancelled_count, last_no_show_at)
+SELECT patient_id,
+       COUNT(*) FILTER (WHERE status = 'COMPLETED'),
+       COUNT(*) FILTER (WHERE status = 'NO_SHOW'),
+       COUNT(*) FILTER (WHERE status = 'CANCELLED'),
+       MAX(starts_at) FILTER (WHERE status = 'NO_SHOW')
+FROM appointments
+GROUP BY patient_id
+ON CONFLICT (patient_id) DO NOTHING;
+
+CREATE INDEX IF NOT EXISTS idx_appointments_confirmed_ends_at ON appointments(ends_at) WHERE status = 'CONFIRMED';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_appointments_confirmed_ends_at;
+DROP TRIGGER IF EXISTS trg_appointment_attendance ON appointments;
+DROP FUNCTION IF EXISTS count_appointment_attendance();
+DROP TABLE IF EXISTS patient_attendance;
+ALTER TABLE booking_requests DROP COLUMN IF EXISTS reviewed_at, DROP COLUMN IF EXISTS reviewed_by;
+ALTER TABLE clinic_settings
+    DROP COLUMN IF EXISTS no_show_action,
+    DROP COLUMN IF EXISTS no_show_window_days,
+    DROP COLUMN IF EXISTS no_show_limit,
+    DROP COLUMN IF EXISTS no_show_grace_minutes;
+-- +goose StatementEnd
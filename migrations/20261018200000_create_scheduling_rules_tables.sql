@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+-- doctor_scheduling_rules holds pacing rules for a doctor's day. A zero
+-- turns the matching rule off.
+CREATE TABLE IF NOT EXISTS doctor_scheduling_rules (
+    doctor_id UUID PRIMARY KEY,
+    buffer_minutes INT NOT NULL DEFAULT 0 CHECK (buffer_minutes >= 0 AND buffer_minutes <= 120),
+    break_after_consecutive INT NOT NULL DEFAULT 0 CHECK (break_after_consecutive >= 0),
+    break_minutes INT NOT NULL DEFAULT 0 CHECK (break_minutes >= 0 AND break_minutes <= 240),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_scheduling_rules_doctor FOREIGN KEY(doctor_id) REFERENCES doctors(id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS doctor_daily_caps (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    doctor_id UUID NOT NULL,
+    appointment_type appt_type NOT NULL,
+    max_per_day INT NOT NULL CHECK (max_per_day > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_daily_cap_doctor FOREIGN KEY(doctor_id) REFERENCES doctors(id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    CONSTRAINT uq_daily_cap_doctor_type UNIQUE (doctor_id, appointment_type)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS doctor_daily_caps;
+DROP TABLE IF EXISTS doctor_scheduling_rules;
+-- +goose StatementEnd
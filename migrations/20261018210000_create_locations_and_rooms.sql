@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS locations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name TEXT NOT NULL UNIQUE,
+    address TEXT NOT NULL DEFAULT '',
+    phone TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS rooms (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    location_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_room_location FOREIGN KEY(location_id) REFERENCES locations(id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    CONSTRAINT uq_room_location_name UNIQUE (location_id, name)
+);
+
+ALTER TABLE doctor_working_hours
+    ADD COLUMN IF NOT EXISTS location_id UUID REFERENCES locations(id) ON DELETE SET NULL;
+
+ALTER TABLE appointments
+    ADD COLUMN IF NOT EXISTS location_id UUID REFERENCES locations(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS room_id UUID REFERENCES rooms(id) ON DELETE SET NULL;
+
+-- A room holds one appointment at a time, whichever doctor booked it.
+ALTER TABLE appointments ADD CONSTRAINT excl_appointments_room_overlap
+    EXCLUDE USING gist (room_id WITH =, time_range WITH &&) WHERE (status <> 'CANCELLED' AND room_id IS NOT NULL);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE appointments DROP CONSTRAINT IF EXISTS excl_appointments_room_overlap;
+ALTER TABLE appointments DROP COLUMN IF EXISTS room_id, DROP COLUMN IF EXISTS location_id;
+ALTER TABLE doctor_working_hours DROP COLUMN IF EXISTS location_id;
+DROP TABLE IF EXISTS rooms;
+DROP TABLE IF EXISTS locations;
+-- +goose StatementEnd
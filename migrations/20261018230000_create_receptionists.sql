@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+-- location_id is the front desk a receptionist works at; NULL covers every
+-- location.
+CREATE TABLE IF NOT EXISTS receptionists (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL UNIQUE,
+    location_id UUID,
+
+    CONSTRAINT fk_receptionist_user FOREIGN KEY(user_id) REFERENCES users(id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    CONSTRAINT fk_receptionist_location FOREIGN KEY(location_id) REFERENCES locations(id)
+        ON DELETE SET NULL
+);
+
+-- Who booked an appointment and who last changed it, for staff acting on a
+-- patient's behalf.
+ALTER TABLE appointments
+    ADD COLUMN IF NOT EXISTS booked_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS updated_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+UPDATE appointments a SET booked_by = b.user_id
+FROM booking_requests b
+WHERE b.appointment_id = a.id AND a.booked_by IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE appointments DROP COLUMN IF EXISTS updated_by, DROP COLUMN IF EXISTS booked_by;
+DROP TABLE IF EXISTS receptionists;
+-- +goose StatementEnd
@@ -0,0 +1,7 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+ALTER TYPE appointment_status ADD VALUE IF NOT EXISTS 'CHECKED_IN';
+ALTER TYPE appointment_status ADD VALUE IF NOT EXISTS 'IN_CONSULTATION';
+
+-- +goose Down
+-- Postgres cannot drop enum values; they are left in place.
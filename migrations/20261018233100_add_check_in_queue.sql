@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- check_in_code lets patients check themselves in at arrival. The queue
+-- timestamps are set as a visit moves through check-in and consultation.
+ALTER TABLE appointments
+    ADD COLUMN IF NOT EXISTS check_in_code TEXT NOT NULL DEFAULT upper(substr(md5(random()::text), 1, 6)),
+    ADD COLUMN IF NOT EXISTS checked_in_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS consultation_started_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS consultation_ended_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_appointments_doctor_queue ON appointments(doctor_id, starts_at)
+    WHERE status IN ('CHECKED_IN', 'IN_CONSULTATION');
+CREATE INDEX IF NOT EXISTS idx_appointments_doctor_consultations ON appointments(doctor_id, consultation_ended_at)
+    WHERE consultation_started_at IS NOT NULL AND consultation_ended_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_appointments_doctor_consultations;
+DROP INDEX IF EXISTS idx_appointments_doctor_queue;
+ALTER TABLE appointments
+    DROP COLUMN IF EXISTS consultation_ended_at,
+    DROP COLUMN IF EXISTS consultation_started_at,
+    DROP COLUMN IF EXISTS checked_in_at,
+    DROP COLUMN IF EXISTS check_in_code;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- triage_level ranks emergencies from 1 (immediate) to 5 (least urgent) and
+-- orders them ahead of routine visits in the waiting-room queue.
+ALTER TABLE appointments
+    ADD COLUMN IF NOT EXISTS triage_level SMALLINT CHECK (triage_level BETWEEN 1 AND 5);
+ALTER TABLE booking_requests
+    ADD COLUMN IF NOT EXISTS triage_level SMALLINT CHECK (triage_level BETWEEN 1 AND 5);
+
+-- Emergencies may overbook the doctor; they still cannot double-book the
+-- patient or a room.
+ALTER TABLE appointments DROP CONSTRAINT IF EXISTS excl_appointments_doctor_overlap;
+ALTER TABLE appointments ADD CONSTRAINT excl_appointments_doctor_overlap
+    EXCLUDE USING gist (doctor_id WITH =, time_range WITH &&)
+    WHERE (status <> 'CANCELLED' AND appointment_type <> 'EMERGENCY');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Overbooked emergencies must be resolved before rolling back.
+ALTER TABLE appointments DROP CONSTRAINT IF EXISTS excl_appointments_doctor_overlap;
+ALTER TABLE appointments ADD CONSTRAINT excl_appointments_doctor_overlap
+    EXCLUDE USING gist (doctor_id WITH =, time_range WITH &&) WHERE (status <> 'CANCELLED');
+ALTER TABLE booking_requests DROP COLUMN IF EXISTS triage_level;
+ALTER TABLE appointments DROP COLUMN IF EXISTS triage_level;
+-- +goose StatementEnd
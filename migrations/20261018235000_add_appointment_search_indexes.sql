@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Indexes for appointment search: each filter column is paired with the date
+-- so date ranges are served from the same index.
+CREATE INDEX IF NOT EXISTS idx_appointments_doctor_date ON appointments(doctor_id, appointment_date);
+CREATE INDEX IF NOT EXISTS idx_appointments_patient_date ON appointments(patient_id, appointment_date);
+CREATE INDEX IF NOT EXISTS idx_appointments_location_date ON appointments(location_id, appointment_date);
+CREATE INDEX IF NOT EXISTS idx_appointments_status_date ON appointments(status, appointment_date);
+CREATE INDEX IF NOT EXISTS idx_appointments_date ON appointments(appointment_date);
+
+-- Name search matches anywhere in the full name, which needs trigrams.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_users_full_name_trgm ON users
+    USING gin ((first_name || ' ' || last_name) gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_users_full_name_trgm;
+DROP INDEX IF EXISTS idx_appointments_date;
+DROP INDEX IF EXISTS idx_appointments_status_date;
+DROP INDEX IF EXISTS idx_appointments_location_date;
+DROP INDEX IF EXISTS idx_appointments_patient_date;
+DROP INDEX IF EXISTS idx_appointments_doctor_date;
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Patient cancellations less than late_cancel_window_minutes before the
+-- start are flagged or blocked. A window of 0 turns the policy off.
+ALTER TABLE clinic_settings
+    ADD COLUMN IF NOT EXISTS late_cancel_window_minutes INTEGER NOT NULL DEFAULT 1440,
+    ADD COLUMN IF NOT EXISTS late_cancel_action TEXT NOT NULL DEFAULT 'FLAG'
+        CHECK (late_cancel_action IN ('FLAG', 'BLOCK'));
+
+-- Cancellations made before reason codes were required have none.
+ALTER TABLE appointments
+    ADD COLUMN IF NOT EXISTS cancellation_reason TEXT
+        CHECK (cancellation_reason IN ('PATIENT_REQUEST', 'PATIENT_ILLNESS', 'SCHEDULE_CONFLICT',
+            'DOCTOR_UNAVAILABLE', 'CLINIC_CLOSURE', 'DUPLICATE_BOOKING', 'OTHER')),
+    ADD COLUMN IF NOT EXISTS late_cancellation BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS cancelled_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE appointments a SET cancelled_at = h.created_at
+FROM (
+    SELECT appointment_id, MAX(created_at) AS created_at FROM appointment_status_history
+    WHERE to_status = 'CANCELLED' GROUP BY appointment_id
+) h
+WHERE h.appointment_id = a.id AND a.status = 'CANCELLED';
+
+CREATE INDEX IF NOT EXISTS idx_appointments_late_cancellations ON appointments(patient_id, cancelled_at)
+    WHERE late_cancellation;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_appointments_late_cancellations;
+ALTER TABLE appointments
+    DROP COLUMN IF EXISTS cancelled_at,
+    DROP COLUMN IF EXISTS late_cancellation,
+    DROP COLUMN IF EXISTS cancellation_reason;
+ALTER TABLE clinic_settings
+    DROP COLUMN IF EXISTS late_cancel_action,
+    DROP COLUMN IF EXISTS late_cancel_window_minutes;
+-- +goose StatementEnd
@@ -0,0 +1,59 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE reschedule_batch_status AS ENUM ('DRAFT', 'CONFIRMED');
+CREATE TYPE reschedule_proposal_status AS ENUM ('PROPOSED', 'NO_SLOT', 'MOVED', 'FAILED', 'ACCEPTED', 'DECLINED');
+
+-- A reschedule batch moves every appointment a doctor has in a period of
+-- unavailability. Admins review the proposals and confirm them together.
+CREATE TABLE IF NOT EXISTS reschedule_batches (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    doctor_id UUID NOT NULL,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    reason TEXT,
+    status reschedule_batch_status NOT NULL DEFAULT 'DRAFT',
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    confirmed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT fk_reschedule_batch_doctor FOREIGN KEY(doctor_id) REFERENCES doctors(id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    CONSTRAINT chk_reschedule_batch_period CHECK (ends_at > starts_at)
+);
+
+-- Each proposal keeps the original booking next to the proposed one, since
+-- the appointment itself is moved on confirmation.
+CREATE TABLE IF NOT EXISTS reschedule_proposals (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    batch_id UUID NOT NULL REFERENCES reschedule_batches(id) ON DELETE CASCADE,
+    appointment_id UUID NOT NULL REFERENCES appointments(id) ON DELETE CASCADE,
+    patient_id UUID NOT NULL REFERENCES patients(id) ON DELETE CASCADE,
+    original_doctor_id UUID NOT NULL,
+    original_date DATE NOT NULL,
+    original_start_time VARCHAR(10) NOT NULL,
+    original_end_time VARCHAR(10) NOT NULL,
+    doctor_id UUID REFERENCES doctors(id) ON DELETE SET NULL,
+    appointment_date DATE,
+    start_time VARCHAR(10),
+    end_time VARCHAR(10),
+    starts_at TIMESTAMP WITH TIME ZONE,
+    status reschedule_proposal_status NOT NULL DEFAULT 'PROPOSED',
+    reason TEXT,
+    responded_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_reschedule_proposals_batch ON reschedule_proposals(batch_id);
+CREATE INDEX IF NOT EXISTS idx_reschedule_proposals_appointment ON reschedule_proposals(appointment_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS reschedule_proposals;
+DROP TABLE IF EXISTS reschedule_batches;
+DROP TYPE IF EXISTS reschedule_proposal_status;
+DROP TYPE IF EXISTS reschedule_batch_status;
+-- +goose StatementEnd
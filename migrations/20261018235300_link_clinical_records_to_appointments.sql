@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Records written when a visit is completed point back at its appointment.
+-- Older rows, and records written outside a visit, have none.
+ALTER TABLE medical_records
+    ADD COLUMN IF NOT EXISTS appointment_id UUID REFERENCES appointments(id) ON UPDATE CASCADE ON DELETE SET NULL;
+ALTER TABLE vitals
+    ADD COLUMN IF NOT EXISTS appointment_id UUID REFERENCES appointments(id) ON UPDATE CASCADE ON DELETE SET NULL;
+ALTER TABLE prescriptions
+    ADD COLUMN IF NOT EXISTS appointment_id UUID REFERENCES appointments(id) ON UPDATE CASCADE ON DELETE SET NULL;
+
+-- A visit produces at most one medical record.
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_medical_records_appointment ON medical_records(appointment_id)
+    WHERE appointment_id IS NOT NULL AND deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_vitals_appointment ON vitals(appointment_id) WHERE appointment_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_prescriptions_appointment ON prescriptions(appointment_id) WHERE appointment_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_prescriptions_appointment;
+DROP INDEX IF EXISTS idx_vitals_appointment;
+DROP INDEX IF EXISTS uniq_medical_records_appointment;
+ALTER TABLE prescriptions DROP COLUMN IF EXISTS appointment_id;
+ALTER TABLE vitals DROP COLUMN IF EXISTS appointment_id;
+ALTER TABLE medical_records DROP COLUMN IF EXISTS appointment_id;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- verified_at is set once the owner proves the address with a code, or an
+-- admin vouches for it. Accounts that predate verification are trusted.
+ALTER TABLE auth ADD COLUMN IF NOT EXISTS verified_at TIMESTAMP WITH TIME ZONE;
+UPDATE auth SET verified_at = created_at WHERE verified_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE auth DROP COLUMN IF EXISTS verified_at;
+-- +goose StatementEnd
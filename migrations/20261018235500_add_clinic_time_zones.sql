@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Holidays and clinic-wide views are kept on the clinic's calendar. Doctors
+-- have so far started in the clinic's zone, so the most common one is the
+-- best guess for existing installations.
+ALTER TABLE clinic_settings ADD COLUMN IF NOT EXISTS time_zone TEXT NOT NULL DEFAULT 'UTC';
+UPDATE clinic_settings SET time_zone = COALESCE(
+    (SELECT time_zone FROM doctors GROUP BY time_zone ORDER BY COUNT(*) DESC LIMIT 1),
+    'UTC'
+);
+
+-- A location in another zone than the clinic sets its own. An empty value
+-- means the clinic's zone.
+ALTER TABLE locations ADD COLUMN IF NOT EXISTS time_zone TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE locations DROP COLUMN IF EXISTS time_zone;
+ALTER TABLE clinic_settings DROP COLUMN IF EXISTS time_zone;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Working hours belong to doctors(id), see fk_doctor_working_hours_doctor_id.
+-- The original constraint against users(id) was never dropped and rejects
+-- every row, since doctor IDs differ from their user IDs.
+ALTER TABLE doctor_working_hours DROP CONSTRAINT IF EXISTS fk_doctor;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE doctor_working_hours
+ADD CONSTRAINT fk_doctor
+FOREIGN KEY (doctor_id) REFERENCES users(id)
+ON DELETE CASCADE
+ON UPDATE CASCADE;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- patient_joined_at records when the patient first fetched the join link of
+-- an Online appointment, which stands in for a check-in.
+ALTER TABLE appointments
+    ADD COLUMN IF NOT EXISTS patient_joined_at TIMESTAMP WITH TIME ZONE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE appointments
+    DROP COLUMN IF EXISTS patient_joined_at;
+-- +goose StatementEnd
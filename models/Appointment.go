@@ -14,12 +14,44 @@ type Appointment struct {
 	StartTime       string            `gorm:"column:start_time;not null"`
 	EndTime         string            `gorm:"column:end_time;not null"`
 	Status          AppointmentStatus `gorm:"type:appointment_status;not null" default:"PENDING"`
+	// Location is a display label; for appointments at a clinic location it
+	// names the location and room.
 	Location        string
-	Mode            string    `gorm:"type:mode;not null" default:"Online"` // Online or In-Person
-	AppointmentType ApptType  `gorm:"type:appt_type;not null" default:"CONSULTATION"`
-	Notes           string    `gorm:"type:text"`
-	CreatedAt       time.Time `gorm:"autoCreateTime"`
-	UpdatedAt       time.Time `gorm:"autoUpdateTime"`
-	Patient         Patient
-	Doctor          Doctor
+	LocationID      *uuid.UUID `gorm:"type:uuid"`
+	RoomID          *uuid.UUID `gorm:"type:uuid"`
+	Mode            string     `gorm:"type:mode;not null" default:"Online"` // Online or In-Person
+	AppointmentType ApptType   `gorm:"type:appt_type;not null" default:"CONSULTATION"`
+	TriageLevel     *int       `gorm:"type:smallint"` // 1 (most urgent) to 5, emergencies only
+	Notes           string     `gorm:"type:text"`
+	SeriesID        *uuid.UUID `gorm:"type:uuid"`
+	SeriesIndex     *int
+	// BookedBy and UpdatedBy are the users who booked and last changed the
+	// appointment; they differ from the patient when staff act for them.
+	BookedBy  *uuid.UUID `gorm:"type:uuid"`
+	UpdatedBy *uuid.UUID `gorm:"type:uuid"`
+	// StartsAt and EndsAt are the absolute instants of the appointment,
+	// derived by the database from the date, times and the doctor's zone.
+	StartsAt *time.Time `gorm:"default:null"`
+	EndsAt   *time.Time `gorm:"default:null"`
+	// CheckInCode is generated by the database and lets the patient check
+	// in at arrival. The timestamps below track the visit through the queue;
+	// PatientJoinedAt is the Online counterpart of CheckedInAt.
+	CheckInCode           string     `gorm:"->"`
+	CheckedInAt           *time.Time `gorm:"default:null"`
+	PatientJoinedAt       *time.Time `gorm:"default:null"`
+	ConsultationStartedAt *time.Time `gorm:"default:null"`
+	ConsultationEndedAt   *time.Time `gorm:"default:null"`
+	// CancellationReason and LateCancellation record why an appointment was
+	// cancelled and whether the patient cancelled it at short notice.
+	CancellationReason *CancellationReason `gorm:"type:text"`
+	LateCancellation   bool                `gorm:"not null;default:false"`
+	CancelledAt        *time.Time          `gorm:"default:null"`
+	// Sequence is the iCalendar SEQUENCE, maintained by the database.
+	Sequence  int       `gorm:"column:ics_sequence;default:0"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+	Patient   Patient
+	Doctor    Doctor
+	Clinic    *Location `gorm:"foreignKey:LocationID"`
+	Room      *Room     `gorm:"foreignKey:RoomID"`
 }
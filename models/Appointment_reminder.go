@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AppointmentReminder records a reminder queued as a delayed task so it can
+// be cancelled when the appointment changes.
+type AppointmentReminder struct {
+	ID            uuid.UUID      `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	AppointmentID uuid.UUID      `gorm:"type:uuid;not null" json:"appointment_id"`
+	OffsetMinutes int            `gorm:"not null" json:"offset_minutes"`
+	SendAt        time.Time      `gorm:"not null" json:"send_at"`
+	TaskID        string         `gorm:"not null;uniqueIndex" json:"-"`
+	Status        ReminderStatus `gorm:"type:reminder_status;not null;default:SCHEDULED" json:"status"`
+	SentAt        *time.Time     `json:"sent_at,omitempty"`
+	CreatedAt     time.Time      `gorm:"autoCreateTime" json:"created_at"`
+}
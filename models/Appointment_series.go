@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RecurrenceFrequency string
+
+const (
+	RecurrenceWeekly  RecurrenceFrequency = "WEEKLY"
+	RecurrenceMonthly RecurrenceFrequency = "MONTHLY"
+)
+
+// AppointmentSeries is the recurrence rule behind a set of appointments. Each
+// occurrence is a normal Appointment pointing back through SeriesID.
+type AppointmentSeries struct {
+	ID              uuid.UUID           `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	PatientID       uuid.UUID           `gorm:"type:uuid;not null" json:"patient_id"`
+	DoctorID        uuid.UUID           `gorm:"type:uuid;not null" json:"doctor_id"`
+	Frequency       RecurrenceFrequency `gorm:"not null" json:"frequency"`
+	Interval        int                 `gorm:"column:repeat_interval;not null;default:1" json:"interval"`
+	Count           *int                `gorm:"column:repeat_count" json:"count,omitempty"`
+	Until           *time.Time          `gorm:"column:repeat_until;type:date" json:"until,omitempty"`
+	StartDate       time.Time           `gorm:"type:date;not null" json:"start_date"`
+	StartTime       string              `gorm:"not null" json:"start_time"`
+	EndTime         string              `gorm:"not null" json:"end_time"`
+	Mode            string              `gorm:"not null" json:"mode"`
+	AppointmentType ApptType            `gorm:"type:appt_type;not null" json:"appointment_type"`
+	Notes           string              `gorm:"type:text" json:"notes"`
+	CancelledAt     *time.Time          `json:"cancelled_at,omitempty"`
+	CreatedAt       time.Time           `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time           `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Appointments []Appointment `gorm:"foreignKey:SeriesID" json:"appointments,omitempty"`
+}
+
+func (AppointmentSeries) TableName() string {
+	return "appointment_series"
+}
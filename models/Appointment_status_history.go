@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AppointmentStatusHistory records a single status transition of an
+// appointment and who made it.
+type AppointmentStatusHistory struct {
+	ID            uuid.UUID         `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	AppointmentID uuid.UUID         `gorm:"type:uuid;not null" json:"appointment_id"`
+	FromStatus    AppointmentStatus `gorm:"type:appointment_status;not null" json:"from_status"`
+	ToStatus      AppointmentStatus `gorm:"type:appointment_status;not null" json:"to_status"`
+	ChangedBy     *uuid.UUID        `gorm:"type:uuid" json:"changed_by,omitempty"`
+	ActorRole     Role              `gorm:"type:varchar(20)" json:"actor_role,omitempty"`
+	Reason        string            `gorm:"type:text" json:"reason"`
+	CreatedAt     time.Time         `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (AppointmentStatusHistory) TableName() string {
+	return "appointment_status_history"
+}
@@ -12,6 +12,9 @@ type Auth struct {
 	Password  string    `gorm:"not null"`
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+	// VerifiedAt is when the email address was verified; logins are refused
+	// until it is set.
+	VerifiedAt *time.Time
 }
 
 func (Auth) TableName() string {
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BookingRequest tracks an appointment booked through the job queue from
+// enqueue until the worker books or rejects it.
+type BookingRequest struct {
+	ID              uuid.UUID            `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	UserID          uuid.UUID            `gorm:"type:uuid;not null" json:"user_id"`
+	PatientID       uuid.UUID            `gorm:"type:uuid;not null" json:"patient_id"`
+	DoctorID        uuid.UUID            `gorm:"type:uuid;not null" json:"doctor_id"`
+	AppointmentDate time.Time            `gorm:"not null" json:"appointment_date"`
+	StartTime       string               `gorm:"not null" json:"start_time"`
+	EndTime         string               `gorm:"not null" json:"end_time"`
+	AppointmentType ApptType             `gorm:"type:appt_type;not null" json:"appointment_type"`
+	Mode            string               `gorm:"not null" json:"mode"`
+	Notes           string               `gorm:"type:text" json:"notes"`
+	TriageLevel     *int                 `gorm:"type:smallint" json:"triage_level,omitempty"`
+	NotifyByEmail   bool                 `gorm:"not null;default:false" json:"notify_by_email"`
+	Status          BookingRequestStatus `gorm:"type:booking_request_status;not null;default:QUEUED" json:"status"`
+	Reason          string               `gorm:"type:text" json:"reason,omitempty"`
+	AppointmentID   *uuid.UUID           `gorm:"type:uuid" json:"appointment_id,omitempty"`
+	ProcessedAt     *time.Time           `json:"processed_at,omitempty"`
+	ReviewedBy      *uuid.UUID           `gorm:"type:uuid" json:"reviewed_by,omitempty"`
+	ReviewedAt      *time.Time           `json:"reviewed_at,omitempty"`
+	CreatedAt       time.Time            `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time            `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ClinicSettings is the clinic's configuration. The table holds one row.
+type ClinicSettings struct {
+	ID uint `gorm:"primaryKey;default:1" json:"-"`
+	// ReminderOffsetsMinutes lists how long before an appointment each
+	// reminder is sent.
+	ReminderOffsetsMinutes pq.Int64Array `gorm:"type:integer[];not null" json:"reminder_offsets_minutes"`
+	// NoShowGraceMinutes is how long after a confirmed appointment ends
+	// without a check-in, or for Online visits a join, before it is marked as
+	// a no-show.
+	NoShowGraceMinutes int `gorm:"not null;default:30" json:"no_show_grace_minutes"`
+	// NoShowLimit no-shows within NoShowWindowDays trigger NoShowAction on
+	// the patient's next booking. 0 turns the policy off.
+	NoShowLimit      int          `gorm:"not null;default:0" json:"no_show_limit"`
+	NoShowWindowDays int          `gorm:"not null;default:90" json:"no_show_window_days"`
+	NoShowAction     NoShowAction `gorm:"not null;default:REQUIRE_APPROVAL" json:"no_show_action"`
+	// Patients cancelling less than LateCancelWindowMinutes before the start
+	// are subject to LateCancelAction. 0 turns the window off.
+	LateCancelWindowMinutes int              `gorm:"not null;default:1440" json:"late_cancel_window_minutes"`
+	LateCancelAction        LateCancelAction `gorm:"not null;default:FLAG" json:"late_cancel_action"`
+	// TimeZone is the IANA zone of the clinic's calendar. Holidays fall on
+	// its dates and new doctors start in it.
+	TimeZone  string    `gorm:"not null;default:UTC" json:"time_zone"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (ClinicSettings) TableName() string {
+	return "clinic_settings"
+}
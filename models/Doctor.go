@@ -10,6 +10,7 @@ type Doctor struct {
 	User   *User
 
 	Specialization string
+	TimeZone       string `gorm:"not null;default:UTC"`
 	Appointments   []Appointment
 	Prescriptions  []Prescription
 }
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DoctorSchedulingRules paces a doctor's day. A zero value turns the
+// matching rule off.
+type DoctorSchedulingRules struct {
+	DoctorID uuid.UUID `gorm:"primaryKey;type:uuid" json:"doctor_id"`
+	// BufferMinutes must stay free between two appointments.
+	BufferMinutes int `gorm:"not null;default:0" json:"buffer_minutes"`
+	// After BreakAfterConsecutive back-to-back appointments the doctor gets
+	// a break of at least BreakMinutes.
+	BreakAfterConsecutive int       `gorm:"not null;default:0" json:"break_after_consecutive"`
+	BreakMinutes          int       `gorm:"not null;default:0" json:"break_minutes"`
+	CreatedAt             time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt             time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// DoctorDailyCap limits how many appointments of one type a doctor takes
+// per day.
+type DoctorDailyCap struct {
+	ID              uuid.UUID `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	DoctorID        uuid.UUID `gorm:"type:uuid;not null" json:"doctor_id"`
+	AppointmentType ApptType  `gorm:"type:appt_type;not null" json:"appointment_type"`
+	MaxPerDay       int       `gorm:"not null" json:"max_per_day"`
+	CreatedAt       time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
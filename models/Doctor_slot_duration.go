@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DoctorSlotDuration overrides the default slot length of an appointment type
+// for a single doctor.
+type DoctorSlotDuration struct {
+	ID              uuid.UUID `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	DoctorID        uuid.UUID `gorm:"type:uuid;not null" json:"doctor_id"`
+	AppointmentType ApptType  `gorm:"type:appt_type;not null" json:"appointment_type"`
+	DurationMinutes int       `gorm:"not null" json:"duration_minutes"`
+	CreatedAt       time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -13,6 +13,12 @@ type Doctor_working_hours struct {
 	StartTime string    `gorm:"type:time;not null"`
 	EndTime   string    `gorm:"type:time;not null"`
 	IsActive  bool      `gorm:"type:boolean;not null" default:"true"`
-	CreatedAt time.Time `gorm:"autoCreateTime"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+	// LocationID is the clinic the doctor works at during this block.
+	LocationID *uuid.UUID `gorm:"type:uuid"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime"`
+}
+
+func (Doctor_working_hours) TableName() string {
+	return "doctor_working_hours"
 }
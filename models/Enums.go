@@ -12,10 +12,13 @@ const (
 type AppointmentStatus string
 
 const (
-	AppointmentStatusPending   AppointmentStatus = "PENDING"
-	AppointmentStatusConfirmed AppointmentStatus = "CONFIRMED"
-	AppointmentStatusCancelled AppointmentStatus = "CANCELLED"
-	AppointmentStatusCompleted AppointmentStatus = "COMPLETED"
+	AppointmentStatusPending        AppointmentStatus = "PENDING"
+	AppointmentStatusConfirmed      AppointmentStatus = "CONFIRMED"
+	AppointmentStatusCheckedIn      AppointmentStatus = "CHECKED_IN"
+	AppointmentStatusInConsultation AppointmentStatus = "IN_CONSULTATION"
+	AppointmentStatusCancelled      AppointmentStatus = "CANCELLED"
+	AppointmentStatusCompleted      AppointmentStatus = "COMPLETED"
+	AppointmentStatusNoShow         AppointmentStatus = "NO_SHOW"
 )
 
 type VitalType string
@@ -46,3 +49,97 @@ const (
 	ApptTypeCheckup      ApptType = "CHECKUP"
 	ApptTypeEmergency    ApptType = "EMERGENCY"
 )
+
+// Triage levels rank emergencies on a five-level scale, most urgent first.
+const (
+	TriageLevelImmediate = 1
+	TriageLevelLowest    = 5
+)
+
+type ScheduleExceptionType string
+
+const (
+	ScheduleExceptionTimeOff    ScheduleExceptionType = "TIME_OFF"
+	ScheduleExceptionExtraHours ScheduleExceptionType = "EXTRA_HOURS"
+)
+
+type WaitlistStatus string
+
+const (
+	WaitlistWaiting   WaitlistStatus = "WAITING"
+	WaitlistOffered   WaitlistStatus = "OFFERED"
+	WaitlistBooked    WaitlistStatus = "BOOKED"
+	WaitlistCancelled WaitlistStatus = "CANCELLED"
+)
+
+type BookingRequestStatus string
+
+const (
+	BookingRequestQueued     BookingRequestStatus = "QUEUED"
+	BookingRequestProcessing BookingRequestStatus = "PROCESSING"
+	BookingRequestBooked     BookingRequestStatus = "BOOKED"
+	BookingRequestRejected   BookingRequestStatus = "REJECTED"
+	BookingRequestFailed     BookingRequestStatus = "FAILED"
+	// BookingRequestAwaitingApproval holds a request from a patient caught
+	// by the attendance policy until staff review it.
+	BookingRequestAwaitingApproval BookingRequestStatus = "AWAITING_APPROVAL"
+)
+
+type ReminderStatus string
+
+const (
+	ReminderScheduled ReminderStatus = "SCHEDULED"
+	ReminderSent      ReminderStatus = "SENT"
+	ReminderSkipped   ReminderStatus = "SKIPPED"
+	ReminderCancelled ReminderStatus = "CANCELLED"
+)
+
+// NoShowAction is what the attendance policy does to a new booking.
+type NoShowAction string
+
+const (
+	NoShowRequireApproval NoShowAction = "REQUIRE_APPROVAL"
+	NoShowBlock           NoShowAction = "BLOCK"
+)
+
+// CancellationReason is the coded reason an appointment was cancelled.
+type CancellationReason string
+
+const (
+	CancelReasonPatientRequest    CancellationReason = "PATIENT_REQUEST"
+	CancelReasonPatientIllness    CancellationReason = "PATIENT_ILLNESS"
+	CancelReasonScheduleConflict  CancellationReason = "SCHEDULE_CONFLICT"
+	CancelReasonDoctorUnavailable CancellationReason = "DOCTOR_UNAVAILABLE"
+	CancelReasonClinicClosure     CancellationReason = "CLINIC_CLOSURE"
+	CancelReasonDuplicateBooking  CancellationReason = "DUPLICATE_BOOKING"
+	CancelReasonOther             CancellationReason = "OTHER"
+)
+
+// LateCancelAction is what the cancellation policy does when a patient
+// cancels inside the late-cancellation window.
+type LateCancelAction string
+
+const (
+	LateCancelFlag  LateCancelAction = "FLAG"
+	LateCancelBlock LateCancelAction = "BLOCK"
+)
+
+type RescheduleBatchStatus string
+
+const (
+	RescheduleBatchDraft     RescheduleBatchStatus = "DRAFT"
+	RescheduleBatchConfirmed RescheduleBatchStatus = "CONFIRMED"
+)
+
+// RescheduleProposalStatus tracks a proposal from the draft, through the
+// move on confirmation, to the patient's answer.
+type RescheduleProposalStatus string
+
+const (
+	RescheduleProposed RescheduleProposalStatus = "PROPOSED"
+	RescheduleNoSlot   RescheduleProposalStatus = "NO_SLOT"
+	RescheduleMoved    RescheduleProposalStatus = "MOVED"
+	RescheduleFailed   RescheduleProposalStatus = "FAILED"
+	RescheduleAccepted RescheduleProposalStatus = "ACCEPTED"
+	RescheduleDeclined RescheduleProposalStatus = "DECLINED"
+)
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Location is a clinic site. Doctors' working hours are tied to one, and
+// in-person appointments there are given one of its rooms. TimeZone is set
+// when the site keeps another IANA zone than the clinic; empty means the
+// clinic's zone.
+type Location struct {
+	ID        uuid.UUID `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	Name      string    `gorm:"not null;uniqueIndex" json:"name"`
+	Address   string    `gorm:"not null;default:''" json:"address"`
+	Phone     string    `gorm:"not null;default:''" json:"phone"`
+	TimeZone  string    `gorm:"not null;default:''" json:"time_zone"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	Rooms     []Room    `json:"rooms,omitempty"`
+}
+
+// Room is an exam room shared by the doctors working at its location.
+type Room struct {
+	ID         uuid.UUID `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	LocationID uuid.UUID `gorm:"type:uuid;not null" json:"location_id"`
+	Name       string    `gorm:"not null" json:"name"`
+	IsActive   bool      `gorm:"not null;default:true" json:"is_active"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -15,6 +15,8 @@ type MedicalRecord struct {
 	CreatedAt time.Time  `gorm:"autoCreateTime"`
 	UpdatedAt time.Time  `gorm:"autoUpdateTime"`
 	DeletedAt *time.Time `gorm:"index"`
+	// AppointmentID is the visit the record was written at, if any.
+	AppointmentID *uuid.UUID `gorm:"type:uuid"`
 
 	Patient Patient `gorm:"foreignKey:PatientID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 	Doctor  Doctor  `gorm:"foreignKey:DoctorID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
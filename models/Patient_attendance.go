@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PatientAttendance holds a patient's lifetime appointment outcomes. The
+// database keeps it current as appointment statuses change.
+type PatientAttendance struct {
+	PatientID      uuid.UUID  `gorm:"primaryKey;type:uuid" json:"patient_id"`
+	CompletedCount int        `gorm:"not null;default:0" json:"completed_count"`
+	NoShowCount    int        `gorm:"not null;default:0" json:"no_show_count"`
+	CancelledCount int        `gorm:"not null;default:0" json:"cancelled_count"`
+	LastNoShowAt   *time.Time `json:"last_no_show_at,omitempty"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (PatientAttendance) TableName() string {
+	return "patient_attendance"
+}
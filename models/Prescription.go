@@ -18,6 +18,7 @@ type Prescription struct {
 	UpdatedAt       time.Time  `gorm:"autoUpdateTime"`
 	DeletedAt       *time.Time `gorm:"index"`
 	MedicalRecordID *uuid.UUID `gorm:"type:uuid"`
+	AppointmentID   *uuid.UUID `gorm:"type:uuid"`
 
 	MedicalRecord MedicalRecord `gorm:"foreignKey:MedicalRecordID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
 	Patient       Patient       `gorm:"foreignKey:PatientID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
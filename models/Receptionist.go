@@ -2,8 +2,12 @@ package models
 
 import "github.com/google/uuid"
 
+// Receptionist is front-desk staff. LocationID limits them to one clinic
+// location; nil covers all of them.
 type Receptionist struct {
-	ID     uuid.UUID `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
-	UserID uuid.UUID `gorm:"uniqueIndex"`
-	User   User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	ID         uuid.UUID  `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	UserID     uuid.UUID  `gorm:"uniqueIndex"`
+	User       User       `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	LocationID *uuid.UUID `gorm:"type:uuid"`
+	Location   *Location  `gorm:"foreignKey:LocationID"`
 }
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RescheduleBatch gathers the appointments a doctor cannot attend between
+// StartsAt and EndsAt, with a proposed new slot for each.
+type RescheduleBatch struct {
+	ID          uuid.UUID             `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	DoctorID    uuid.UUID             `gorm:"type:uuid;not null" json:"doctor_id"`
+	StartsAt    time.Time             `gorm:"not null" json:"starts_at"`
+	EndsAt      time.Time             `gorm:"not null" json:"ends_at"`
+	Reason      string                `gorm:"type:text" json:"reason,omitempty"`
+	Status      RescheduleBatchStatus `gorm:"type:reschedule_batch_status;not null;default:DRAFT" json:"status"`
+	CreatedBy   *uuid.UUID            `gorm:"type:uuid" json:"created_by,omitempty"`
+	ConfirmedAt *time.Time            `json:"confirmed_at,omitempty"`
+	CreatedAt   time.Time             `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time             `gorm:"autoUpdateTime" json:"updated_at"`
+	Proposals   []RescheduleProposal  `gorm:"foreignKey:BatchID" json:"proposals,omitempty"`
+}
+
+// RescheduleProposal is the new slot offered for one appointment of a batch.
+// The proposed fields are empty when no free slot was found.
+type RescheduleProposal struct {
+	ID                uuid.UUID                `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	BatchID           uuid.UUID                `gorm:"type:uuid;not null" json:"batch_id"`
+	AppointmentID     uuid.UUID                `gorm:"type:uuid;not null" json:"appointment_id"`
+	PatientID         uuid.UUID                `gorm:"type:uuid;not null" json:"patient_id"`
+	OriginalDoctorID  uuid.UUID                `gorm:"type:uuid;not null" json:"original_doctor_id"`
+	OriginalDate      time.Time                `gorm:"type:date;not null" json:"original_date"`
+	OriginalStartTime string                   `gorm:"not null" json:"original_start_time"`
+	OriginalEndTime   string                   `gorm:"not null" json:"original_end_time"`
+	DoctorID          *uuid.UUID               `gorm:"type:uuid" json:"doctor_id,omitempty"`
+	AppointmentDate   *time.Time               `gorm:"type:date" json:"appointment_date,omitempty"`
+	StartTime         *string                  `json:"start_time,omitempty"`
+	EndTime           *string                  `json:"end_time,omitempty"`
+	StartsAt          *time.Time               `json:"starts_at,omitempty"`
+	Status            RescheduleProposalStatus `gorm:"type:reschedule_proposal_status;not null;default:PROPOSED" json:"status"`
+	Reason            string                   `gorm:"type:text" json:"reason,omitempty"`
+	RespondedAt       *time.Time               `json:"responded_at,omitempty"`
+	CreatedAt         time.Time                `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time                `gorm:"autoUpdateTime" json:"updated_at"`
+}
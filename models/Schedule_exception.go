@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ScheduleException overrides a doctor's weekly template for a date range.
+// TIME_OFF without times blocks whole days; EXTRA_HOURS adds a one-off block.
+type ScheduleException struct {
+	ID        uuid.UUID             `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	DoctorID  uuid.UUID             `gorm:"type:uuid;not null" json:"doctor_id"`
+	Type      ScheduleExceptionType `gorm:"type:schedule_exception_type;not null" json:"type"`
+	StartDate time.Time             `gorm:"type:date;not null" json:"start_date"`
+	EndDate   time.Time             `gorm:"type:date;not null" json:"end_date"`
+	StartTime *string               `gorm:"type:time" json:"start_time,omitempty"`
+	EndTime   *string               `gorm:"type:time" json:"end_time,omitempty"`
+	Reason    string                `gorm:"type:text" json:"reason"`
+	CreatedBy *uuid.UUID            `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt time.Time             `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time             `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// ClinicHoliday closes the clinic for every doctor on a date.
+type ClinicHoliday struct {
+	ID        uuid.UUID `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	Date      time.Time `gorm:"type:date;not null;unique" json:"date"`
+	Name      string    `gorm:"not null" json:"name"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
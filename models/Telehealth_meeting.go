@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TelehealthMeeting is the video meeting of an Online appointment. It is
+// created on confirmation and replaced whenever the appointment moves.
+type TelehealthMeeting struct {
+	ID            uuid.UUID `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	AppointmentID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"appointment_id"`
+	Provider      string    `gorm:"not null" json:"provider"`
+	ExternalID    string    `gorm:"not null" json:"-"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
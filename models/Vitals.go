@@ -14,6 +14,7 @@ type Vital struct {
 	Status          string     `gorm:"not null"`
 	RecordedAt      time.Time  `gorm:"not null"`
 	MedicalRecordID *uuid.UUID `gorm:"type:uuid"`
+	AppointmentID   *uuid.UUID `gorm:"type:uuid"`
 	CreatedAt       time.Time  `gorm:"autoCreateTime"`
 	UpdatedAt       time.Time  `gorm:"autoUpdateTime"`
 	DeletedAt       *time.Time `gorm:"index"`
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WaitlistEntry is a patient's request to be offered a freed slot with a
+// doctor inside a date range and daily time window.
+type WaitlistEntry struct {
+	ID              uuid.UUID      `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()" json:"id"`
+	PatientID       uuid.UUID      `gorm:"type:uuid;not null" json:"patient_id"`
+	DoctorID        uuid.UUID      `gorm:"type:uuid;not null" json:"doctor_id"`
+	AppointmentType ApptType       `gorm:"type:appt_type;not null" json:"appointment_type"`
+	Mode            string         `gorm:"not null" json:"mode"`
+	EarliestDate    time.Time      `gorm:"type:date;not null" json:"earliest_date"`
+	LatestDate      time.Time      `gorm:"type:date;not null" json:"latest_date"`
+	WindowStart     string         `gorm:"not null" json:"window_start"`
+	WindowEnd       string         `gorm:"not null" json:"window_end"`
+	Status          WaitlistStatus `gorm:"type:waitlist_status;not null;default:WAITING" json:"status"`
+	OfferToken      *string        `json:"-"`
+	OfferDate       *time.Time     `gorm:"type:date" json:"offer_date,omitempty"`
+	OfferStartTime  *string        `json:"offer_start_time,omitempty"`
+	OfferEndTime    *string        `json:"offer_end_time,omitempty"`
+	OfferExpiresAt  *time.Time     `json:"offer_expires_at,omitempty"`
+	AppointmentID   *uuid.UUID     `gorm:"type:uuid" json:"appointment_id,omitempty"`
+	CreatedAt       time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -13,5 +13,25 @@ func AllModels() []interface{} {
 		&Vital{},
 		&Message{},
 		&Prescription{},
+		&Doctor_working_hours{},
+		&DoctorSlotDuration{},
+		&ScheduleException{},
+		&ClinicHoliday{},
+		&AppointmentSeries{},
+		&WaitlistEntry{},
+		&AppointmentStatusHistory{},
+		&BookingRequest{},
+		&CalendarFeedToken{},
+		&ClinicSettings{},
+		&AppointmentReminder{},
+		&PatientAttendance{},
+		&DoctorSchedulingRules{},
+		&DoctorDailyCap{},
+		&Location{},
+		&Room{},
+		&TelehealthMeeting{},
+		&Receptionist{},
+		&RescheduleBatch{},
+		&RescheduleProposal{},
 	}
 }
@@ -2,7 +2,9 @@ package queue
 
 import (
 	"encoding/json"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
 )
 
@@ -16,6 +18,35 @@ type OTPPayload struct {
 	OTP   string `json:"otp"`
 }
 
+// FreedSlotPayload describes a slot released by a cancellation, used to
+// offer it to the doctor's waitlist.
+type FreedSlotPayload struct {
+	DoctorID        uuid.UUID `json:"doctor_id"`
+	AppointmentDate time.Time `json:"appointment_date"`
+	StartTime       string    `json:"start_time"`
+	EndTime         string    `json:"end_time"`
+	// SkipEntryIDs lists waitlist entries that already let an offer for this
+	// slot expire.
+	SkipEntryIDs []uuid.UUID `json:"skip_entry_ids,omitempty"`
+}
+
+// BookingRequestPayload points the worker at a persisted booking request.
+type BookingRequestPayload struct {
+	RequestID uuid.UUID `json:"request_id"`
+}
+
+// ReminderPayload points the worker at a scheduled appointment reminder.
+type ReminderPayload struct {
+	ReminderID uuid.UUID `json:"reminder_id"`
+}
+
+type WaitlistOfferPayload struct {
+	EntryID uuid.UUID `json:"entry_id"`
+	Token   string    `json:"token"`
+	// SkipEntryIDs carries earlier expired entries along the offer chain.
+	SkipEntryIDs []uuid.UUID `json:"skip_entry_ids,omitempty"`
+}
+
 func NewTask(jobType JobType, payload any) (*asynq.Task, error) {
 	b, err := json.Marshal(payload)
 	if err != nil {
@@ -52,3 +83,11 @@ func ResetEmailTask(email, subject, body string) (*asynq.Task, error) {
 	}
 	return asynq.NewTask(string(JobTypeResetPassword), b), nil
 }
+
+func NewEmailTask(to, subject, body string, attachments ...EmailAttachment) (*asynq.Task, error) {
+	p, err := json.Marshal(EmailPayload{To: to, Subject: subject, Body: body, Attachments: attachments})
+	if err != nil {
+		return nil, err
+	}
+	return asynq.NewTask(string(JobTypeNotificationEmail), p), nil
+}
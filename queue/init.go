@@ -7,12 +7,19 @@ import (
 
 var Client *asynq.Client
 
+// Inspector is used to delete delayed tasks that are no longer wanted.
+var Inspector *asynq.Inspector
+
 func Init() *asynq.Client {
 	Client = asynq.NewClient(config.QueueRedisOpt)
+	Inspector = asynq.NewInspector(config.QueueRedisOpt)
 	return Client
 }
 
 func Close() error {
+	if Inspector != nil {
+		Inspector.Close()
+	}
 	if Client != nil {
 		return Client.Close()
 	}
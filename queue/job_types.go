@@ -7,6 +7,13 @@ const (
 	JobTypeWelcomeEmail      JobType = "email:welcome"
 	JobOTPEmail              JobType = "email:otp"
 	JobTypeResetPassword     JobType = "email:reset_password"
+	JobTypeNotificationEmail JobType = "email:notification"
+
+	JobTypeWaitlistBackfill    JobType = "waitlist:backfill"
+	JobTypeWaitlistOfferExpire JobType = "waitlist:offer_expire"
+
+	JobTypeAppointmentReminder JobType = "appointment:reminder"
+	JobTypeNoShowSweep         JobType = "appointment:no_show_sweep"
 )
 
 type JobPayload struct {
@@ -15,7 +22,14 @@ type JobPayload struct {
 }
 
 type EmailPayload struct {
-	To      string `json:"to"`
-	Subject string `json:"subject"`
-	Body    string `json:"body"`
+	To          string            `json:"to"`
+	Subject     string            `json:"subject"`
+	Body        string            `json:"body"`
+	Attachments []EmailAttachment `json:"attachments,omitempty"`
+}
+
+type EmailAttachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Content     []byte `json:"content"`
 }
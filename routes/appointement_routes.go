@@ -12,20 +12,65 @@ import (
 
 func RegisterAppointmentRoutes(rg *gin.RouterGroup, appointmentCache *cache.Cache, queue *asynq.Client) {
 
-	rg.POST("", utils.RoleChecker(models.RolePatient), func(c *gin.Context) { handlers.HandleUserCreateAppointment(c, queue) })
+	rg.POST("", utils.RoleChecker(models.RolePatient, models.RoleReceptionist, models.RoleAdmin), func(c *gin.Context) { handlers.HandleUserCreateAppointment(c, queue) })
 	{
 		rg.GET("", utils.RoleChecker(models.RoleAdmin), appointments.GetAllAppointments)
-		rg.GET(":id", utils.RoleChecker(models.RoleAdmin, models.RolePatient, models.RoleDoctor), appointments.GetAppointmentByID)
-		rg.PUT(":id", utils.RoleChecker(models.RoleAdmin, models.RolePatient, models.RoleDoctor), func(c *gin.Context) {
+		rg.POST("series", utils.RoleChecker(models.RoleAdmin, models.RolePatient), func(c *gin.Context) {
+			appointments.CreateAppointmentSeries(c, appointmentCache)
+		})
+		rg.GET("series/:id", utils.RoleChecker(models.RoleAdmin, models.RolePatient, models.RoleDoctor), appointments.GetAppointmentSeries)
+		rg.PUT("series/:id", utils.RoleChecker(models.RoleAdmin, models.RolePatient, models.RoleDoctor), func(c *gin.Context) {
+			appointments.UpdateAppointmentSeries(c, appointmentCache)
+		})
+		rg.PUT("series/cancel/:id", utils.RoleChecker(models.RoleAdmin, models.RolePatient, models.RoleDoctor), func(c *gin.Context) {
+			appointments.CancelAppointmentSeries(c, appointmentCache)
+		})
+		rg.GET("requests/pending", utils.RoleChecker(models.RoleAdmin, models.RoleReceptionist), appointments.GetPendingBookingRequests)
+		rg.PUT("requests/review/:id", utils.RoleChecker(models.RoleAdmin, models.RoleReceptionist), appointments.ReviewBookingRequest)
+		rg.GET("requests/:id", utils.RoleChecker(models.RoleAdmin, models.RoleReceptionist, models.RolePatient), appointments.GetBookingRequest)
+		rg.GET("attendance/:id", utils.RoleChecker(models.RoleAdmin, models.RoleReceptionist, models.RoleDoctor, models.RolePatient), appointments.GetPatientAttendance)
+		rg.GET("search", utils.RoleChecker(models.RoleAdmin, models.RoleReceptionist, models.RoleDoctor), appointments.SearchAppointments)
+		rg.GET("day", utils.RoleChecker(models.RoleAdmin, models.RoleReceptionist), appointments.GetDaySchedule)
+		rg.GET("calendar", utils.RoleChecker(models.RoleDoctor), appointments.GetDoctorCalendar)
+		rg.GET("slots", utils.RoleChecker(models.RoleAdmin, models.RoleReceptionist, models.RolePatient, models.RoleDoctor), appointments.GetAvailableSlots)
+		rg.GET(":id", utils.RoleChecker(models.RoleAdmin, models.RoleReceptionist, models.RolePatient, models.RoleDoctor), appointments.GetAppointmentByID)
+		rg.PUT(":id", utils.RoleChecker(models.RoleAdmin, models.RoleReceptionist, models.RolePatient, models.RoleDoctor), func(c *gin.Context) {
 			appointments.UpdateAppointment(c, appointmentCache)
 		})
-		rg.PUT("status/:id", utils.RoleChecker(models.RoleAdmin), appointments.ChangeAppointmentStatus)
-		rg.PUT("reschedule/:id", utils.RoleChecker(models.RoleAdmin, models.RoleDoctor, models.RolePatient), func(c *gin.Context) {
+		rg.PUT("status/:id", utils.RoleChecker(models.RoleAdmin, models.RoleReceptionist, models.RoleDoctor), func(c *gin.Context) {
+			appointments.ChangeAppointmentStatus(c, appointmentCache)
+		})
+		rg.GET("join/:id", utils.RoleChecker(models.RolePatient, models.RoleDoctor), appointments.GetJoinLink)
+		rg.POST("check-in", utils.RoleChecker(models.RolePatient), func(c *gin.Context) {
+			appointments.SelfCheckIn(c, appointmentCache)
+		})
+		rg.PUT("check-in/:id", utils.RoleChecker(models.RoleAdmin, models.RoleReceptionist, models.RoleDoctor), func(c *gin.Context) {
+			appointments.CheckInAppointment(c, appointmentCache)
+		})
+		rg.GET("queue/doctor/:id", utils.RoleChecker(models.RoleAdmin, models.RoleReceptionist, models.RoleDoctor), appointments.GetDoctorQueue)
+		rg.POST("queue/next", utils.RoleChecker(models.RoleAdmin, models.RoleDoctor), func(c *gin.Context) {
+			appointments.CallNextPatient(c, appointmentCache)
+		})
+		rg.PUT("complete/:id", utils.RoleChecker(models.RoleAdmin, models.RoleDoctor), func(c *gin.Context) {
+			appointments.CompleteVisit(c, appointmentCache)
+		})
+		rg.GET("history/:id", utils.RoleChecker(models.RoleAdmin, models.RoleReceptionist, models.RolePatient, models.RoleDoctor), appointments.GetAppointmentStatusHistory)
+		rg.PUT("reschedule/:id", utils.RoleChecker(models.RoleAdmin, models.RoleReceptionist, models.RoleDoctor, models.RolePatient), func(c *gin.Context) {
 			appointments.RescheduleAppointment(c, appointmentCache)
 		})
-		rg.PUT("cancel/:id", utils.RoleChecker(models.RoleAdmin, models.RoleDoctor, models.RolePatient), appointments.CancelAppointment)
+		rg.PUT("cancel/:id", utils.RoleChecker(models.RoleAdmin, models.RoleReceptionist, models.RoleDoctor, models.RolePatient), func(c *gin.Context) {
+			appointments.CancelAppointment(c, appointmentCache)
+		})
+		rg.POST("bulk-reschedule", utils.RoleChecker(models.RoleAdmin), appointments.ProposeBulkReschedule)
+		rg.GET("bulk-reschedule/:id", utils.RoleChecker(models.RoleAdmin), appointments.GetRescheduleBatch)
+		rg.PUT("bulk-reschedule/:id/confirm", utils.RoleChecker(models.RoleAdmin), func(c *gin.Context) {
+			appointments.ConfirmBulkReschedule(c, appointmentCache)
+		})
+		rg.PUT("reschedule-proposals/:id/respond", utils.RoleChecker(models.RoleAdmin, models.RoleReceptionist, models.RolePatient), func(c *gin.Context) {
+			appointments.RespondToReschedule(c, appointmentCache)
+		})
 		rg.GET("doctor/:id", utils.RoleChecker(models.RoleAdmin), appointments.GetAppointmentByDoctorID)
-		rg.GET("patient/:id", utils.RoleChecker(models.RoleAdmin, models.RoleDoctor, models.RolePatient), appointments.GetAppointmentByPatientID)
+		rg.GET("patient/:id", utils.RoleChecker(models.RoleAdmin, models.RoleReceptionist, models.RoleDoctor, models.RolePatient), appointments.GetAppointmentByPatientID)
 		rg.DELETE(":id", utils.RoleChecker(models.RoleAdmin), func(c *gin.Context) {
 			appointments.DeleteAppointment(c, appointmentCache)
 		})
@@ -10,6 +10,11 @@ func RegisterAuthRoutes(rg *gin.RouterGroup) {
 	{
 		rg.POST("/signup", auth.SignUp)
 		rg.POST("/login", auth.Login)
+		rg.POST("/verify-email", auth.VerifyEmail)
+		rg.POST("/resend-verification", auth.ResendVerification)
+		rg.POST("/forgot-password", auth.ForgotPassword)
+		rg.POST("/resend-otp", auth.ResendOTP)
+		rg.POST("/reset-password", auth.ResetPassword)
 		// rg.POST("/verify", auth.VerifyToken)
 		rg.POST("/refresh", auth.RefreshAccessToken)
 		rg.POST("/logout", auth.Logout)
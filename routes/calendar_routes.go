@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/AltSumpreme/Medistream.git/controllers/calendar"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterCalendarRoutes(rg *gin.RouterGroup) {
+	rg.POST("/feed-token", calendar.CreateFeedToken)
+	rg.DELETE("/feed-token", calendar.RevokeFeedToken)
+}
+
+// RegisterCalendarFeedRoutes mounts the token-protected feed outside the JWT
+// group so calendar apps can subscribe to it.
+func RegisterCalendarFeedRoutes(rg *gin.RouterGroup) {
+	rg.GET("/feed/:token", calendar.GetCalendarFeed)
+}
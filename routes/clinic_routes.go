@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"github.com/AltSumpreme/Medistream.git/controllers/clinic"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/services/cache"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterClinicRoutes(rg *gin.RouterGroup, scheduleCache *cache.Cache) {
+	rg.GET("/settings", utils.RoleChecker(models.RoleAdmin), clinic.GetClinicSettings)
+	rg.PUT("/settings", utils.RoleChecker(models.RoleAdmin), func(c *gin.Context) {
+		clinic.UpdateClinicSettings(c, scheduleCache)
+	})
+
+	rg.GET("/locations", clinic.GetLocations)
+	rg.POST("/locations", utils.RoleChecker(models.RoleAdmin), clinic.CreateLocation)
+	rg.PUT("/locations/:id", utils.RoleChecker(models.RoleAdmin), clinic.UpdateLocation)
+	rg.DELETE("/locations/:id", utils.RoleChecker(models.RoleAdmin), clinic.DeleteLocation)
+	rg.POST("/locations/:id/rooms", utils.RoleChecker(models.RoleAdmin), clinic.CreateRoom)
+	rg.PUT("/rooms/:id", utils.RoleChecker(models.RoleAdmin), clinic.UpdateRoom)
+	rg.DELETE("/rooms/:id", utils.RoleChecker(models.RoleAdmin), clinic.DeleteRoom)
+}
@@ -13,6 +13,14 @@ func RegisterRoutes(r *gin.Engine, appointmentCache *cache.Cache, medicalrecords
 	auth.Use(middleware.StrictRateLimiterMiddleware())
 	RegisterAuthRoutes(auth)
 
+	feeds := r.Group("/calendar")
+	feeds.Use(middleware.RateLimiterMiddleware())
+	RegisterCalendarFeedRoutes(feeds)
+
+	meetings := r.Group("/telehealth")
+	meetings.Use(middleware.RateLimiterMiddleware())
+	RegisterTelehealthRoutes(meetings)
+
 	protected := r.Group("/")
 	protected.Use(middleware.RateLimiterMiddleware())
 	protected.Use(middleware.AuthMiddleware())
@@ -23,4 +31,8 @@ func RegisterRoutes(r *gin.Engine, appointmentCache *cache.Cache, medicalrecords
 	RegisterReportRoute(protected.Group("/reports"), reportsCache)
 	RegisterVitalsRoutes(protected.Group("/vitals"), vitalsCache)
 	RegisterPrescriptionRoutes(protected.Group("/prescriptions"), prescriptionsCache)
+	RegisterScheduleRoutes(protected.Group("/schedule"), appointmentCache)
+	RegisterWaitlistRoutes(protected.Group("/waitlist"), appointmentCache)
+	RegisterCalendarRoutes(protected.Group("/calendar"))
+	RegisterClinicRoutes(protected.Group("/clinic"), appointmentCache)
 }
@@ -0,0 +1,52 @@
+package routes
+
+import (
+	"github.com/AltSumpreme/Medistream.git/controllers/schedule"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/services/cache"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterScheduleRoutes(rg *gin.RouterGroup, scheduleCache *cache.Cache) {
+	rg.POST("/working-hours", utils.RoleChecker(models.RoleAdmin, models.RoleDoctor), func(c *gin.Context) {
+		schedule.CreateWorkingHours(c, scheduleCache)
+	})
+	rg.GET("/working-hours/doctor/:id", utils.RoleChecker(models.RoleAdmin, models.RoleDoctor, models.RolePatient), schedule.GetWorkingHoursByDoctorID)
+	rg.PUT("/working-hours/:id", utils.RoleChecker(models.RoleAdmin, models.RoleDoctor), func(c *gin.Context) {
+		schedule.UpdateWorkingHours(c, scheduleCache)
+	})
+	rg.PUT("/working-hours/deactivate/:id", utils.RoleChecker(models.RoleAdmin, models.RoleDoctor), func(c *gin.Context) {
+		schedule.DeactivateWorkingHours(c, scheduleCache)
+	})
+	rg.PUT("/slot-durations", utils.RoleChecker(models.RoleAdmin, models.RoleDoctor), func(c *gin.Context) {
+		schedule.SetSlotDuration(c, scheduleCache)
+	})
+	rg.PUT("/time-zone", utils.RoleChecker(models.RoleAdmin, models.RoleDoctor), func(c *gin.Context) {
+		schedule.SetDoctorTimeZone(c, scheduleCache)
+	})
+	rg.GET("/slot-durations/doctor/:id", utils.RoleChecker(models.RoleAdmin, models.RoleDoctor, models.RolePatient), schedule.GetSlotDurationsByDoctorID)
+	rg.PUT("/rules", utils.RoleChecker(models.RoleAdmin, models.RoleDoctor), func(c *gin.Context) {
+		schedule.SetSchedulingRules(c, scheduleCache)
+	})
+	rg.PUT("/daily-caps", utils.RoleChecker(models.RoleAdmin, models.RoleDoctor), func(c *gin.Context) {
+		schedule.SetDailyCap(c, scheduleCache)
+	})
+	rg.GET("/rules/doctor/:id", utils.RoleChecker(models.RoleAdmin, models.RoleDoctor, models.RolePatient), schedule.GetSchedulingRulesByDoctorID)
+
+	rg.POST("/exceptions", utils.RoleChecker(models.RoleAdmin, models.RoleDoctor), func(c *gin.Context) {
+		schedule.CreateScheduleException(c, scheduleCache)
+	})
+	rg.GET("/exceptions/doctor/:id", utils.RoleChecker(models.RoleAdmin, models.RoleDoctor, models.RolePatient), schedule.GetScheduleExceptionsByDoctorID)
+	rg.DELETE("/exceptions/:id", utils.RoleChecker(models.RoleAdmin, models.RoleDoctor), func(c *gin.Context) {
+		schedule.DeleteScheduleException(c, scheduleCache)
+	})
+
+	rg.POST("/holidays", utils.RoleChecker(models.RoleAdmin), func(c *gin.Context) {
+		schedule.CreateClinicHoliday(c, scheduleCache)
+	})
+	rg.GET("/holidays", utils.RoleChecker(models.RoleAdmin, models.RoleDoctor, models.RolePatient), schedule.GetClinicHolidays)
+	rg.DELETE("/holidays/:id", utils.RoleChecker(models.RoleAdmin), func(c *gin.Context) {
+		schedule.DeleteClinicHoliday(c, scheduleCache)
+	})
+}
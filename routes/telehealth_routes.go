@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"github.com/AltSumpreme/Medistream.git/controllers/telehealth"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterTelehealthRoutes mounts the landing page of signed join links. The
+// signature is the credential, so it sits outside the JWT group.
+func RegisterTelehealthRoutes(rg *gin.RouterGroup) {
+	rg.GET("/join/:room", telehealth.JoinLocalMeeting)
+}
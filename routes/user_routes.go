@@ -9,9 +9,12 @@ import (
 
 func RegisterUserRoutes(rg *gin.RouterGroup) {
 
+	rg.GET("/patients", utils.RoleChecker(models.RoleAdmin, models.RoleReceptionist), user.SearchPatients)
 	rg.GET("/doctors", utils.RoleChecker(models.RoleAdmin, models.RolePatient), user.GetDoctorsBySpecialization)
 	rg.GET("/:id", utils.RoleChecker(models.RoleAdmin, models.RoleDoctor, models.RolePatient), user.GetUserProfile)
 	rg.PUT("/:id", utils.RoleChecker(models.RoleAdmin, models.RoleDoctor, models.RolePatient), user.UpdateUserProfile)
 	rg.PUT("/promote/:id", utils.RoleChecker(models.RoleAdmin), user.PromotePatienttoDoctor)
+	rg.PUT("/promote/receptionist/:id", utils.RoleChecker(models.RoleAdmin), user.PromotePatientToReceptionist)
+	rg.PUT("/verify-email/:id", utils.RoleChecker(models.RoleAdmin), user.VerifyUserEmail)
 
 }
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/AltSumpreme/Medistream.git/controllers/waitlist"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/services/cache"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterWaitlistRoutes(rg *gin.RouterGroup, appointmentCache *cache.Cache) {
+	rg.POST("", utils.RoleChecker(models.RolePatient), waitlist.JoinWaitlist)
+	rg.GET("", utils.RoleChecker(models.RolePatient), waitlist.GetMyWaitlistEntries)
+	rg.GET("/doctor/:id", utils.RoleChecker(models.RoleAdmin, models.RoleDoctor), waitlist.GetWaitlistByDoctorID)
+	rg.DELETE("/:id", utils.RoleChecker(models.RolePatient), waitlist.LeaveWaitlist)
+	rg.POST("/claim/:id", utils.RoleChecker(models.RolePatient), func(c *gin.Context) {
+		waitlist.ClaimWaitlistOffer(c, appointmentCache)
+	})
+}
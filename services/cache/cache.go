@@ -3,9 +3,7 @@ package cache
 import (
 	"context"
 	"fmt"
-	"time"
 
-	"github.com/AltSumpreme/Medistream.git/config"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -26,7 +24,7 @@ func (c *Cache) AppointmentInvalidate(appointmentID, doctorID, patientID, date s
 		fmt.Sprintf("cache:appointment:%s", appointmentID),
 		fmt.Sprintf("cache:appointments:doctor:%s*", doctorID),
 		fmt.Sprintf("cache:appointments:patient:%s*", patientID),
-		fmt.Sprintf("cache:doctorSchedule:%s:%s", doctorID, date),
+		fmt.Sprintf("cache:doctorSchedule:%s:%s*", doctorID, date),
 	}
 
 	for _, key := range keys {
@@ -37,6 +35,24 @@ func (c *Cache) AppointmentInvalidate(appointmentID, doctorID, patientID, date s
 	}
 }
 
+// DoctorScheduleInvalidate drops every cached slot list of a doctor, used when
+// the weekly template changes and all dates are affected.
+func (c *Cache) DoctorScheduleInvalidate(doctorID string) {
+	iter := c.Rdb.Scan(c.Ctx, 0, fmt.Sprintf("cache:doctorSchedule:%s:*", doctorID), 0).Iterator()
+	for iter.Next(c.Ctx) {
+		c.Rdb.Del(c.Ctx, iter.Val())
+	}
+}
+
+// AllSchedulesInvalidate drops the cached slot lists of every doctor, used for
+// clinic-wide changes such as holidays.
+func (c *Cache) AllSchedulesInvalidate() {
+	iter := c.Rdb.Scan(c.Ctx, 0, "cache:doctorSchedule:*", 0).Iterator()
+	for iter.Next(c.Ctx) {
+		c.Rdb.Del(c.Ctx, iter.Val())
+	}
+}
+
 func (c *Cache) MedicalRecordInvalidate(medicalRecordID, patientID string) {
 	keys := []string{
 		fmt.Sprintf("cache:medicalRecord:%s", medicalRecordID),
@@ -89,24 +105,3 @@ func (c *Cache) VitalsInvalidate(patientID string) {
 		}
 	}
 }
-
-func SaveOTP(email, otp string, ttl time.Duration) error {
-	return config.Rdb.Set(config.Ctx, fmt.Sprintf("otp:%s", email), otp, ttl).Err()
-}
-
-func VerifyOTP(email, otp string) error {
-	key := fmt.Sprintf("otp:%s", email)
-
-	storedOTP, err := config.Rdb.Get(config.Ctx, key).Result()
-	if err != nil {
-		return fmt.Errorf("OTP not found or expired")
-	}
-
-	if storedOTP != otp {
-		return fmt.Errorf("invalid otp")
-	}
-
-	config.Rdb.Del(config.Ctx, key)
-
-	return nil
-}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/redis/go-redis/v9"
+)
+
+// OTP purposes keep the codes of different flows apart, so one flow cannot
+// consume another's code.
+const (
+	OTPPasswordReset     = "reset"
+	OTPEmailVerification = "verify"
+)
+
+const (
+	// maxOTPAttempts wrong codes lock an email out of a flow for otpLockout.
+	// Issuing a new code does not reset the count.
+	maxOTPAttempts = 5
+	otpLockout     = 15 * time.Minute
+)
+
+var (
+	ErrOTPInvalid = errors.New("invalid or expired code")
+	ErrOTPLocked  = errors.New("too many attempts, try again later")
+)
+
+func otpKey(purpose, email string) string {
+	return fmt.Sprintf("otp:%s:%s", purpose, email)
+}
+
+// SaveOTP stores the digest of a one-time code, replacing any earlier code
+// for the same purpose and email. The plain code is never stored.
+func SaveOTP(purpose, email, digest string, ttl time.Duration) error {
+	return config.Rdb.Set(config.Ctx, otpKey(purpose, email), digest, ttl).Err()
+}
+
+// VerifyOTP consumes the code whose digest matches. Each code works once, and
+// every attempt counts towards the email's lockout, whether or not a code was
+// issued, so replies do not reveal which emails have one. The count is taken
+// before the comparison so parallel guesses cannot get past the limit.
+//
+// On success it returns restore, which puts the code back for its remaining
+// lifetime; callers use it when the work the code authorises fails.
+func VerifyOTP(purpose, email, digest string) (restore func(), err error) {
+	key := otpKey(purpose, email)
+	attemptsKey := key + ":attempts"
+
+	attempts, err := config.Rdb.Incr(config.Ctx, attemptsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	if attempts == 1 {
+		config.Rdb.Expire(config.Ctx, attemptsKey, otpLockout)
+	}
+	if attempts > maxOTPAttempts {
+		return nil, ErrOTPLocked
+	}
+
+	stored, err := config.Rdb.Get(config.Ctx, key).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	if err == nil && subtle.ConstantTimeCompare([]byte(stored), []byte(digest)) == 1 {
+		ttl, err := config.Rdb.PTTL(config.Ctx, key).Result()
+		if err != nil {
+			return nil, err
+		}
+		// Deleting decides the winner when the same code is sent twice at once.
+		deleted, err := config.Rdb.Del(config.Ctx, key).Result()
+		if err != nil {
+			return nil, err
+		}
+		if deleted == 1 {
+			config.Rdb.Del(config.Ctx, attemptsKey)
+			return func() {
+				if ttl > 0 {
+					config.Rdb.SetNX(config.Ctx, key, stored, ttl)
+				}
+			}, nil
+		}
+	}
+
+	if attempts >= maxOTPAttempts {
+		config.Rdb.Del(config.Ctx, key)
+		return nil, ErrOTPLocked
+	}
+	return nil, ErrOTPInvalid
+}
+
+// AllowOTPSend reports whether a new code may be sent for purpose and email,
+// allowing one send per cooldown.
+func AllowOTPSend(purpose, email string, cooldown time.Duration) (bool, error) {
+	return config.Rdb.SetNX(config.Ctx, otpKey(purpose, email)+":cooldown", 1, cooldown).Result()
+}
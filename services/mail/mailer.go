@@ -1,6 +1,8 @@
 package mail
 
 import (
+	"io"
+
 	"github.com/hibiken/asynq"
 	"gopkg.in/gomail.v2"
 )
@@ -17,3 +19,30 @@ func SendEmail(to, subject, body string) error {
 	d := NewDialer()
 	return d.DialAndSend(m)
 }
+
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+func SendEmailWithAttachments(to, subject, body string, attachments []Attachment) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", Mailer.From)
+	m.SetHeader("To", to)
+	m.SetHeader("Subject", subject)
+	m.SetBody("text/html", body)
+	for _, a := range attachments {
+		content := a.Content
+		m.Attach(a.Filename,
+			gomail.SetCopyFunc(func(w io.Writer) error {
+				_, err := w.Write(content)
+				return err
+			}),
+			gomail.SetHeader(map[string][]string{"Content-Type": {a.ContentType}}),
+		)
+	}
+
+	d := NewDialer()
+	return d.DialAndSend(m)
+}
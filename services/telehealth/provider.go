@@ -0,0 +1,57 @@
+package telehealth
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+)
+
+// Meeting is a video room created for one appointment.
+type Meeting struct {
+	Provider   string
+	ExternalID string
+}
+
+// Participant is who a join link is issued to.
+type Participant struct {
+	Role string
+	Name string
+}
+
+// Provider creates video meetings and issues join links for them. The local
+// provider is built in; hosted services implement the same interface.
+type Provider interface {
+	Name() string
+	CreateMeeting(ctx context.Context, appointmentID string, start, end time.Time) (Meeting, error)
+	// JoinURL returns a link for the participant that stops working at
+	// expiresAt.
+	JoinURL(meeting Meeting, participant Participant, expiresAt time.Time) (string, error)
+	DeleteMeeting(ctx context.Context, meeting Meeting) error
+}
+
+// Default is the provider used for new meetings. It is nil until Init runs,
+// in which case appointments are booked without meetings.
+var Default Provider
+
+// Init selects the provider named by TELEHEALTH_PROVIDER, "local" by default.
+func Init() error {
+	switch name := os.Getenv("TELEHEALTH_PROVIDER"); name {
+	case "", LocalProviderName:
+		secret := os.Getenv("TELEHEALTH_SIGNING_SECRET")
+		if secret == "" {
+			secret = os.Getenv("JWT_SECRET")
+		}
+		if secret == "" {
+			return errors.New("TELEHEALTH_SIGNING_SECRET is not set")
+		}
+		baseURL := os.Getenv("TELEHEALTH_BASE_URL")
+		if baseURL == "" {
+			baseURL = "/telehealth/join"
+		}
+		Default = NewLocalProvider(baseURL, []byte(secret))
+		return nil
+	default:
+		return errors.New("unknown telehealth provider " + name)
+	}
+}
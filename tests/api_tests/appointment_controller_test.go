@@ -1,29 +1,39 @@
 package apitests
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/controllers/appointments"
 	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/queue"
+	"github.com/AltSumpreme/Medistream.git/routes"
+	"github.com/AltSumpreme/Medistream.git/services/cache"
+	"github.com/AltSumpreme/Medistream.git/services/telehealth"
 	apiclient "github.com/AltSumpreme/Medistream.git/tests/api_client"
 	"github.com/AltSumpreme/Medistream.git/tests/factories"
 	"github.com/AltSumpreme/Medistream.git/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
 func setupApptRouterWithClaims(claims *utils.JWTClaims) *gin.Engine {
 	r := gin.Default()
 	//r.Use(middleware.RequestTimer())
-	// appointmentCache := cache.NewCache(config.Rdb, config.Ctx)
+	appointmentCache := cache.NewCache(config.Rdb, config.Ctx)
 	r.Use(func(c *gin.Context) {
 		c.Set("jwtPayload", claims)
 		c.Next()
 	})
-	// rg := r.Group("/appointments")
-	// routes.RegisterAppointmentRoutes(rg, appointmentCache)
+	rg := r.Group("/appointments")
+	routes.RegisterAppointmentRoutes(rg, appointmentCache, queue.Client)
 	return r
 }
 
@@ -46,8 +56,28 @@ func TestCreateAppointment(t *testing.T) {
 	headers := map[string]string{"Content-Type": "application/json"}
 
 	res := client.Post("/appointments", body, headers)
-	assert.Equal(t, http.StatusCreated, res.Code)
-	assert.Contains(t, res.Body.String(), "Appointment created successfully")
+	assert.Equal(t, http.StatusAccepted, res.Code)
+
+	var queued struct {
+		RequestID uuid.UUID `json:"requestId"`
+		Status    string    `json:"status"`
+	}
+	assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &queued))
+	assert.Equal(t, "QUEUED", queued.Status)
+
+	// Run the worker step inline, then poll for the outcome.
+	assert.NoError(t, appointments.CreateAppointment(context.Background(), queued.RequestID))
+	res = client.Get("/appointments/requests/"+queued.RequestID.String(), nil)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Contains(t, res.Body.String(), `"status":"BOOKED"`)
+
+	// The same slot again is rejected with a reason.
+	res = client.Post("/appointments", body, headers)
+	assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &queued))
+	assert.NoError(t, appointments.CreateAppointment(context.Background(), queued.RequestID))
+	res = client.Get("/appointments/requests/"+queued.RequestID.String(), nil)
+	assert.Contains(t, res.Body.String(), `"status":"REJECTED"`)
+	assert.Contains(t, res.Body.String(), "already booked")
 }
 
 func TestAppointmentCacheOnCreate(t *testing.T) {
@@ -69,7 +99,7 @@ func TestAppointmentCacheOnCreate(t *testing.T) {
 	headers := map[string]string{"Content-Type": "application/json"}
 
 	res := client.Post("/appointments", body, headers)
-	assert.Equal(t, http.StatusCreated, res.Code)
+	assert.Equal(t, http.StatusAccepted, res.Code)
 
 	// 🔑 Check Redis cache
 	key := "appointments:doctor:" + doctor.ID.String()
@@ -156,8 +186,15 @@ func TestCancelAppointment(t *testing.T) {
 	client := apiclient.NewTestClient(router)
 
 	res := client.Put("/appointments/cancel/"+appt.ID.String(), nil, nil)
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+
+	res = client.Put("/appointments/cancel/"+appt.ID.String(), map[string]interface{}{"reasonCode": "CLINIC_CLOSURE"}, nil)
 	assert.Equal(t, http.StatusOK, res.Code)
 	assert.Contains(t, res.Body.String(), "Appointment cancelled")
+
+	var history models.AppointmentStatusHistory
+	db.Where("appointment_id = ? AND to_status = ?", appt.ID, models.AppointmentStatusCancelled).First(&history)
+	assert.Equal(t, "The clinic is closed", history.Reason)
 }
 
 func TestRescheduleAppointment(t *testing.T) {
@@ -212,3 +249,1106 @@ func TestGetAppointmentByPatientID(t *testing.T) {
 	assert.Equal(t, http.StatusOK, res.Code)
 	assert.Contains(t, res.Body.String(), "appointments")
 }
+
+func TestGetAvailableSlots(t *testing.T) {
+	db := config.DB
+	userPatient, patient, _, doctor, _ := factories.CreateEntries(db)
+	date := time.Now().AddDate(0, 0, 7)
+	factories.SeedWorkingHours(db, doctor.ID, int(date.Weekday()), "09:00", "10:00")
+
+	booked := factories.CreateAppointment(db, patient.ID, doctor.ID)
+	booked.AppointmentDate = date
+	booked.StartTime = "09:20"
+	booked.EndTime = "09:35"
+	db.Save(&booked)
+
+	claims := factories.MakeJWT(userPatient.ID, models.RolePatient)
+	router := setupApptRouterWithClaims(claims)
+	client := apiclient.NewTestClient(router)
+
+	res := client.Get("/appointments/slots?doctorId="+doctor.ID.String()+"&date="+date.Format("2006-01-02")+"&type=FOLLOWUP", nil)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Contains(t, res.Body.String(), `"start_time":"09:00"`)
+	assert.NotContains(t, res.Body.String(), `"start_time":"09:15"`)
+	assert.NotContains(t, res.Body.String(), `"start_time":"09:30"`)
+	assert.Contains(t, res.Body.String(), `"start_time":"09:45"`)
+}
+
+func TestCreateAppointmentSeries(t *testing.T) {
+	db := config.DB
+	userPatient, patient, _, doctor, userAdmin := factories.CreateEntries(db)
+	start := time.Now().AddDate(0, 0, 14)
+
+	// Occupy the third weekly occurrence so the series reports one conflict.
+	taken := factories.CreateAppointment(db, patient.ID, doctor.ID)
+	taken.AppointmentDate = start.AddDate(0, 0, 14)
+	taken.StartTime = "11:00"
+	taken.EndTime = "11:30"
+	db.Save(&taken)
+
+	claims := factories.MakeJWT(userPatient.ID, models.RolePatient)
+	client := apiclient.NewTestClient(setupApptRouterWithClaims(claims))
+
+	body := map[string]interface{}{
+		"doctorId":        doctor.ID,
+		"startDate":       start.Format(time.RFC3339),
+		"startTime":       "11:00",
+		"endTime":         "11:30",
+		"appointmentType": "FOLLOWUP",
+		"mode":            "Online",
+		"rrule":           "FREQ=WEEKLY;INTERVAL=1;COUNT=4",
+	}
+	res := client.Post("/appointments/series", body, nil)
+	assert.Equal(t, http.StatusCreated, res.Code)
+	assert.Contains(t, res.Body.String(), `"index":2`)
+
+	// Admins booking for someone else must name a patient that exists.
+	clientAdmin := apiclient.NewTestClient(setupApptRouterWithClaims(factories.MakeJWT(userAdmin.ID, models.RoleAdmin)))
+	body["patientId"] = uuid.New()
+	res = clientAdmin.Post("/appointments/series", body, nil)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+
+	// The doctor has to exist too.
+	body["patientId"] = patient.ID
+	body["doctorId"] = uuid.New()
+	res = clientAdmin.Post("/appointments/series", body, nil)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.Contains(t, res.Body.String(), "doctor not found")
+}
+
+func TestAppointmentStatusTransitions(t *testing.T) {
+	db := config.DB
+	_, patient, userDoctor, doctor, _ := factories.CreateEntries(db)
+	appt := factories.CreateAppointment(db, patient.ID, doctor.ID)
+
+	claims := factories.MakeJWT(userDoctor.ID, models.RoleDoctor)
+	client := apiclient.NewTestClient(setupApptRouterWithClaims(claims))
+
+	t.Run("Reject skipping confirmation", func(t *testing.T) {
+		res := client.Put("/appointments/status/"+appt.ID.String(), map[string]interface{}{"status": "COMPLETED"}, nil)
+		assert.Equal(t, http.StatusConflict, res.Code)
+	})
+
+	t.Run("Confirm appointment", func(t *testing.T) {
+		body := map[string]interface{}{"status": "CONFIRMED", "reason": "Checked referral"}
+		res := client.Put("/appointments/status/"+appt.ID.String(), body, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+
+	t.Run("Reject no-show before start", func(t *testing.T) {
+		res := client.Put("/appointments/status/"+appt.ID.String(), map[string]interface{}{"status": "NO_SHOW"}, nil)
+		assert.Equal(t, http.StatusConflict, res.Code)
+	})
+
+	t.Run("List history", func(t *testing.T) {
+		res := client.Get("/appointments/history/"+appt.ID.String(), nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Contains(t, res.Body.String(), `"to_status":"CONFIRMED"`)
+		assert.Contains(t, res.Body.String(), "Checked referral")
+	})
+}
+
+func TestOverlappingInsertIsRejectedByDatabase(t *testing.T) {
+	db := config.DB
+	_, patient, _, doctor, _ := factories.CreateEntries(db)
+	appt := factories.CreateAppointment(db, patient.ID, doctor.ID)
+
+	// Bypass the pre-check as a concurrent booking would.
+	duplicate := appt
+	duplicate.ID = uuid.New()
+	duplicate.StartTime = "10:15"
+	duplicate.EndTime = "10:45"
+
+	err := utils.BookingError(db.Create(&duplicate).Error)
+	assert.True(t, utils.IsScheduleConflict(err))
+	assert.Contains(t, err.Error(), "already booked")
+}
+
+func TestTimeZoneAwareSlotsAndBooking(t *testing.T) {
+	db := config.DB
+	userPatient, _, _, doctor, _ := factories.CreateEntries(db)
+	db.Model(&doctor).Update("time_zone", "Asia/Tokyo")
+
+	// 2031-01-06 is a Monday; Tokyo has no daylight saving time.
+	date := "2031-01-06"
+	factories.SeedWorkingHours(db, doctor.ID, int(time.Monday), "09:00", "10:00")
+
+	client := apiclient.NewTestClient(setupApptRouterWithClaims(factories.MakeJWT(userPatient.ID, models.RolePatient)))
+
+	t.Run("Slots are rendered in the caller's zone", func(t *testing.T) {
+		res := client.Get("/appointments/slots?doctorId="+doctor.ID.String()+"&date="+date+"&type=FOLLOWUP&tz=UTC", nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Contains(t, res.Body.String(), `"start_time":"09:00","end_time":"09:15","starts_at":"2031-01-06T00:00:00Z"`)
+	})
+
+	t.Run("Reject unknown zone", func(t *testing.T) {
+		res := client.Get("/appointments/slots?doctorId="+doctor.ID.String()+"&date="+date+"&tz=Mars/Olympus", nil)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Book with absolute instants", func(t *testing.T) {
+		body := map[string]interface{}{
+			"doctorId":        doctor.ID,
+			"startsAt":        "2031-01-05T16:30:00-08:00",
+			"endsAt":          "2031-01-05T16:45:00-08:00",
+			"appointmentType": "FOLLOWUP",
+			"mode":            "Online",
+		}
+		res := client.Post("/appointments", body, nil)
+		assert.Equal(t, http.StatusAccepted, res.Code)
+
+		var queued struct {
+			RequestID uuid.UUID `json:"requestId"`
+		}
+		assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &queued))
+		res = client.Get("/appointments/requests/"+queued.RequestID.String(), nil)
+		assert.Contains(t, res.Body.String(), `"start_time":"09:30"`)
+	})
+}
+
+func TestAppointmentReminders(t *testing.T) {
+	db := config.DB
+	_, patient, userDoctor, doctor, _ := factories.CreateEntries(db)
+	appt := factories.CreateAppointment(db, patient.ID, doctor.ID)
+	client := apiclient.NewTestClient(setupApptRouterWithClaims(factories.MakeJWT(userDoctor.ID, models.RoleDoctor)))
+
+	countReminders := func(status models.ReminderStatus) int64 {
+		var n int64
+		db.Model(&models.AppointmentReminder{}).Where("appointment_id = ? AND status = ?", appt.ID, status).Count(&n)
+		return n
+	}
+
+	t.Run("Confirming schedules reminders", func(t *testing.T) {
+		res := client.Put("/appointments/status/"+appt.ID.String(), map[string]interface{}{"status": "CONFIRMED"}, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, int64(len(utils.DefaultReminderOffsets)), countReminders(models.ReminderScheduled))
+	})
+
+	t.Run("Cancelling cancels reminders", func(t *testing.T) {
+		var reminder models.AppointmentReminder
+		db.First(&reminder, "appointment_id = ?", appt.ID)
+
+		res := client.Put("/appointments/cancel/"+appt.ID.String(), map[string]interface{}{"reasonCode": "PATIENT_REQUEST"}, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, int64(0), countReminders(models.ReminderScheduled))
+
+		// A task that escaped deletion is dropped by the worker.
+		db.Model(&reminder).Update("status", models.ReminderScheduled)
+		err := appointments.SendAppointmentReminder(context.Background(), queue.ReminderPayload{ReminderID: reminder.ID})
+		assert.NoError(t, err)
+		db.First(&reminder, "id = ?", reminder.ID)
+		assert.Equal(t, models.ReminderSkipped, reminder.Status)
+	})
+}
+
+func TestNoShowPolicy(t *testing.T) {
+	db := config.DB
+	userPatient, patient, _, doctor, userAdmin := factories.CreateEntries(db)
+	appt := factories.CreateAppointment(db, patient.ID, doctor.ID)
+	db.Model(&appt).Updates(map[string]interface{}{
+		"appointment_date": time.Now().AddDate(0, 0, -3),
+		"status":           models.AppointmentStatusConfirmed,
+		"mode":             utils.ModeInPerson,
+	})
+	joinedAppt := factories.CreateAppointment(db, patient.ID, doctor.ID)
+	db.Model(&joinedAppt).Updates(map[string]interface{}{
+		"appointment_date":  time.Now().AddDate(0, 0, -3),
+		"start_time":        "11:00",
+		"end_time":          "11:30",
+		"status":            models.AppointmentStatusConfirmed,
+		"patient_joined_at": time.Now().AddDate(0, 0, -3),
+	})
+	missedAppt := factories.CreateAppointment(db, patient.ID, doctor.ID)
+	db.Model(&missedAppt).Updates(map[string]interface{}{
+		"appointment_date": time.Now().AddDate(0, 0, -3),
+		"start_time":       "12:00",
+		"end_time":         "12:30",
+		"status":           models.AppointmentStatusConfirmed,
+	})
+
+	db.Model(&models.ClinicSettings{}).Where("id = 1").Updates(map[string]interface{}{"no_show_limit": 1, "no_show_action": models.NoShowRequireApproval})
+	defer db.Model(&models.ClinicSettings{}).Where("id = 1").Update("no_show_limit", 0)
+
+	t.Run("Sweep marks missed appointments", func(t *testing.T) {
+		_, err := appointments.MarkNoShows(context.Background())
+		assert.NoError(t, err)
+
+		var reloaded models.Appointment
+		db.First(&reloaded, "id = ?", appt.ID)
+		assert.Equal(t, models.AppointmentStatusNoShow, reloaded.Status)
+
+		// Online visits count as attended once the patient has joined.
+		db.First(&reloaded, "id = ?", joinedAppt.ID)
+		assert.Equal(t, models.AppointmentStatusConfirmed, reloaded.Status)
+		db.First(&reloaded, "id = ?", missedAppt.ID)
+		assert.Equal(t, models.AppointmentStatusNoShow, reloaded.Status)
+
+		var attendance models.PatientAttendance
+		db.First(&attendance, "patient_id = ?", patient.ID)
+		assert.Equal(t, 2, attendance.NoShowCount)
+	})
+
+	var queued struct {
+		RequestID uuid.UUID `json:"requestId"`
+		Status    string    `json:"status"`
+	}
+
+	t.Run("Policy holds new bookings for approval", func(t *testing.T) {
+		client := apiclient.NewTestClient(setupApptRouterWithClaims(factories.MakeJWT(userPatient.ID, models.RolePatient)))
+		body := map[string]interface{}{
+			"doctorId":        doctor.ID,
+			"appointmentDate": time.Now().Add(220 * time.Hour).Format(time.RFC3339),
+			"startTime":       "15:00",
+			"endTime":         "15:30",
+			"appointmentType": "CONSULTATION",
+			"mode":            "Online",
+		}
+		res := client.Post("/appointments", body, nil)
+		assert.Equal(t, http.StatusAccepted, res.Code)
+		assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &queued))
+		assert.Equal(t, "AWAITING_APPROVAL", queued.Status)
+
+		// The worker leaves held requests alone.
+		assert.NoError(t, appointments.CreateAppointment(context.Background(), queued.RequestID))
+		res = client.Get("/appointments/requests/"+queued.RequestID.String(), nil)
+		assert.Contains(t, res.Body.String(), `"status":"AWAITING_APPROVAL"`)
+	})
+
+	t.Run("Staff approve held requests", func(t *testing.T) {
+		client := apiclient.NewTestClient(setupApptRouterWithClaims(factories.MakeJWT(userAdmin.ID, models.RoleAdmin)))
+		res := client.Get("/appointments/requests/pending", nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Contains(t, res.Body.String(), queued.RequestID.String())
+
+		res = client.Put("/appointments/requests/review/"+queued.RequestID.String(), map[string]interface{}{"approve": true}, nil)
+		assert.Equal(t, http.StatusAccepted, res.Code)
+
+		assert.NoError(t, appointments.CreateAppointment(context.Background(), queued.RequestID))
+		res = client.Get("/appointments/requests/"+queued.RequestID.String(), nil)
+		assert.Contains(t, res.Body.String(), `"status":"BOOKED"`)
+
+		res = client.Get("/appointments/attendance/"+patient.ID.String(), nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Contains(t, res.Body.String(), `"no_show_count":2`)
+	})
+}
+
+func TestTelehealthJoinLink(t *testing.T) {
+	db := config.DB
+	userPatient, patient, userDoctor, doctor, _ := factories.CreateEntries(db)
+	appt := factories.CreateAppointment(db, patient.ID, doctor.ID)
+	clientDoctor := apiclient.NewTestClient(setupApptRouterWithClaims(factories.MakeJWT(userDoctor.ID, models.RoleDoctor)))
+	clientPatient := apiclient.NewTestClient(setupApptRouterWithClaims(factories.MakeJWT(userPatient.ID, models.RolePatient)))
+
+	provider := telehealth.NewLocalProvider("/telehealth/join", []byte("test-secret"))
+	previous := telehealth.Default
+	telehealth.Default = provider
+	defer func() { telehealth.Default = previous }()
+
+	var meeting models.TelehealthMeeting
+	t.Run("Confirming creates a meeting", func(t *testing.T) {
+		res := clientDoctor.Put("/appointments/status/"+appt.ID.String(), map[string]interface{}{"status": "CONFIRMED"}, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.NoError(t, db.First(&meeting, "appointment_id = ?", appt.ID).Error)
+	})
+
+	t.Run("No link before the appointment", func(t *testing.T) {
+		res := clientPatient.Get("/appointments/join/"+appt.ID.String(), nil)
+		assert.Equal(t, http.StatusForbidden, res.Code)
+		assert.Contains(t, res.Body.String(), "opensAt")
+	})
+
+	t.Run("Signed link during the appointment", func(t *testing.T) {
+		start := time.Now().UTC().Truncate(time.Minute).Add(-5 * time.Minute)
+		end := start.Add(30 * time.Minute)
+		if end.Day() != start.Day() {
+			t.Skip("appointment window would span midnight")
+		}
+		db.Model(&appt).Updates(map[string]interface{}{
+			"appointment_date": utils.CalendarDate(start),
+			"start_time":       start.Format(utils.ClockLayout),
+			"end_time":         end.Format(utils.ClockLayout),
+		})
+
+		res := clientPatient.Get("/appointments/join/"+appt.ID.String(), nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		var body struct {
+			JoinURL string `json:"joinUrl"`
+		}
+		assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &body))
+		assert.True(t, strings.HasPrefix(body.JoinURL, "/telehealth/join/"+meeting.ExternalID+"?"))
+
+		link, err := url.Parse(body.JoinURL)
+		assert.NoError(t, err)
+		q := link.Query()
+		assert.NoError(t, provider.Verify(meeting.ExternalID, q.Get("role"), q.Get("expires"), q.Get("sig"), time.Now()))
+		assert.ErrorIs(t, provider.Verify(meeting.ExternalID, "doctor", q.Get("expires"), q.Get("sig"), time.Now()), telehealth.ErrInvalidSignature)
+		assert.ErrorIs(t, provider.Verify(meeting.ExternalID, q.Get("role"), q.Get("expires"), q.Get("sig"), end.Add(time.Minute)), telehealth.ErrLinkExpired)
+
+		var joined models.Appointment
+		db.First(&joined, "id = ?", appt.ID)
+		assert.NotNil(t, joined.PatientJoinedAt)
+	})
+
+	t.Run("Cancelling ends the meeting", func(t *testing.T) {
+		res := clientDoctor.Put("/appointments/cancel/"+appt.ID.String(), map[string]interface{}{"reasonCode": "DOCTOR_UNAVAILABLE"}, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		var n int64
+		db.Model(&models.TelehealthMeeting{}).Where("appointment_id = ?", appt.ID).Count(&n)
+		assert.Equal(t, int64(0), n)
+	})
+}
+
+func TestReceptionistWorkflow(t *testing.T) {
+	db := config.DB
+	userPatient, patient, _, doctor, _ := factories.CreateEntries(db)
+	appt := factories.CreateAppointment(db, patient.ID, doctor.ID)
+
+	userReceptionist := factories.SeedUser(db, models.RoleReceptionist)
+	factories.SeedReceptionist(db, userReceptionist, nil)
+	client := apiclient.NewTestClient(setupApptRouterWithClaims(factories.MakeJWT(userReceptionist.ID, models.RoleReceptionist)))
+
+	t.Run("Search patients", func(t *testing.T) {
+		users := apiclient.NewTestClient(setupUserRouterWithClaims(factories.MakeJWT(userReceptionist.ID, models.RoleReceptionist)))
+		res := users.Get("/users/patients?q="+userPatient.LastName, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Contains(t, res.Body.String(), patient.ID.String())
+	})
+
+	t.Run("Book on a patient's behalf", func(t *testing.T) {
+		body := map[string]interface{}{
+			"doctorId":        doctor.ID,
+			"appointmentDate": time.Now().Add(240 * time.Hour).Format(time.RFC3339),
+			"startTime":       "15:00",
+			"endTime":         "15:30",
+			"appointmentType": "CONSULTATION",
+			"mode":            "In-Person",
+		}
+		res := client.Post("/appointments", body, nil)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+		assert.Contains(t, res.Body.String(), "patientId is required")
+
+		body["patientId"] = patient.ID
+		res = client.Post("/appointments", body, nil)
+		assert.Equal(t, http.StatusAccepted, res.Code)
+		var queued struct {
+			RequestID uuid.UUID `json:"requestId"`
+		}
+		assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &queued))
+
+		var request models.BookingRequest
+		assert.NoError(t, db.First(&request, "id = ?", queued.RequestID).Error)
+		assert.Equal(t, patient.ID, request.PatientID)
+		assert.Equal(t, userReceptionist.ID, request.UserID)
+	})
+
+	t.Run("Confirm is attributed to the receptionist", func(t *testing.T) {
+		res := client.Put("/appointments/status/"+appt.ID.String(), map[string]interface{}{"status": "CONFIRMED"}, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+
+		var history models.AppointmentStatusHistory
+		db.Where("appointment_id = ?", appt.ID).Order("created_at desc").First(&history)
+		assert.Equal(t, userReceptionist.ID, *history.ChangedBy)
+		assert.Equal(t, models.RoleReceptionist, history.ActorRole)
+
+		var updated models.Appointment
+		db.First(&updated, "id = ?", appt.ID)
+		assert.Equal(t, userReceptionist.ID, *updated.UpdatedBy)
+	})
+
+	t.Run("Day schedule", func(t *testing.T) {
+		res := client.Get("/appointments/day?date="+appt.AppointmentDate.Format("2006-01-02"), nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Contains(t, res.Body.String(), appt.ID.String())
+		assert.Contains(t, res.Body.String(), "patientName")
+	})
+
+	t.Run("Other locations are off limits", func(t *testing.T) {
+		location := models.Location{Name: "Annex " + uuid.NewString()}
+		db.Create(&location)
+		userOther := factories.SeedUser(db, models.RoleReceptionist)
+		factories.SeedReceptionist(db, userOther, &location.ID)
+		other := apiclient.NewTestClient(setupApptRouterWithClaims(factories.MakeJWT(userOther.ID, models.RoleReceptionist)))
+
+		res := other.Put("/appointments/cancel/"+appt.ID.String(), map[string]interface{}{"reasonCode": "PATIENT_REQUEST"}, nil)
+		assert.Equal(t, http.StatusForbidden, res.Code)
+
+		// 2031-01-06 is a Monday.
+		body := map[string]interface{}{
+			"doctorId":        doctor.ID,
+			"patientId":       patient.ID,
+			"appointmentDate": "2031-01-06T00:00:00Z",
+			"startTime":       "15:00",
+			"endTime":         "15:30",
+			"appointmentType": "CONSULTATION",
+			"mode":            "In-Person",
+		}
+		res = other.Post("/appointments", body, nil)
+		assert.Equal(t, http.StatusForbidden, res.Code)
+
+		hours := factories.SeedWorkingHours(db, doctor.ID, int(time.Monday), "09:00", "17:00")
+		db.Model(&hours).Update("location_id", location.ID)
+		res = other.Post("/appointments", body, nil)
+		assert.Equal(t, http.StatusAccepted, res.Code)
+	})
+}
+
+func TestCheckInQueue(t *testing.T) {
+	db := config.DB
+	_, patient, userDoctor, doctor, _ := factories.CreateEntries(db)
+	userOther := factories.SeedUser(db, models.RolePatient)
+	otherPatient := factories.SeedPatient(db, userOther)
+
+	start := time.Now().UTC().Truncate(time.Minute).Add(-5 * time.Minute)
+	if start.Add(time.Hour).Day() != start.Day() {
+		t.Skip("queue would span midnight")
+	}
+	visit := func(patientID uuid.UUID, from time.Time) models.Appointment {
+		appt := factories.CreateAppointment(db, patientID, doctor.ID)
+		db.Model(&appt).Updates(map[string]interface{}{
+			"appointment_date": utils.CalendarDate(from),
+			"start_time":       from.Format(utils.ClockLayout),
+			"end_time":         from.Add(30 * time.Minute).Format(utils.ClockLayout),
+			"status":           models.AppointmentStatusConfirmed,
+			"mode":             utils.ModeInPerson,
+		})
+		db.First(&appt, "id = ?", appt.ID)
+		return appt
+	}
+	first := visit(patient.ID, start)
+	second := visit(otherPatient.ID, start.Add(30*time.Minute))
+
+	userReceptionist := factories.SeedUser(db, models.RoleReceptionist)
+	factories.SeedReceptionist(db, userReceptionist, nil)
+	clientReception := apiclient.NewTestClient(setupApptRouterWithClaims(factories.MakeJWT(userReceptionist.ID, models.RoleReceptionist)))
+	clientOther := apiclient.NewTestClient(setupApptRouterWithClaims(factories.MakeJWT(userOther.ID, models.RolePatient)))
+	clientDoctor := apiclient.NewTestClient(setupApptRouterWithClaims(factories.MakeJWT(userDoctor.ID, models.RoleDoctor)))
+
+	t.Run("Receptionist checks a patient in", func(t *testing.T) {
+		res := clientReception.Put("/appointments/check-in/"+first.ID.String(), nil, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+
+	t.Run("Self check-in by code", func(t *testing.T) {
+		res := clientOther.Post("/appointments/check-in", map[string]interface{}{"code": "NOPE00"}, nil)
+		assert.Equal(t, http.StatusNotFound, res.Code)
+
+		res = clientOther.Post("/appointments/check-in", map[string]interface{}{"code": strings.ToLower(second.CheckInCode)}, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+
+	var queue struct {
+		Queue utils.DoctorQueue `json:"queue"`
+	}
+	t.Run("Queue with estimated waits", func(t *testing.T) {
+		res := clientDoctor.Get("/appointments/queue/doctor/"+doctor.ID.String(), nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &queue))
+		if assert.Len(t, queue.Queue.Waiting, 2) {
+			assert.Equal(t, first.ID, queue.Queue.Waiting[0].AppointmentID)
+			assert.Equal(t, 0, queue.Queue.Waiting[0].EstimatedWaitMinutes)
+			assert.Equal(t, 30, queue.Queue.Waiting[1].EstimatedWaitMinutes)
+		}
+	})
+
+	t.Run("Doctor calls the next patient", func(t *testing.T) {
+		res := clientDoctor.Post("/appointments/queue/next", nil, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Contains(t, res.Body.String(), first.ID.String())
+
+		res = clientDoctor.Post("/appointments/queue/next", nil, nil)
+		assert.Equal(t, http.StatusConflict, res.Code)
+	})
+
+	t.Run("Completing records the consultation", func(t *testing.T) {
+		res := clientDoctor.Put("/appointments/status/"+first.ID.String(), map[string]interface{}{"status": "COMPLETED"}, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+
+		var done models.Appointment
+		db.First(&done, "id = ?", first.ID)
+		assert.NotNil(t, done.ConsultationStartedAt)
+		assert.NotNil(t, done.ConsultationEndedAt)
+
+		res = clientDoctor.Get("/appointments/queue/doctor/"+doctor.ID.String(), nil)
+		assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &queue))
+		assert.Nil(t, queue.Queue.Current)
+		assert.Len(t, queue.Queue.Waiting, 1)
+		assert.NotNil(t, queue.Queue.AverageConsultationMinutes)
+	})
+}
+
+func TestEmergencyBooking(t *testing.T) {
+	db := config.DB
+	userPatient, patient, _, doctor, _ := factories.CreateEntries(db)
+	routine := factories.CreateAppointment(db, patient.ID, doctor.ID)
+	userOther := factories.SeedUser(db, models.RolePatient)
+	otherPatient := factories.SeedPatient(db, userOther)
+
+	userReceptionist := factories.SeedUser(db, models.RoleReceptionist)
+	factories.SeedReceptionist(db, userReceptionist, nil)
+	clientReception := apiclient.NewTestClient(setupApptRouterWithClaims(factories.MakeJWT(userReceptionist.ID, models.RoleReceptionist)))
+	clientPatient := apiclient.NewTestClient(setupApptRouterWithClaims(factories.MakeJWT(userPatient.ID, models.RolePatient)))
+
+	body := map[string]interface{}{
+		"doctorId":        doctor.ID,
+		"patientId":       otherPatient.ID,
+		"appointmentDate": routine.AppointmentDate.Format(time.RFC3339),
+		"startTime":       routine.StartTime,
+		"endTime":         routine.EndTime,
+		"appointmentType": "EMERGENCY",
+		"mode":            "In-Person",
+	}
+
+	t.Run("Patients cannot book emergencies", func(t *testing.T) {
+		res := clientPatient.Post("/appointments", body, nil)
+		assert.Equal(t, http.StatusForbidden, res.Code)
+	})
+
+	t.Run("Emergencies need a triage level", func(t *testing.T) {
+		res := clientReception.Post("/appointments", body, nil)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+
+		body["triageLevel"] = models.TriageLevelLowest + 1
+		res = clientReception.Post("/appointments", body, nil)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+		assert.Contains(t, res.Body.String(), "triageLevel must be between")
+	})
+
+	t.Run("Emergency overbooks the doctor", func(t *testing.T) {
+		body["triageLevel"] = 2
+		res := clientReception.Post("/appointments", body, nil)
+		assert.Equal(t, http.StatusAccepted, res.Code)
+		var queued struct {
+			RequestID uuid.UUID `json:"requestId"`
+		}
+		assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &queued))
+		assert.NoError(t, appointments.CreateAppointment(context.Background(), queued.RequestID))
+
+		var request models.BookingRequest
+		assert.NoError(t, db.First(&request, "id = ?", queued.RequestID).Error)
+		if !assert.Equal(t, models.BookingRequestBooked, request.Status, request.Reason) {
+			return
+		}
+
+		var emergency models.Appointment
+		assert.NoError(t, db.First(&emergency, "id = ?", *request.AppointmentID).Error)
+		assert.Equal(t, models.AppointmentStatusConfirmed, emergency.Status)
+		if assert.NotNil(t, emergency.TriageLevel) {
+			assert.Equal(t, 2, *emergency.TriageLevel)
+		}
+
+		delayed, err := utils.EmergencyDelays(db, emergency)
+		assert.NoError(t, err)
+		if assert.Len(t, delayed, 1) {
+			assert.Equal(t, routine.ID, delayed[0].Appointment.ID)
+			assert.Equal(t, 30*time.Minute, delayed[0].Delay)
+		}
+	})
+}
+
+func TestSearchAppointments(t *testing.T) {
+	db := config.DB
+	userPatient, patient, userDoctor, doctor, userAdmin := factories.CreateEntries(db)
+	second := factories.CreateAppointment(db, patient.ID, doctor.ID)
+	db.Model(&second).Updates(map[string]interface{}{"start_time": "11:00", "end_time": "11:30", "status": models.AppointmentStatusConfirmed})
+	first := factories.CreateAppointment(db, patient.ID, doctor.ID)
+
+	clientAdmin := apiclient.NewTestClient(setupApptRouterWithClaims(factories.MakeJWT(userAdmin.ID, models.RoleAdmin)))
+	clientDoctor := apiclient.NewTestClient(setupApptRouterWithClaims(factories.MakeJWT(userDoctor.ID, models.RoleDoctor)))
+
+	type searchResult struct {
+		Appointments []struct {
+			ID          uuid.UUID `json:"ID"`
+			PatientName string    `json:"patientName"`
+		} `json:"appointments"`
+		Total int64 `json:"total"`
+	}
+
+	t.Run("Filters, sorts and counts", func(t *testing.T) {
+		date := first.AppointmentDate.Format("2006-01-02")
+		res := clientAdmin.Get("/appointments/search?doctor_id="+doctor.ID.String()+"&from="+date+"&to="+date+"&sort=-date&limit=1", nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		var result searchResult
+		assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &result))
+		assert.Equal(t, int64(2), result.Total)
+		if assert.Len(t, result.Appointments, 1) {
+			assert.Equal(t, second.ID, result.Appointments[0].ID)
+			assert.Contains(t, result.Appointments[0].PatientName, userPatient.LastName)
+		}
+
+		res = clientAdmin.Get("/appointments/search?doctor_id="+doctor.ID.String()+"&status=CONFIRMED&q="+userPatient.LastName, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &result))
+		assert.Equal(t, int64(1), result.Total)
+	})
+
+	t.Run("Invalid filters are rejected", func(t *testing.T) {
+		res := clientAdmin.Get("/appointments/search?status=LOST", nil)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+		res = clientAdmin.Get("/appointments/search?sort=password", nil)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+		res = clientAdmin.Get("/appointments/search?from=18-10-2026", nil)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Doctors only see their own appointments", func(t *testing.T) {
+		_, _, _, otherDoctor, _ := factories.CreateEntries(db)
+		res := clientDoctor.Get("/appointments/search?doctor_id="+otherDoctor.ID.String(), nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		var result searchResult
+		assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &result))
+		assert.Equal(t, int64(0), result.Total)
+	})
+}
+
+func TestCancellationPolicy(t *testing.T) {
+	db := config.DB
+	userPatient, patient, userDoctor, doctor, _ := factories.CreateEntries(db)
+	for weekday := 0; weekday < 7; weekday++ {
+		factories.SeedWorkingHours(db, doctor.ID, weekday, "09:00", "17:00")
+	}
+
+	start := time.Now().UTC().Truncate(time.Minute).Add(3 * time.Hour)
+	if start.Add(30*time.Minute).Day() != start.Day() {
+		t.Skip("appointment would span midnight")
+	}
+	soon := factories.CreateAppointment(db, patient.ID, doctor.ID)
+	db.Model(&soon).Updates(map[string]interface{}{
+		"appointment_date": utils.CalendarDate(start),
+		"start_time":       start.Format(utils.ClockLayout),
+		"end_time":         start.Add(30 * time.Minute).Format(utils.ClockLayout),
+		"status":           models.AppointmentStatusConfirmed,
+	})
+	later := factories.CreateAppointment(db, patient.ID, doctor.ID)
+
+	db.Model(&models.ClinicSettings{}).Where("id = 1").Updates(map[string]interface{}{"late_cancel_window_minutes": 24 * 60, "late_cancel_action": models.LateCancelBlock})
+	defer db.Model(&models.ClinicSettings{}).Where("id = 1").Update("late_cancel_action", models.LateCancelFlag)
+
+	clientPatient := apiclient.NewTestClient(setupApptRouterWithClaims(factories.MakeJWT(userPatient.ID, models.RolePatient)))
+	clientDoctor := apiclient.NewTestClient(setupApptRouterWithClaims(factories.MakeJWT(userDoctor.ID, models.RoleDoctor)))
+
+	t.Run("OTHER needs a written reason", func(t *testing.T) {
+		res := clientPatient.Put("/appointments/cancel/"+later.ID.String(), map[string]interface{}{"reasonCode": "OTHER"}, nil)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Late cancellation is blocked", func(t *testing.T) {
+		res := clientPatient.Put("/appointments/cancel/"+soon.ID.String(), map[string]interface{}{"reasonCode": "PATIENT_REQUEST"}, nil)
+		assert.Equal(t, http.StatusForbidden, res.Code)
+		assert.Contains(t, res.Body.String(), "lateCancelWindowMinutes")
+	})
+
+	t.Run("Late cancellation is flagged", func(t *testing.T) {
+		db.Model(&models.ClinicSettings{}).Where("id = 1").Update("late_cancel_action", models.LateCancelFlag)
+		res := clientPatient.Put("/appointments/cancel/"+soon.ID.String(), map[string]interface{}{"reasonCode": "PATIENT_ILLNESS", "reason": "Fever"}, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Contains(t, res.Body.String(), `"lateCancellation":true`)
+
+		var cancelled models.Appointment
+		db.First(&cancelled, "id = ?", soon.ID)
+		assert.True(t, cancelled.LateCancellation)
+		assert.NotNil(t, cancelled.CancelledAt)
+		if assert.NotNil(t, cancelled.CancellationReason) {
+			assert.Equal(t, models.CancelReasonPatientIllness, *cancelled.CancellationReason)
+		}
+	})
+
+	t.Run("Doctor cancellation suggests other slots", func(t *testing.T) {
+		res := clientDoctor.Put("/appointments/cancel/"+later.ID.String(), map[string]interface{}{"reasonCode": "DOCTOR_UNAVAILABLE"}, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+
+		var body struct {
+			LateCancellation bool         `json:"lateCancellation"`
+			SuggestedSlots   []utils.Slot `json:"suggestedSlots"`
+		}
+		assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &body))
+		assert.False(t, body.LateCancellation)
+		assert.NotEmpty(t, body.SuggestedSlots)
+		for _, slot := range body.SuggestedSlots {
+			assert.True(t, slot.StartsAt.After(time.Now()))
+		}
+	})
+}
+
+func TestBulkReschedule(t *testing.T) {
+	db := config.DB
+	userPatient, patient, _, doctor, userAdmin := factories.CreateEntries(db)
+	colleague := factories.SeedDoctor(db, factories.SeedUser(db, models.RoleDoctor))
+	userOther := factories.SeedUser(db, models.RolePatient)
+	other := factories.SeedPatient(db, userOther)
+
+	specialization := "BULK-" + uuid.NewString()
+	factories.ChangeDoctorSpecialization(db, doctor, specialization)
+	factories.ChangeDoctorSpecialization(db, colleague, specialization)
+	for weekday := 0; weekday < 7; weekday++ {
+		factories.SeedWorkingHours(db, doctor.ID, weekday, "09:00", "17:00")
+		factories.SeedWorkingHours(db, colleague.ID, weekday, "09:00", "17:00")
+	}
+
+	first := factories.CreateAppointment(db, patient.ID, doctor.ID)
+	second := factories.CreateAppointment(db, other.ID, doctor.ID)
+	db.Model(&second).Updates(map[string]interface{}{"start_time": "11:00", "end_time": "11:30", "status": models.AppointmentStatusConfirmed})
+	db.First(&first, "id = ?", first.ID)
+
+	clientAdmin := apiclient.NewTestClient(setupApptRouterWithClaims(factories.MakeJWT(userAdmin.ID, models.RoleAdmin)))
+	clientPatient := apiclient.NewTestClient(setupApptRouterWithClaims(factories.MakeJWT(userPatient.ID, models.RolePatient)))
+	clientOther := apiclient.NewTestClient(setupApptRouterWithClaims(factories.MakeJWT(userOther.ID, models.RolePatient)))
+
+	var batch models.RescheduleBatch
+	t.Run("Propose moves to the colleague", func(t *testing.T) {
+		res := clientAdmin.Post("/appointments/bulk-reschedule", map[string]interface{}{
+			"doctorId": doctor.ID,
+			"from":     first.StartsAt.Add(-time.Hour),
+			"to":       first.StartsAt.Add(3 * time.Hour),
+			"reason":   "Conference",
+		}, nil)
+		assert.Equal(t, http.StatusCreated, res.Code)
+
+		var body struct {
+			Batch models.RescheduleBatch `json:"batch"`
+		}
+		assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &body))
+		batch = body.Batch
+		assert.Equal(t, models.RescheduleBatchDraft, batch.Status)
+		if assert.Len(t, batch.Proposals, 2) {
+			for _, proposal := range batch.Proposals {
+				assert.Equal(t, models.RescheduleProposed, proposal.Status)
+				if assert.NotNil(t, proposal.DoctorID) {
+					assert.Equal(t, colleague.ID, *proposal.DoctorID)
+				}
+			}
+			assert.NotEqual(t, *batch.Proposals[0].StartTime, *batch.Proposals[1].StartTime)
+		}
+	})
+
+	t.Run("Reject a second draft for the same appointments", func(t *testing.T) {
+		res := clientAdmin.Post("/appointments/bulk-reschedule", map[string]interface{}{
+			"doctorId": doctor.ID,
+			"from":     first.StartsAt.Add(-time.Hour),
+			"to":       first.StartsAt.Add(time.Hour),
+		}, nil)
+		assert.Equal(t, http.StatusConflict, res.Code)
+	})
+
+	t.Run("Patients cannot propose", func(t *testing.T) {
+		res := clientPatient.Post("/appointments/bulk-reschedule", map[string]interface{}{
+			"doctorId": doctor.ID,
+			"from":     first.StartsAt,
+			"to":       first.StartsAt.Add(time.Hour),
+		}, nil)
+		assert.Equal(t, http.StatusForbidden, res.Code)
+	})
+
+	t.Run("Confirm moves the appointments", func(t *testing.T) {
+		res := clientAdmin.Put("/appointments/bulk-reschedule/"+batch.ID.String()+"/confirm", nil, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Contains(t, res.Body.String(), `"moved":2`)
+
+		var moved models.Appointment
+		db.First(&moved, "id = ?", second.ID)
+		assert.Equal(t, colleague.ID, moved.DoctorID)
+		assert.Equal(t, models.AppointmentStatusConfirmed, moved.Status)
+
+		res = clientAdmin.Put("/appointments/bulk-reschedule/"+batch.ID.String()+"/confirm", nil, nil)
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+
+	proposalFor := func(appointmentID uuid.UUID) models.RescheduleProposal {
+		var proposal models.RescheduleProposal
+		db.First(&proposal, "batch_id = ? AND appointment_id = ?", batch.ID, appointmentID)
+		return proposal
+	}
+
+	t.Run("Only the patient can respond", func(t *testing.T) {
+		res := clientOther.Put("/appointments/reschedule-proposals/"+proposalFor(first.ID).ID.String()+"/respond", map[string]interface{}{"accept": true}, nil)
+		assert.Equal(t, http.StatusForbidden, res.Code)
+	})
+
+	t.Run("Accept keeps the new time", func(t *testing.T) {
+		res := clientPatient.Put("/appointments/reschedule-proposals/"+proposalFor(first.ID).ID.String()+"/respond", map[string]interface{}{"accept": true}, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, models.RescheduleAccepted, proposalFor(first.ID).Status)
+
+		res = clientPatient.Put("/appointments/reschedule-proposals/"+proposalFor(first.ID).ID.String()+"/respond", map[string]interface{}{"accept": false}, nil)
+		assert.Equal(t, http.StatusConflict, res.Code)
+	})
+
+	t.Run("Decline cancels the appointment", func(t *testing.T) {
+		res := clientOther.Put("/appointments/reschedule-proposals/"+proposalFor(second.ID).ID.String()+"/respond", map[string]interface{}{"accept": false}, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, models.RescheduleDeclined, proposalFor(second.ID).Status)
+
+		var cancelled models.Appointment
+		db.First(&cancelled, "id = ?", second.ID)
+		assert.Equal(t, models.AppointmentStatusCancelled, cancelled.Status)
+		if assert.NotNil(t, cancelled.CancellationReason) {
+			assert.Equal(t, models.CancelReasonDoctorUnavailable, *cancelled.CancellationReason)
+		}
+	})
+
+	t.Run("Appointments changed since the proposal stay put", func(t *testing.T) {
+		third := factories.CreateAppointment(db, patient.ID, doctor.ID)
+		db.Model(&third).Updates(map[string]interface{}{"start_time": "15:00", "end_time": "15:30"})
+		db.First(&third, "id = ?", third.ID)
+
+		res := clientAdmin.Post("/appointments/bulk-reschedule", map[string]interface{}{
+			"doctorId": doctor.ID,
+			"from":     third.StartsAt.Add(-10 * time.Minute),
+			"to":       third.EndsAt.Add(10 * time.Minute),
+		}, nil)
+		assert.Equal(t, http.StatusCreated, res.Code)
+		var body struct {
+			Batch models.RescheduleBatch `json:"batch"`
+		}
+		assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &body))
+
+		db.Model(&third).Updates(map[string]interface{}{"start_time": "15:30", "end_time": "16:00"})
+		res = clientAdmin.Put("/appointments/bulk-reschedule/"+body.Batch.ID.String()+"/confirm", nil, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Contains(t, res.Body.String(), `"moved":0`)
+
+		var proposal models.RescheduleProposal
+		db.First(&proposal, "batch_id = ? AND appointment_id = ?", body.Batch.ID, third.ID)
+		assert.Equal(t, models.RescheduleFailed, proposal.Status)
+
+		var reloaded models.Appointment
+		db.First(&reloaded, "id = ?", third.ID)
+		assert.Equal(t, doctor.ID, reloaded.DoctorID)
+	})
+}
+
+func TestDoctorCalendar(t *testing.T) {
+	db := config.DB
+	userPatient, patient, userDoctor, doctor, _ := factories.CreateEntries(db)
+	for weekday := 0; weekday < 7; weekday++ {
+		factories.SeedWorkingHours(db, doctor.ID, weekday, "09:00", "12:00")
+		factories.SeedWorkingHours(db, doctor.ID, weekday, "13:00", "17:00")
+	}
+	appt := factories.CreateAppointment(db, patient.ID, doctor.ID)
+	date := appt.AppointmentDate.Format("2006-01-02")
+
+	start, end := "15:00", "16:00"
+	db.Create(&models.ScheduleException{
+		DoctorID:  doctor.ID,
+		Type:      models.ScheduleExceptionTimeOff,
+		StartDate: utils.CalendarDate(appt.AppointmentDate),
+		EndDate:   utils.CalendarDate(appt.AppointmentDate),
+		StartTime: &start,
+		EndTime:   &end,
+		Reason:    "Training",
+	})
+
+	clientDoctor := apiclient.NewTestClient(setupApptRouterWithClaims(factories.MakeJWT(userDoctor.ID, models.RoleDoctor)))
+	clientPatient := apiclient.NewTestClient(setupApptRouterWithClaims(factories.MakeJWT(userPatient.ID, models.RolePatient)))
+
+	type calendarResponse struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+		Days []struct {
+			Date         string                `json:"date"`
+			WorkingHours []utils.CalendarBlock `json:"workingHours"`
+			Breaks       []utils.CalendarBlock `json:"breaks"`
+			TimeOff      []utils.CalendarBlock `json:"timeOff"`
+			FreeSlots    []utils.Slot          `json:"freeSlots"`
+			Appointments []struct {
+				ID          uuid.UUID `json:"ID"`
+				PatientName string    `json:"patientName"`
+			} `json:"appointments"`
+		} `json:"days"`
+	}
+
+	t.Run("Day view merges the schedule", func(t *testing.T) {
+		res := clientDoctor.Get("/appointments/calendar?date="+date, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+
+		var body calendarResponse
+		assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &body))
+		if !assert.Len(t, body.Days, 1) {
+			return
+		}
+		day := body.Days[0]
+		assert.Equal(t, date, day.Date)
+		assert.Len(t, day.WorkingHours, 3)
+		if assert.Len(t, day.Breaks, 1) {
+			assert.Equal(t, "12:00", day.Breaks[0].StartTime)
+			assert.Equal(t, "13:00", day.Breaks[0].EndTime)
+		}
+		if assert.Len(t, day.TimeOff, 1) {
+			assert.Equal(t, "15:00", day.TimeOff[0].StartTime)
+		}
+		if assert.Len(t, day.Appointments, 1) {
+			assert.Equal(t, appt.ID, day.Appointments[0].ID)
+			assert.NotEmpty(t, day.Appointments[0].PatientName)
+		}
+		for _, slot := range day.FreeSlots {
+			assert.NotEqual(t, "10:00", slot.StartTime)
+			assert.NotEqual(t, "15:00", slot.StartTime)
+		}
+		assert.NotEmpty(t, day.FreeSlots)
+	})
+
+	t.Run("Week view runs Monday to Sunday", func(t *testing.T) {
+		res := clientDoctor.Get("/appointments/calendar?view=week&date="+date, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+
+		var body calendarResponse
+		assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &body))
+		assert.Len(t, body.Days, 7)
+		from, err := time.Parse("2006-01-02", body.From)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Monday, from.Weekday())
+		assert.Contains(t, res.Body.String(), appt.ID.String())
+	})
+
+	t.Run("Invalid view", func(t *testing.T) {
+		res := clientDoctor.Get("/appointments/calendar?view=month", nil)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Patients cannot view", func(t *testing.T) {
+		res := clientPatient.Get("/appointments/calendar", nil)
+		assert.Equal(t, http.StatusForbidden, res.Code)
+	})
+}
+
+func TestCompleteVisit(t *testing.T) {
+	db := config.DB
+	_, patient, userDoctor, doctor, _ := factories.CreateEntries(db)
+	otherDoctor := factories.SeedUser(db, models.RoleDoctor)
+	factories.SeedDoctor(db, otherDoctor)
+
+	appt := factories.CreateAppointment(db, patient.ID, doctor.ID)
+	clientDoctor := apiclient.NewTestClient(setupApptRouterWithClaims(factories.MakeJWT(userDoctor.ID, models.RoleDoctor)))
+	clientOther := apiclient.NewTestClient(setupApptRouterWithClaims(factories.MakeJWT(otherDoctor.ID, models.RoleDoctor)))
+
+	visit := map[string]interface{}{
+		"diagnosis": "Seasonal allergy",
+		"notes":     "Follow up in two weeks",
+		"vitals": []map[string]interface{}{
+			{"type": "TEMPERATURE", "value": "37.1", "status": "NORMAL"},
+			{"type": "HEART_RATE", "value": "72", "status": "NORMAL"},
+		},
+		"prescriptions": []map[string]interface{}{
+			{"medication": "Cetirizine", "dosage": "10mg daily"},
+		},
+	}
+
+	t.Run("Invalid vital type", func(t *testing.T) {
+		res := clientDoctor.Put("/appointments/complete/"+appt.ID.String(), map[string]interface{}{
+			"diagnosis": "Seasonal allergy",
+			"vitals":    []map[string]interface{}{{"type": "MOOD", "value": "good", "status": "NORMAL"}},
+		}, nil)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Pending appointment cannot be completed", func(t *testing.T) {
+		res := clientDoctor.Put("/appointments/complete/"+appt.ID.String(), visit, nil)
+		assert.Equal(t, http.StatusConflict, res.Code)
+
+		var count int64
+		db.Model(&models.MedicalRecord{}).Where("appointment_id = ?", appt.ID).Count(&count)
+		assert.Zero(t, count)
+	})
+
+	db.Model(&appt).Update("status", models.AppointmentStatusConfirmed)
+
+	t.Run("Another doctor cannot complete", func(t *testing.T) {
+		res := clientOther.Put("/appointments/complete/"+appt.ID.String(), visit, nil)
+		assert.Equal(t, http.StatusForbidden, res.Code)
+	})
+
+	t.Run("Complete visit writes linked records", func(t *testing.T) {
+		res := clientDoctor.Put("/appointments/complete/"+appt.ID.String(), visit, nil)
+		assert.Equal(t, http.StatusCreated, res.Code)
+
+		var completed models.Appointment
+		db.First(&completed, "id = ?", appt.ID)
+		assert.Equal(t, models.AppointmentStatusCompleted, completed.Status)
+		assert.NotNil(t, completed.ConsultationEndedAt)
+
+		var record models.MedicalRecord
+		assert.NoError(t, db.First(&record, "appointment_id = ?", appt.ID).Error)
+		assert.Equal(t, "Seasonal allergy", record.Diagnosis)
+		assert.Equal(t, doctor.ID, record.DoctorID)
+
+		var vitals []models.Vital
+		db.Where("appointment_id = ?", appt.ID).Find(&vitals)
+		assert.Len(t, vitals, 2)
+		for _, v := range vitals {
+			if assert.NotNil(t, v.MedicalRecordID) {
+				assert.Equal(t, record.ID, *v.MedicalRecordID)
+			}
+		}
+
+		var prescriptions []models.Prescription
+		db.Where("appointment_id = ?", appt.ID).Find(&prescriptions)
+		if assert.Len(t, prescriptions, 1) {
+			assert.Equal(t, "Cetirizine", prescriptions[0].Medication)
+			assert.Equal(t, record.ID, *prescriptions[0].MedicalRecordID)
+		}
+	})
+
+	t.Run("A visit is recorded once", func(t *testing.T) {
+		res := clientDoctor.Put("/appointments/complete/"+appt.ID.String(), visit, nil)
+		assert.Equal(t, http.StatusConflict, res.Code)
+	})
+}
+
+func TestCancelAppointmentSeries(t *testing.T) {
+	db := config.DB
+	userPatient, patient, _, doctor, _ := factories.CreateEntries(db)
+	client := apiclient.NewTestClient(setupApptRouterWithClaims(factories.MakeJWT(userPatient.ID, models.RolePatient)))
+
+	body := map[string]interface{}{
+		"doctorId":        doctor.ID,
+		"startDate":       time.Now().AddDate(0, 0, 10).Format(time.RFC3339),
+		"startTime":       "14:00",
+		"endTime":         "14:30",
+		"appointmentType": "FOLLOWUP",
+		"mode":            "Online",
+		"rrule":           "FREQ=WEEKLY;INTERVAL=1;COUNT=3",
+	}
+	res := client.Post("/appointments/series", body, nil)
+	assert.Equal(t, http.StatusCreated, res.Code)
+
+	var series models.AppointmentSeries
+	db.First(&series, "patient_id = ?", patient.ID)
+	var occurrences []models.Appointment
+	db.Where("series_id = ?", series.ID).Order("series_index asc").Find(&occurrences)
+	if !assert.Len(t, occurrences, 3) {
+		return
+	}
+	path := "/appointments/series/cancel/" + series.ID.String()
+
+	t.Run("Reason code is required", func(t *testing.T) {
+		res := client.Put(path, map[string]interface{}{"scope": "this", "appointmentId": occurrences[0].ID}, nil)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Late-cancellation block applies to occurrences", func(t *testing.T) {
+		db.Model(&models.ClinicSettings{}).Where("id = 1").Updates(map[string]interface{}{"late_cancel_window_minutes": 30 * 24 * 60, "late_cancel_action": models.LateCancelBlock})
+		defer db.Model(&models.ClinicSettings{}).Where("id = 1").Updates(map[string]interface{}{"late_cancel_window_minutes": 24 * 60, "late_cancel_action": models.LateCancelFlag})
+
+		res := client.Put(path, map[string]interface{}{"scope": "this", "appointmentId": occurrences[0].ID, "reasonCode": "PATIENT_REQUEST"}, nil)
+		assert.Equal(t, http.StatusForbidden, res.Code)
+
+		var reloaded models.Appointment
+		db.First(&reloaded, "id = ?", occurrences[0].ID)
+		assert.NotEqual(t, models.AppointmentStatusCancelled, reloaded.Status)
+	})
+
+	t.Run("Cancel following records the reason", func(t *testing.T) {
+		res := client.Put(path, map[string]interface{}{"scope": "following", "appointmentId": occurrences[1].ID, "reasonCode": "PATIENT_ILLNESS"}, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Contains(t, res.Body.String(), `"cancelled":2`)
+
+		for _, appt := range occurrences[1:] {
+			var reloaded models.Appointment
+			db.First(&reloaded, "id = ?", appt.ID)
+			assert.Equal(t, models.AppointmentStatusCancelled, reloaded.Status)
+			if assert.NotNil(t, reloaded.CancellationReason) {
+				assert.Equal(t, models.CancelReasonPatientIllness, *reloaded.CancellationReason)
+			}
+		}
+	})
+}
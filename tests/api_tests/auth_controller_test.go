@@ -2,15 +2,22 @@ package apitests
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/AltSumpreme/Medistream.git/config"
 	"github.com/AltSumpreme/Medistream.git/models"
 	"github.com/AltSumpreme/Medistream.git/routes"
+	"github.com/AltSumpreme/Medistream.git/services/cache"
 	apiclient "github.com/AltSumpreme/Medistream.git/tests/api_client"
+	"github.com/AltSumpreme/Medistream.git/tests/factories"
+	"github.com/AltSumpreme/Medistream.git/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -43,6 +50,37 @@ func TestAuthFlow(t *testing.T) {
 		assert.Equal(t, http.StatusOK, res.Code, "Signup failed")
 	})
 
+	t.Run("Login before verification", func(t *testing.T) {
+		res := client.Post("/auth/login", map[string]string{"email": email, "password": password}, nil)
+		assert.Equal(t, http.StatusForbidden, res.Code)
+		assert.Contains(t, res.Body.String(), "EMAIL_NOT_VERIFIED")
+	})
+
+	t.Run("Resend verification", func(t *testing.T) {
+		res := client.Post("/auth/resend-verification", map[string]string{"email": email}, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+
+	t.Run("Verify email", func(t *testing.T) {
+		exists, err := config.Rdb.Exists(config.Ctx, "otp:verify:"+email).Result()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), exists, "Verification code not stored")
+
+		// Only the digest is stored, so replace the emailed code with a known one.
+		otp := "123456"
+		assert.NoError(t, cache.SaveOTP(cache.OTPEmailVerification, email, utils.HashOTP(email, otp), time.Minute))
+
+		res := client.Post("/auth/verify-email", map[string]string{"email": email, "otp": "654321"}, nil)
+		assert.Equal(t, http.StatusUnauthorized, res.Code)
+
+		res = client.Post("/auth/verify-email", map[string]string{"email": email, "otp": otp}, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+
+		var auth models.Auth
+		assert.NoError(t, config.DB.Where("email = ?", email).First(&auth).Error)
+		assert.NotNil(t, auth.VerifiedAt)
+	})
+
 	t.Run("Login", func(t *testing.T) {
 		login := map[string]string{
 			"email":    email,
@@ -91,3 +129,103 @@ func TestAuthFlow(t *testing.T) {
 		assert.Equal(t, http.StatusOK, res.Code, "Logout failed")
 	})
 }
+
+func TestPasswordReset(t *testing.T) {
+	db := config.DB
+	client := apiclient.NewTestClient(setupAuthRouter())
+
+	user := factories.SeedUser(db, models.RolePatient)
+	var auth models.Auth
+	db.First(&auth, "id = ?", user.AuthID)
+	db.Create(&models.RefreshToken{UserID: user.ID, Token: uuid.NewString(), ExpiresAt: time.Now().Add(time.Hour)})
+
+	newPassword := "aBrandNewPassword1"
+	setCode := func(code string) {
+		assert.NoError(t, cache.SaveOTP(cache.OTPPasswordReset, auth.Email, utils.HashOTP(auth.Email, code), time.Minute))
+	}
+	reset := func(email, code string) int {
+		return client.Post("/auth/reset-password", map[string]string{"email": email, "resetToken": code, "newPassword": newPassword}, nil).Code
+	}
+
+	t.Run("Forgot password does not reveal accounts", func(t *testing.T) {
+		known := client.Post("/auth/forgot-password", map[string]string{"email": auth.Email}, nil)
+		unknown := client.Post("/auth/forgot-password", map[string]string{"email": "nobody+" + uuid.NewString() + "@example.com"}, nil)
+		assert.Equal(t, http.StatusOK, known.Code)
+		assert.Equal(t, known.Code, unknown.Code)
+		assert.Equal(t, known.Body.String(), unknown.Body.String())
+
+		stored, err := config.Rdb.Get(config.Ctx, "otp:reset:"+auth.Email).Result()
+		assert.NoError(t, err)
+		assert.Len(t, stored, 64, "Only the digest should be stored")
+		ttl, _ := config.Rdb.TTL(config.Ctx, "otp:reset:"+auth.Email).Result()
+		assert.Greater(t, ttl, time.Minute)
+
+		res := client.Post("/auth/resend-otp", map[string]string{"email": auth.Email}, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+
+	t.Run("Wrong code and unknown email are rejected alike", func(t *testing.T) {
+		setCode("246810")
+		assert.Equal(t, http.StatusUnauthorized, reset(auth.Email, "000000"))
+		assert.Equal(t, http.StatusUnauthorized, reset("nobody+"+uuid.NewString()+"@example.com", "246810"))
+	})
+
+	t.Run("Reset revokes refresh tokens", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, reset(auth.Email, "246810"))
+
+		var updated models.Auth
+		db.First(&updated, "id = ?", auth.ID)
+		assert.NoError(t, utils.VerifyPassword(updated.Password, newPassword))
+
+		var active int64
+		db.Model(&models.RefreshToken{}).Where("user_id = ? AND revoked = false", user.ID).Count(&active)
+		assert.Zero(t, active)
+	})
+
+	t.Run("Codes work once", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, reset(auth.Email, "246810"))
+	})
+
+	t.Run("Too many wrong codes lock the email", func(t *testing.T) {
+		setCode("135790")
+		status := 0
+		for i := 0; i < 5 && status != http.StatusTooManyRequests; i++ {
+			status = reset(auth.Email, "000000")
+		}
+		assert.Equal(t, http.StatusTooManyRequests, status)
+		assert.Equal(t, http.StatusTooManyRequests, reset(auth.Email, "135790"))
+	})
+
+	t.Run("Parallel guesses share the limit", func(t *testing.T) {
+		email := "burst+" + uuid.NewString() + "@example.com"
+		assert.NoError(t, cache.SaveOTP(cache.OTPPasswordReset, email, utils.HashOTP(email, "112233"), time.Minute))
+
+		var wg sync.WaitGroup
+		var misses atomic.Int32
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := cache.VerifyOTP(cache.OTPPasswordReset, email, utils.HashOTP(email, "000000")); errors.Is(err, cache.ErrOTPInvalid) {
+					misses.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Less(t, int(misses.Load()), 5)
+	})
+
+	t.Run("Restored codes can be used again", func(t *testing.T) {
+		email := "restore+" + uuid.NewString() + "@example.com"
+		digest := utils.HashOTP(email, "445566")
+		assert.NoError(t, cache.SaveOTP(cache.OTPPasswordReset, email, digest, time.Minute))
+
+		restore, err := cache.VerifyOTP(cache.OTPPasswordReset, email, digest)
+		assert.NoError(t, err)
+		restore()
+		_, err = cache.VerifyOTP(cache.OTPPasswordReset, email, digest)
+		assert.NoError(t, err)
+		_, err = cache.VerifyOTP(cache.OTPPasswordReset, email, digest)
+		assert.ErrorIs(t, err, cache.ErrOTPInvalid)
+	})
+}
@@ -0,0 +1,89 @@
+package apitests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/routes"
+	apiclient "github.com/AltSumpreme/Medistream.git/tests/api_client"
+	"github.com/AltSumpreme/Medistream.git/tests/factories"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupCalendarRouterWithClaims(claims *utils.JWTClaims) *gin.Engine {
+	r := gin.Default()
+	routes.RegisterCalendarFeedRoutes(r.Group("/calendar"))
+	protected := r.Group("/")
+	protected.Use(func(c *gin.Context) {
+		c.Set("jwtPayload", claims)
+		c.Next()
+	})
+	routes.RegisterCalendarRoutes(protected.Group("/calendar"))
+	return r
+}
+
+func TestCalendarFeed(t *testing.T) {
+	db := config.DB
+	_, patient, userDoctor, doctor, _ := factories.CreateEntries(db)
+	client := apiclient.NewTestClient(setupCalendarRouterWithClaims(factories.MakeJWT(userDoctor.ID, models.RoleDoctor)))
+	appt := factories.CreateAppointment(db, patient.ID, doctor.ID)
+	uid := "UID:" + appt.ID.String() + "@medistream"
+
+	res := client.Post("/calendar/feed-token", nil, nil)
+	assert.Equal(t, http.StatusCreated, res.Code)
+	var created struct {
+		URL string `json:"url"`
+	}
+	assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &created))
+
+	t.Run("Feed lists the doctor's appointments", func(t *testing.T) {
+		res := client.Get(created.URL, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.True(t, strings.HasPrefix(res.Header().Get("Content-Type"), "text/calendar"))
+		body := res.Body.String()
+		assert.Contains(t, body, "BEGIN:VCALENDAR\r\n")
+		assert.Contains(t, body, uid)
+		assert.Contains(t, body, "SEQUENCE:0")
+		assert.Contains(t, body, "STATUS:TENTATIVE")
+		assert.NotContains(t, body, "METHOD:")
+	})
+
+	t.Run("Status changes bump the sequence", func(t *testing.T) {
+		db.Model(&appt).Update("status", models.AppointmentStatusConfirmed)
+
+		body := client.Get(created.URL, nil).Body.String()
+		assert.Contains(t, body, uid)
+		assert.Contains(t, body, "SEQUENCE:1")
+		assert.Contains(t, body, "STATUS:CONFIRMED")
+	})
+
+	t.Run("Cancellation invite", func(t *testing.T) {
+		var reloaded models.Appointment
+		db.First(&reloaded, "id = ?", appt.ID)
+		ics := string(utils.BuildICS(utils.ICalMethodCancel, utils.AppointmentEvent(reloaded)))
+		assert.Contains(t, ics, "METHOD:CANCEL")
+		assert.Contains(t, ics, uid)
+		assert.Contains(t, ics, "STATUS:CANCELLED")
+	})
+
+	t.Run("Rotated and revoked tokens stop working", func(t *testing.T) {
+		res := client.Post("/calendar/feed-token", nil, nil)
+		assert.Equal(t, http.StatusCreated, res.Code)
+		assert.Equal(t, http.StatusNotFound, client.Get(created.URL, nil).Code)
+
+		var rotated struct {
+			URL string `json:"url"`
+		}
+		assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &rotated))
+		assert.Equal(t, http.StatusOK, client.Get(rotated.URL, nil).Code)
+
+		assert.Equal(t, http.StatusOK, client.Delete("/calendar/feed-token", nil).Code)
+		assert.Equal(t, http.StatusNotFound, client.Get(rotated.URL, nil).Code)
+	})
+}
@@ -0,0 +1,161 @@
+package apitests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/routes"
+	"github.com/AltSumpreme/Medistream.git/services/cache"
+	apiclient "github.com/AltSumpreme/Medistream.git/tests/api_client"
+	"github.com/AltSumpreme/Medistream.git/tests/factories"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupClinicRouterWithClaims(claims *utils.JWTClaims) *gin.Engine {
+	r := gin.Default()
+	r.Use(func(c *gin.Context) {
+		c.Set("jwtPayload", claims)
+		c.Next()
+	})
+	routes.RegisterClinicRoutes(r.Group("/clinic"), cache.NewCache(config.Rdb, config.Ctx))
+	return r
+}
+
+func TestRoomAssignment(t *testing.T) {
+	db := config.DB
+	_, patient, _, doctor, userAdmin := factories.CreateEntries(db)
+	_, otherPatient, _, otherDoctor, _ := factories.CreateEntries(db)
+	clientAdmin := apiclient.NewTestClient(setupClinicRouterWithClaims(factories.MakeJWT(userAdmin.ID, models.RoleAdmin)))
+
+	res := clientAdmin.Post("/clinic/locations", map[string]interface{}{"name": "North " + uuid.NewString(), "address": "1 Main St"}, nil)
+	assert.Equal(t, http.StatusCreated, res.Code)
+	var created struct {
+		Location models.Location `json:"location"`
+	}
+	assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &created))
+	location := created.Location
+
+	res = clientAdmin.Post("/clinic/locations/"+location.ID.String()+"/rooms", map[string]interface{}{"name": "Room 1"}, nil)
+	assert.Equal(t, http.StatusCreated, res.Code)
+
+	date := utils.CalendarDate(time.Now().AddDate(0, 0, 3))
+	for _, doctorID := range []uuid.UUID{doctor.ID, otherDoctor.ID} {
+		block := factories.SeedWorkingHours(db, doctorID, int(date.Weekday()), "09:00", "17:00")
+		db.Model(&block).Update("location_id", location.ID)
+	}
+
+	newAppt := func(doctorID, patientID uuid.UUID, mode string) models.Appointment {
+		return models.Appointment{
+			ID:              uuid.New(),
+			PatientID:       patientID,
+			DoctorID:        doctorID,
+			AppointmentDate: date,
+			StartTime:       "10:00",
+			EndTime:         "10:30",
+			Status:          models.AppointmentStatusPending,
+			Mode:            mode,
+			AppointmentType: models.ApptTypeConsultation,
+		}
+	}
+
+	t.Run("In-person visit gets a room", func(t *testing.T) {
+		appt := newAppt(doctor.ID, patient.ID, utils.ModeInPerson)
+		assert.NoError(t, utils.AssignRoom(db, &appt))
+		assert.NotNil(t, appt.RoomID)
+		assert.Equal(t, location.Name+", Room 1", appt.Location)
+		assert.NoError(t, db.Create(&appt).Error)
+	})
+
+	t.Run("Reject when every room is taken", func(t *testing.T) {
+		appt := newAppt(otherDoctor.ID, otherPatient.ID, utils.ModeInPerson)
+		err := utils.AssignRoom(db, &appt)
+		assert.True(t, utils.IsScheduleConflict(err))
+		assert.Contains(t, err.Error(), "no room is free")
+	})
+
+	t.Run("Online visit needs no room", func(t *testing.T) {
+		appt := newAppt(otherDoctor.ID, otherPatient.ID, "Online")
+		assert.NoError(t, utils.AssignRoom(db, &appt))
+		assert.Nil(t, appt.RoomID)
+		assert.Equal(t, location.ID, *appt.LocationID)
+	})
+
+	t.Run("Reject duplicate names", func(t *testing.T) {
+		res := clientAdmin.Post("/clinic/locations", map[string]interface{}{"name": location.Name}, nil)
+		assert.Equal(t, http.StatusConflict, res.Code)
+
+		res = clientAdmin.Post("/clinic/locations/"+location.ID.String()+"/rooms", map[string]interface{}{"name": "Room 1"}, nil)
+		assert.Equal(t, http.StatusConflict, res.Code)
+	})
+
+	t.Run("List locations with rooms", func(t *testing.T) {
+		res := clientAdmin.Get("/clinic/locations", nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Contains(t, res.Body.String(), "Room 1")
+	})
+}
+
+func TestClinicTimeZones(t *testing.T) {
+	db := config.DB
+	userAdmin := factories.SeedUser(db, models.RoleAdmin)
+	doctor := factories.SeedDoctor(db, factories.SeedUser(db, models.RoleDoctor))
+	clientAdmin := apiclient.NewTestClient(setupClinicRouterWithClaims(factories.MakeJWT(userAdmin.ID, models.RoleAdmin)))
+	t.Cleanup(func() {
+		clientAdmin.Put("/clinic/settings", map[string]interface{}{"timeZone": "UTC"}, nil)
+	})
+
+	t.Run("Reject unknown zones", func(t *testing.T) {
+		res := clientAdmin.Put("/clinic/settings", map[string]interface{}{"timeZone": "Mars/Olympus"}, nil)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+
+		res = clientAdmin.Post("/clinic/locations", map[string]interface{}{"name": "East " + uuid.NewString(), "time_zone": "Local"}, nil)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Locations keep their own zone", func(t *testing.T) {
+		res := clientAdmin.Put("/clinic/settings", map[string]interface{}{"timeZone": "Asia/Tokyo"}, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+
+		res = clientAdmin.Post("/clinic/locations", map[string]interface{}{"name": "West " + uuid.NewString(), "time_zone": "America/New_York"}, nil)
+		assert.Equal(t, http.StatusCreated, res.Code)
+		var created struct {
+			Location models.Location `json:"location"`
+		}
+		assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &created))
+
+		loc, err := utils.SiteLocation(db, created.Location.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "America/New_York", loc.String())
+		loc, err = utils.ClinicLocation(db)
+		assert.NoError(t, err)
+		assert.Equal(t, "Asia/Tokyo", loc.String())
+	})
+
+	t.Run("Holidays follow the clinic calendar", func(t *testing.T) {
+		// A Tokyo holiday runs from 15:00 UTC the day before to 15:00 UTC
+		// on the date, which is where it closes a doctor kept in UTC.
+		date := utils.CalendarDate(time.Now().AddDate(1, 6, 0))
+		assert.NoError(t, db.Create(&models.ClinicHoliday{Date: date, Name: "Founders' Day"}).Error)
+
+		morning, _ := utils.ParseClock("10:00")
+		afternoon, _ := utils.ParseClock("16:00")
+		err := utils.CheckDoctorAvailability(db, doctor.ID, date, morning, morning.Add(30*time.Minute))
+		assert.ErrorIs(t, err, utils.ErrDoctorUnavailable)
+		assert.Contains(t, err.Error(), "holiday")
+		assert.NoError(t, utils.CheckDoctorAvailability(db, doctor.ID, date, afternoon, afternoon.Add(30*time.Minute)))
+
+		dayBefore, err := utils.GetDayAvailability(db, doctor.ID, date.AddDate(0, 0, -1))
+		assert.NoError(t, err)
+		assert.Nil(t, dayBefore.Holiday)
+		if assert.Len(t, dayBefore.Closed, 1) {
+			assert.Equal(t, "15:00", dayBefore.Closed[0].Start.Format(utils.ClockLayout))
+		}
+	})
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/AltSumpreme/Medistream.git/config"
 	"github.com/AltSumpreme/Medistream.git/metrics"
+	"github.com/AltSumpreme/Medistream.git/queue"
 	"github.com/AltSumpreme/Medistream.git/tests/helpers"
 	"github.com/AltSumpreme/Medistream.git/utils"
 	"github.com/gin-gonic/gin"
@@ -23,6 +24,8 @@ func TestMain(m *testing.M) {
 
 	config.ConnectDB()
 	config.InitRedis()
+	config.InitAsynqQueue()
+	queue.Init()
 	helpers.SetupTestDatabase()
 	helpers.PatchDatabase()
 	utils.InitLogger()
@@ -41,6 +44,7 @@ func TestMain(m *testing.M) {
 
 	// Run Tests
 	code := m.Run()
+	queue.Close()
 	helpers.UnpatchDatabase()
 	helpers.TearDownTestDatabase()
 
@@ -0,0 +1,161 @@
+package apitests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/routes"
+	"github.com/AltSumpreme/Medistream.git/services/cache"
+	apiclient "github.com/AltSumpreme/Medistream.git/tests/api_client"
+	"github.com/AltSumpreme/Medistream.git/tests/factories"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupScheduleRouterWithClaims(claims *utils.JWTClaims) *gin.Engine {
+	r := gin.Default()
+	r.Use(func(c *gin.Context) {
+		c.Set("jwtPayload", claims)
+		c.Next()
+	})
+	scheduleCache := cache.NewCache(config.Rdb, config.Ctx)
+	rg := r.Group("/schedule")
+	routes.RegisterScheduleRoutes(rg, scheduleCache)
+	return r
+}
+
+func TestWorkingHoursRoutes(t *testing.T) {
+	db := config.DB
+	_, _, userDoctor, doctor, _ := factories.CreateEntries(db)
+
+	claimsDoctor := factories.MakeJWT(userDoctor.ID, models.RoleDoctor)
+	clientDoctor := apiclient.NewTestClient(setupScheduleRouterWithClaims(claimsDoctor))
+
+	t.Run("Create split shift", func(t *testing.T) {
+		morning := map[string]interface{}{"weekday": 1, "start_time": "09:00", "end_time": "12:00"}
+		res := clientDoctor.Post("/schedule/working-hours", morning, nil)
+		assert.Equal(t, http.StatusCreated, res.Code)
+
+		afternoon := map[string]interface{}{"weekday": 1, "start_time": "14:00", "end_time": "17:00"}
+		res = clientDoctor.Post("/schedule/working-hours", afternoon, nil)
+		assert.Equal(t, http.StatusCreated, res.Code)
+	})
+
+	t.Run("Reject overlapping block", func(t *testing.T) {
+		body := map[string]interface{}{"weekday": 1, "start_time": "11:00", "end_time": "15:00"}
+		res := clientDoctor.Post("/schedule/working-hours", body, nil)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+		assert.Contains(t, res.Body.String(), "overlap")
+	})
+
+	t.Run("List working hours", func(t *testing.T) {
+		res := clientDoctor.Get("/schedule/working-hours/doctor/"+doctor.ID.String(), nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Contains(t, res.Body.String(), "working_hours")
+	})
+
+	t.Run("Deactivate working hours", func(t *testing.T) {
+		block := factories.SeedWorkingHours(db, doctor.ID, 3, "08:00", "10:00")
+		res := clientDoctor.Put("/schedule/working-hours/deactivate/"+block.ID.String(), nil, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Contains(t, res.Body.String(), "Working hours deactivated")
+	})
+}
+
+func TestScheduleExceptionRoutes(t *testing.T) {
+	db := config.DB
+	_, _, userDoctor, doctor, userAdmin := factories.CreateEntries(db)
+
+	clientDoctor := apiclient.NewTestClient(setupScheduleRouterWithClaims(factories.MakeJWT(userDoctor.ID, models.RoleDoctor)))
+	clientAdmin := apiclient.NewTestClient(setupScheduleRouterWithClaims(factories.MakeJWT(userAdmin.ID, models.RoleAdmin)))
+
+	t.Run("Create time off", func(t *testing.T) {
+		body := map[string]interface{}{
+			"type":       "TIME_OFF",
+			"start_date": "2030-01-07",
+			"end_date":   "2030-01-11",
+			"reason":     "Conference",
+		}
+		res := clientDoctor.Post("/schedule/exceptions", body, nil)
+		assert.Equal(t, http.StatusCreated, res.Code)
+		assert.Contains(t, res.Body.String(), "affected_appointments")
+	})
+
+	t.Run("Extra hours require times", func(t *testing.T) {
+		body := map[string]interface{}{"type": "EXTRA_HOURS", "start_date": "2030-01-12"}
+		res := clientDoctor.Post("/schedule/exceptions", body, nil)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("List exceptions", func(t *testing.T) {
+		res := clientDoctor.Get("/schedule/exceptions/doctor/"+doctor.ID.String()+"?from=2030-01-01&to=2030-01-31", nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Contains(t, res.Body.String(), "Conference")
+	})
+
+	t.Run("Create clinic holiday", func(t *testing.T) {
+		body := map[string]interface{}{"date": time.Now().AddDate(10, 0, 0).Format("2006-01-02"), "name": "Founders Day"}
+		res := clientAdmin.Post("/schedule/holidays", body, nil)
+		assert.Equal(t, http.StatusCreated, res.Code)
+
+		res = clientAdmin.Post("/schedule/holidays", body, nil)
+		assert.Equal(t, http.StatusConflict, res.Code)
+	})
+
+	t.Run("List holidays rejects bad dates", func(t *testing.T) {
+		res := clientAdmin.Get("/schedule/holidays?from=next-week", nil)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+
+		res = clientAdmin.Get("/schedule/holidays?from=2030-01-01&to=2030-12-31", nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+}
+
+func TestSchedulingRules(t *testing.T) {
+	db := config.DB
+	_, patient, userDoctor, doctor, _ := factories.CreateEntries(db)
+	appt := factories.CreateAppointment(db, patient.ID, doctor.ID)
+	clientDoctor := apiclient.NewTestClient(setupScheduleRouterWithClaims(factories.MakeJWT(userDoctor.ID, models.RoleDoctor)))
+
+	t.Run("Buffer between appointments", func(t *testing.T) {
+		res := clientDoctor.Put("/schedule/rules", map[string]interface{}{"buffer_minutes": 15}, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+
+		err := utils.ScheduleAppointment(db, doctor.ID, patient.ID, appt.AppointmentDate, "10:35", "11:00", models.ApptTypeFollowup, nil)
+		assert.True(t, utils.IsScheduleConflict(err))
+		assert.Contains(t, err.Error(), "15 minutes between appointments")
+
+		assert.NoError(t, utils.ScheduleAppointment(db, doctor.ID, patient.ID, appt.AppointmentDate, "10:45", "11:00", models.ApptTypeFollowup, nil))
+	})
+
+	t.Run("Daily cap per type", func(t *testing.T) {
+		res := clientDoctor.Put("/schedule/daily-caps", map[string]interface{}{"appointment_type": "CONSULTATION", "max_per_day": 1}, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+
+		err := utils.ScheduleAppointment(db, doctor.ID, patient.ID, appt.AppointmentDate, "14:00", "14:45", models.ApptTypeConsultation, nil)
+		assert.True(t, utils.IsScheduleConflict(err))
+		assert.Contains(t, err.Error(), "daily limit")
+
+		slots, err := utils.GetAvailableSlots(db, doctor.ID, appt.AppointmentDate, models.ApptTypeConsultation)
+		assert.NoError(t, err)
+		assert.Empty(t, slots)
+
+		res = clientDoctor.Get("/schedule/rules/doctor/"+doctor.ID.String(), nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Contains(t, res.Body.String(), `"CONSULTATION":1`)
+	})
+
+	t.Run("Break after consecutive appointments", func(t *testing.T) {
+		clock := func(s string) time.Time { c, _ := utils.ParseClock(s); return c }
+		block := func(start, end string) utils.TimeBlock { return utils.TimeBlock{Start: clock(start), End: clock(end)} }
+		rules := utils.SchedulingRules{DoctorSchedulingRules: models.DoctorSchedulingRules{BreakAfterConsecutive: 2, BreakMinutes: 15}}
+		booked := []utils.BookedBlock{{TimeBlock: block("09:00", "09:30")}, {TimeBlock: block("09:30", "10:00")}}
+
+		assert.Contains(t, rules.Violation(block("10:00", "10:30"), models.ApptTypeCheckup, booked), "break")
+		assert.Empty(t, rules.Violation(block("10:15", "10:45"), models.ApptTypeCheckup, booked))
+	})
+}
@@ -79,4 +79,23 @@ func TestUserRoutes(t *testing.T) {
 		assert.Equal(t, http.StatusOK, res.Code)
 		assert.Contains(t, res.Body.String(), "CARDIOLOGY")
 	})
+
+	t.Run("Admin verifies an email address", func(t *testing.T) {
+		userAdmin := factories.SeedUser(db, models.RoleAdmin)
+		clientAdmin := apiclient.NewTestClient(setupUserRouterWithClaims(factories.MakeJWT(userAdmin.ID, models.RoleAdmin)))
+
+		unverified := factories.SeedUser(db, models.RolePatient)
+		db.Model(&models.Auth{}).Where("id = ?", unverified.AuthID).Update("verified_at", nil)
+
+		res := clientPatient.Put("/users/verify-email/"+unverified.ID.String(), nil, nil)
+		assert.Equal(t, http.StatusForbidden, res.Code)
+
+		res = clientAdmin.Put("/users/verify-email/"+unverified.ID.String(), nil, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Contains(t, res.Body.String(), "Email marked as verified")
+
+		var auth models.Auth
+		db.First(&auth, "id = ?", unverified.AuthID)
+		assert.NotNil(t, auth.VerifiedAt)
+	})
 }
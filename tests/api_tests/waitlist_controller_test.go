@@ -0,0 +1,154 @@
+package apitests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/controllers/waitlist"
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/AltSumpreme/Medistream.git/queue"
+	"github.com/AltSumpreme/Medistream.git/routes"
+	"github.com/AltSumpreme/Medistream.git/services/cache"
+	apiclient "github.com/AltSumpreme/Medistream.git/tests/api_client"
+	"github.com/AltSumpreme/Medistream.git/tests/factories"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupWaitlistRouterWithClaims(claims *utils.JWTClaims) *gin.Engine {
+	r := gin.Default()
+	r.Use(func(c *gin.Context) {
+		c.Set("jwtPayload", claims)
+		c.Next()
+	})
+	appointmentCache := cache.NewCache(config.Rdb, config.Ctx)
+	routes.RegisterWaitlistRoutes(r.Group("/waitlist"), appointmentCache)
+	return r
+}
+
+func TestWaitlistRoutes(t *testing.T) {
+	db := config.DB
+	userPatient, patient, _, doctor, _ := factories.CreateEntries(db)
+	client := apiclient.NewTestClient(setupWaitlistRouterWithClaims(factories.MakeJWT(userPatient.ID, models.RolePatient)))
+	day := time.Now().AddDate(0, 0, 21)
+
+	t.Run("Join waitlist", func(t *testing.T) {
+		body := map[string]interface{}{
+			"doctorId":        doctor.ID,
+			"appointmentType": "FOLLOWUP",
+			"mode":            "Online",
+			"earliestDate":    day.Format("2006-01-02"),
+			"latestDate":      day.AddDate(0, 0, 7).Format("2006-01-02"),
+			"windowStart":     "09:00",
+			"windowEnd":       "12:00",
+		}
+		res := client.Post("/waitlist", body, nil)
+		assert.Equal(t, http.StatusCreated, res.Code)
+
+		res = client.Get("/waitlist", nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Contains(t, res.Body.String(), "WAITING")
+
+		body["doctorId"] = uuid.New()
+		res = client.Post("/waitlist", body, nil)
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+
+	t.Run("Claim offer", func(t *testing.T) {
+		token := "123456"
+		start, end := "10:00", "10:15"
+		expires := time.Now().Add(30 * time.Minute)
+		entry := models.WaitlistEntry{
+			PatientID:       patient.ID,
+			DoctorID:        doctor.ID,
+			AppointmentType: models.ApptTypeFollowup,
+			Mode:            "Online",
+			EarliestDate:    day,
+			LatestDate:      day,
+			WindowStart:     "09:00",
+			WindowEnd:       "12:00",
+			Status:          models.WaitlistOffered,
+			OfferToken:      &token,
+			OfferDate:       &day,
+			OfferStartTime:  &start,
+			OfferEndTime:    &end,
+			OfferExpiresAt:  &expires,
+		}
+		db.Create(&entry)
+
+		res := client.Post("/waitlist/claim/"+entry.ID.String(), map[string]interface{}{"token": "000000"}, nil)
+		assert.Equal(t, http.StatusConflict, res.Code)
+
+		res = client.Post("/waitlist/claim/"+entry.ID.String(), map[string]interface{}{"token": token}, nil)
+		assert.Equal(t, http.StatusCreated, res.Code)
+
+		res = client.Post("/waitlist/claim/"+entry.ID.String(), map[string]interface{}{"token": token}, nil)
+		assert.Equal(t, http.StatusConflict, res.Code)
+	})
+}
+
+func TestWaitlistOfferChain(t *testing.T) {
+	db := config.DB
+	ctx := context.Background()
+	doctor := factories.SeedDoctor(db, factories.SeedUser(db, models.RoleDoctor))
+	day := time.Now().AddDate(0, 0, 28)
+	factories.SeedWorkingHours(db, doctor.ID, int(day.Weekday()), "09:00", "17:00")
+
+	entries := make([]models.WaitlistEntry, 3)
+	for i := range entries {
+		patient := factories.SeedPatient(db, factories.SeedUser(db, models.RolePatient))
+		entries[i] = models.WaitlistEntry{
+			PatientID:       patient.ID,
+			DoctorID:        doctor.ID,
+			AppointmentType: models.ApptTypeFollowup,
+			Mode:            "Online",
+			EarliestDate:    day,
+			LatestDate:      day,
+			WindowStart:     "09:00",
+			WindowEnd:       "12:00",
+			Status:          models.WaitlistWaiting,
+			CreatedAt:       time.Now().Add(time.Duration(i-3) * time.Minute),
+		}
+		db.Create(&entries[i])
+	}
+
+	// expiryFor finds the expiry task scheduled for an entry's offer.
+	expiryFor := func(entryID uuid.UUID) queue.WaitlistOfferPayload {
+		tasks, err := queue.Inspector.ListScheduledTasks("appointments", asynq.PageSize(1000))
+		assert.NoError(t, err)
+		for _, task := range tasks {
+			var p queue.WaitlistOfferPayload
+			if task.Type == string(queue.JobTypeWaitlistOfferExpire) && json.Unmarshal(task.Payload, &p) == nil && p.EntryID == entryID {
+				return p
+			}
+		}
+		t.Fatalf("no expiry scheduled for entry %s", entryID)
+		return queue.WaitlistOfferPayload{}
+	}
+	status := func(entryID uuid.UUID) models.WaitlistStatus {
+		var entry models.WaitlistEntry
+		db.First(&entry, "id = ?", entryID)
+		return entry.Status
+	}
+
+	err := waitlist.BackfillSlot(ctx, queue.FreedSlotPayload{DoctorID: doctor.ID, AppointmentDate: day, StartTime: "10:00", EndTime: "10:30"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.WaitlistOffered, status(entries[0].ID))
+
+	// Neither of the first two patients responds, so the offer must reach
+	// the third rather than bounce between them.
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, waitlist.ExpireWaitlistOffer(ctx, expiryFor(entries[i].ID)))
+		assert.Equal(t, models.WaitlistWaiting, status(entries[i].ID))
+		assert.Equal(t, models.WaitlistOffered, status(entries[i+1].ID))
+	}
+	assert.Equal(t, models.WaitlistWaiting, status(entries[0].ID))
+	assert.ElementsMatch(t, []uuid.UUID{entries[0].ID, entries[1].ID}, expiryFor(entries[2].ID).SkipEntryIDs)
+}
@@ -3,6 +3,7 @@ package factories
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/AltSumpreme/Medistream.git/models"
 	"github.com/AltSumpreme/Medistream.git/utils"
@@ -17,10 +18,12 @@ func SeedUser(db *gorm.DB, role models.Role) models.User {
 	}
 	email := fmt.Sprintf("test_%s_%s@example.com", role, uuid.New().String())
 
+	now := time.Now()
 	auth := models.Auth{
-		ID:       uuid.New(),
-		Email:    email,
-		Password: "hashedpassword",
+		ID:         uuid.New(),
+		Email:      email,
+		Password:   "hashedpassword",
+		VerifiedAt: &now,
 	}
 	if err := db.Create(&auth).Error; err != nil {
 		panic(fmt.Sprintf("Failed to create auth: %v", err))
@@ -93,3 +96,18 @@ func CreateEntries(db *gorm.DB) (models.User, models.Patient, models.User, model
 
 	return userPatient, patient, userDoctor, doctor, userAdmin
 }
+
+func SeedReceptionist(db *gorm.DB, user models.User, locationID *uuid.UUID) models.Receptionist {
+	if db == nil {
+		log.Fatal("db instance is nil")
+	}
+	receptionist := models.Receptionist{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		LocationID: locationID,
+	}
+	if err := db.Omit("User", "Location").Create(&receptionist).Error; err != nil {
+		log.Fatalf("failed to seed receptionist: %v", err)
+	}
+	return receptionist
+}
@@ -0,0 +1,27 @@
+package factories
+
+import (
+	"log"
+
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func SeedWorkingHours(db *gorm.DB, doctorID uuid.UUID, weekday int, start, end string) models.Doctor_working_hours {
+	if db == nil {
+		log.Fatal("db instance is nil")
+	}
+	block := models.Doctor_working_hours{
+		ID:        uuid.New(),
+		DoctorID:  doctorID,
+		Weekday:   weekday,
+		StartTime: start,
+		EndTime:   end,
+		IsActive:  true,
+	}
+	if err := db.Create(&block).Error; err != nil {
+		log.Fatalf("failed to seed working hours: %v", err)
+	}
+	return block
+}
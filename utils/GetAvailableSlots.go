@@ -3,63 +3,116 @@ package utils
 import (
 	"time"
 
+	"github.com/AltSumpreme/Medistream.git/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
-func GetAvailableSlots(db *gorm.DB, doctorID uuid.UUID, appointmentDate time.Time) ([]string, error) {
-	var workingHours struct {
-		StartTime string
-		EndTime   string
+// Slot is a bookable period. StartTime and EndTime are on the doctor's wall
+// clock; StartsAt and EndsAt are the same period as absolute instants.
+type Slot struct {
+	StartTime string    `json:"start_time"`
+	EndTime   string    `json:"end_time"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+}
+
+// GetBookedBlocks returns the appointments a doctor already has on a date,
+// leaving out excludeID. Cancelled appointments do not hold their slot.
+func GetBookedBlocks(db *gorm.DB, doctorID uuid.UUID, appointmentDate time.Time, excludeID *uuid.UUID) ([]BookedBlock, error) {
+	var bookedSlots []struct {
+		StartTime       string
+		EndTime         string
+		AppointmentType models.ApptType
+	}
+	query := db.Model(&models.Appointment{}).
+		Select("start_time, end_time, appointment_type").
+		Where("doctor_id = ? AND DATE(appointment_date) = ? AND status <> ?", doctorID, appointmentDate.Format("2006-01-02"), models.AppointmentStatusCancelled)
+	if excludeID != nil {
+		query = query.Where("id <> ?", *excludeID)
+	}
+	if err := query.Scan(&bookedSlots).Error; err != nil {
+		return nil, err
 	}
 
-	weekday := int(appointmentDate.Weekday()) // 0 = Sunday
-	err := db.Raw(`
-		SELECT start_time, end_time 
-		FROM doctor_working_hours 
-		WHERE doctor_id = ? AND weekday = ? AND is_active = true
-	`, doctorID, weekday).Scan(&workingHours).Error
+	booked := make([]BookedBlock, 0, len(bookedSlots))
+	for _, slot := range bookedSlots {
+		start, err1 := ParseClock(slot.StartTime)
+		end, err2 := ParseClock(slot.EndTime)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		booked = append(booked, BookedBlock{TimeBlock: TimeBlock{Start: start, End: end}, Type: slot.AppointmentType})
+	}
+	return booked, nil
+}
+
+func GetAvailableSlots(db *gorm.DB, doctorID uuid.UUID, appointmentDate time.Time, apptType models.ApptType) ([]Slot, error) {
+	length, err := GetSlotDuration(db, doctorID, apptType)
 	if err != nil {
 		return nil, err
 	}
-	if workingHours.StartTime == "" || workingHours.EndTime == "" {
-		return []string{}, nil
-	}
 
-	// Parse start/end times
-	layout := "15:04"
-	startTime, err1 := time.Parse(layout, workingHours.StartTime)
-	endTime, err2 := time.Parse(layout, workingHours.EndTime)
-	if err1 != nil || err2 != nil {
+	day, err := GetDayAvailability(db, doctorID, appointmentDate)
+	if err != nil {
 		return nil, err
 	}
+	blocks := day.Open
+	if len(blocks) == 0 {
+		return []Slot{}, nil
+	}
 
-	// Fetch booked slots
-	var bookedSlots []struct {
-		StartTime string
-		EndTime   string
+	booked, err := GetBookedBlocks(db, doctorID, appointmentDate, nil)
+	if err != nil {
+		return nil, err
 	}
-	err = db.Raw(`
-		SELECT start_time, end_time 
-		FROM appointments 
-		WHERE doctor_id = ? AND DATE(appointment_date) = ?
-	`, doctorID, appointmentDate.Format("2006-01-02")).Scan(&bookedSlots).Error
+	rules, err := GetSchedulingRules(db, doctorID)
 	if err != nil {
 		return nil, err
 	}
-
-	booked := make(map[string]bool)
-	for _, slot := range bookedSlots {
-		booked[slot.StartTime] = true
+	loc, err := DoctorLocation(db, doctorID)
+	if err != nil {
+		return nil, err
 	}
-	// Generate 30-min interval slots
-	var available []string
-	for t := startTime; t.Add(30*time.Minute).Before(endTime) || t.Add(30*time.Minute).Equal(endTime); t = t.Add(30 * time.Minute) {
-		slot := t.Format(layout)
-		if !booked[slot] {
-			available = append(available, slot)
+
+	// Walk every open block (working hours and extra hours minus time off) in
+	// steps of the slot length plus the doctor's buffer, so split shifts are
+	// both offered. A slot is dropped when it overlaps any booking, not only
+	// when the start times match, or when it breaks the doctor's pacing rules.
+	step := length + time.Duration(rules.BufferMinutes)*time.Minute
+	available := []Slot{}
+	for _, block := range blocks {
+		for t := block.Start; !t.Add(length).After(block.End); t = t.Add(step) {
+			candidate := TimeBlock{Start: t, End: t.Add(length)}
+			if overlapsBooked(candidate, booked) || rules.Violation(candidate, apptType, booked) != "" {
+				continue
+			}
+			available = append(available, Slot{
+				StartTime: candidate.Start.Format(ClockLayout),
+				EndTime:   candidate.End.Format(ClockLayout),
+				StartsAt:  Instant(appointmentDate, candidate.Start, loc),
+				EndsAt:    Instant(appointmentDate, candidate.End, loc),
+			})
 		}
 	}
 
 	return available, nil
 }
+
+func overlapsAny(candidate TimeBlock, blocks []TimeBlock) bool {
+	for _, b := range blocks {
+		if candidate.Overlaps(b) {
+			return true
+		}
+	}
+	return false
+}
+
+func overlapsBooked(candidate TimeBlock, booked []BookedBlock) bool {
+	for _, b := range booked {
+		if candidate.Overlaps(b.TimeBlock) {
+			return true
+		}
+	}
+	return false
+}
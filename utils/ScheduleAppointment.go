@@ -6,53 +6,172 @@ import (
 
 	"github.com/AltSumpreme/Medistream.git/models"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"gorm.io/gorm"
 )
 
-func ScheduleAppointment(db *gorm.DB, doctorID uuid.UUID, patientID uuid.UUID, appointmentDate time.Time, start string, end string, excludeID *uuid.UUID) error {
+// ScheduleConflictError is returned when a booking is rejected for scheduling
+// reasons, as opposed to a database failure.
+type ScheduleConflictError struct {
+	Reason string
+}
+
+func (e *ScheduleConflictError) Error() string {
+	return e.Reason
+}
+
+func conflict(reason string) error {
+	return &ScheduleConflictError{Reason: reason}
+}
+
+// IsScheduleConflict reports whether err means the requested slot cannot be
+// booked, so retrying will not help.
+func IsScheduleConflict(err error) bool {
+	var conflictErr *ScheduleConflictError
+	return errors.As(err, &conflictErr) || errors.Is(err, ErrDoctorUnavailable)
+}
 
-	layoutTime := "15:04"
-	startTime, err1 := time.Parse(layoutTime, start)
-	endTime, err2 := time.Parse(layoutTime, end)
+// Exclusion constraints on the appointments table, see the time range migration.
+const (
+	doctorOverlapConstraint  = "excl_appointments_doctor_overlap"
+	patientOverlapConstraint = "excl_appointments_patient_overlap"
+	roomOverlapConstraint    = "excl_appointments_room_overlap"
+	exclusionViolationCode   = "23P01"
+	uniqueViolationCode      = "23505"
+)
+
+// IsUniqueViolation reports whether err is the database rejecting a duplicate
+// value.
+func IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolationCode
+}
+
+// BookingError turns an overlap rejected by the database into the same
+// conflict error ScheduleAppointment returns, so callers report it the same
+// way whether the pre-check or the constraint caught it.
+func BookingError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != exclusionViolationCode {
+		return err
+	}
+	switch pqErr.Constraint {
+	case patientOverlapConstraint:
+		return conflict("time slot already booked for this patient")
+	case doctorOverlapConstraint:
+		return conflict("time slot already booked for this doctor")
+	case roomOverlapConstraint:
+		return conflict("the assigned room was just booked, please try again")
+	}
+	return err
+}
+
+// ScheduleAppointment validates a booking of apptType against the doctor's
+// availability, existing appointments and pacing rules. excludeID is the
+// appointment being moved, if any.
+func ScheduleAppointment(db *gorm.DB, doctorID uuid.UUID, patientID uuid.UUID, appointmentDate time.Time, start string, end string, apptType models.ApptType, excludeID *uuid.UUID) error {
+
+	startTime, err1 := ParseClock(start)
+	endTime, err2 := ParseClock(end)
 	if err1 != nil || err2 != nil {
-		return errors.New("invalid time format, expected HH:MM")
+		return conflict("invalid time format, expected HH:MM")
 	}
 
 	if !endTime.After(startTime) {
-		return errors.New("end time must be after start time")
+		return conflict("end time must be after start time")
 	}
 
-	// will later have an overlap buffer for after every 5 appointments have a break of 15mins to half and hour
-	overlapCondition := "DATE(appointment_date) = ? AND ((start_time < ? AND end_time > ?) OR (start_time >= ? AND start_time < ?))"
-	var count int64
-	query := db.Model(&models.Appointment{}).
-		Where("patient_id = ?", patientID).
-		Where(overlapCondition, appointmentDate, endTime, startTime, startTime, endTime)
-
-	if excludeID != nil {
-		query = query.Where("id != ?", *excludeID)
+	if err := CheckDoctorAvailability(db, doctorID, appointmentDate, startTime, endTime); err != nil {
+		return err
 	}
 
-	if err := query.Count(&count).Error; err != nil {
+	// Cancelled appointments no longer hold their slot. These checks give a
+	// readable error; the exclusion constraints are what prevent double booking
+	// under concurrency, see BookingError.
+	count, err := countOverlaps(db, "patient_id", patientID, appointmentDate, startTime, endTime, excludeID)
+	if err != nil {
 		return err
 	}
+	if count > 0 {
+		return conflict("time slot already booked for this patient")
+	}
 
+	count, err = countOverlaps(db, "doctor_id", doctorID, appointmentDate, startTime, endTime, excludeID)
+	if err != nil {
+		return err
+	}
 	if count > 0 {
-		return errors.New("time slot already booked for this doctor")
+		return conflict("time slot already booked for this doctor")
 	}
 
-	query = db.Model(&models.Appointment{}).Where("doctor_id=?", doctorID).Where(overlapCondition, appointmentDate, endTime, startTime, startTime, endTime)
-	if excludeID != nil {
-		query = query.Where("id != ?", *excludeID)
+	return checkSchedulingRules(db, doctorID, appointmentDate, TimeBlock{Start: startTime, End: endTime}, apptType, excludeID)
+}
+
+// checkSchedulingRules applies the doctor's buffers, breaks and daily caps.
+// Inside a transaction the doctor row is locked first so concurrent bookings
+// for the same doctor are checked one after another.
+func checkSchedulingRules(db *gorm.DB, doctorID uuid.UUID, appointmentDate time.Time, candidate TimeBlock, apptType models.ApptType, excludeID *uuid.UUID) error {
+	rules, err := GetSchedulingRules(db, doctorID)
+	if err != nil {
+		return err
+	}
+	if !rules.Active() {
+		return nil
 	}
 
-	if err := query.Count(&count).Error; err != nil {
+	if err := db.Exec("SELECT 1 FROM doctors WHERE id = ? FOR UPDATE", doctorID).Error; err != nil {
 		return err
 	}
+	booked, err := GetBookedBlocks(db, doctorID, appointmentDate, excludeID)
+	if err != nil {
+		return err
+	}
+	if reason := rules.Violation(candidate, apptType, booked); reason != "" {
+		return conflict(reason)
+	}
+	return nil
+}
 
-	if count > 0 {
-		return errors.New("time slot already booked for this patient")
+// ScheduleEmergency validates an emergency booking. The doctor only has to be
+// on duty: emergencies may overbook the doctor and skip the pacing rules, but
+// the patient still cannot be in two appointments at once.
+func ScheduleEmergency(db *gorm.DB, doctorID uuid.UUID, patientID uuid.UUID, appointmentDate time.Time, start string, end string) error {
+	startTime, err1 := ParseClock(start)
+	endTime, err2 := ParseClock(end)
+	if err1 != nil || err2 != nil {
+		return conflict("invalid time format, expected HH:MM")
+	}
+	if !endTime.After(startTime) {
+		return conflict("end time must be after start time")
+	}
+
+	if err := CheckDoctorAvailability(db, doctorID, appointmentDate, startTime, endTime); err != nil {
+		return err
 	}
 
+	count, err := countOverlaps(db, "patient_id", patientID, appointmentDate, startTime, endTime, nil)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return conflict("time slot already booked for this patient")
+	}
 	return nil
 }
+
+// countOverlaps counts the live appointments of the patient or doctor in
+// column that overlap the period. Times are stored as zero-padded "HH:MM"
+// strings, so they compare correctly as text.
+func countOverlaps(db *gorm.DB, column string, id uuid.UUID, appointmentDate time.Time, startTime, endTime time.Time, excludeID *uuid.UUID) (int64, error) {
+	query := db.Model(&models.Appointment{}).
+		Where(column+" = ?", id).
+		Where("DATE(appointment_date) = DATE(?) AND start_time < ? AND end_time > ? AND status <> ?",
+			appointmentDate, endTime.Format(ClockLayout), startTime.Format(ClockLayout), models.AppointmentStatusCancelled)
+	if excludeID != nil {
+		query = query.Where("id != ?", *excludeID)
+	}
+
+	var count int64
+	err := query.Count(&count).Error
+	return count, err
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"time"
+
 	"github.com/AltSumpreme/Medistream.git/config"
 	"github.com/AltSumpreme/Medistream.git/models"
 	"github.com/google/uuid"
@@ -40,10 +42,12 @@ func CreateAdminUserIfNotExists() error {
 		}
 	}()
 
+	now := time.Now()
 	newAdmin := models.Auth{
-		ID:       uuid.New(),
-		Email:    adminEmail,
-		Password: hashedPassword,
+		ID:         uuid.New(),
+		Email:      adminEmail,
+		Password:   hashedPassword,
+		VerifiedAt: &now,
 	}
 	if err := tx.Create(&newAdmin).Error; err != nil {
 		tx.Rollback()
@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidTransition    = errors.New("invalid status transition")
+	ErrTransitionNotAllowed = errors.New("status transition not allowed for this role")
+)
+
+// appointmentTransitions lists every legal status change and the roles that
+// may make it. Statuses without an entry are terminal.
+var appointmentTransitions = map[models.AppointmentStatus]map[models.AppointmentStatus][]models.Role{
+	models.AppointmentStatusPending: {
+		models.AppointmentStatusConfirmed: {models.RoleAdmin, models.RoleDoctor, models.RoleReceptionist},
+		models.AppointmentStatusCancelled: {models.RoleAdmin, models.RoleDoctor, models.RoleReceptionist, models.RolePatient},
+	},
+	models.AppointmentStatusConfirmed: {
+		models.AppointmentStatusCheckedIn: {models.RoleAdmin, models.RoleDoctor, models.RoleReceptionist, models.RolePatient},
+		models.AppointmentStatusCompleted: {models.RoleAdmin, models.RoleDoctor},
+		models.AppointmentStatusCancelled: {models.RoleAdmin, models.RoleDoctor, models.RoleReceptionist, models.RolePatient},
+		models.AppointmentStatusNoShow:    {models.RoleAdmin, models.RoleDoctor},
+	},
+	models.AppointmentStatusCheckedIn: {
+		models.AppointmentStatusInConsultation: {models.RoleAdmin, models.RoleDoctor},
+		models.AppointmentStatusCancelled:      {models.RoleAdmin, models.RoleDoctor, models.RoleReceptionist},
+	},
+	models.AppointmentStatusInConsultation: {
+		models.AppointmentStatusCompleted: {models.RoleAdmin, models.RoleDoctor},
+	},
+}
+
+// CheckInLead is how long before the start a patient may check in.
+const CheckInLead = time.Hour
+
+// statusTimestamps names the column that records when a visit reached each
+// stage of the waiting-room queue, or was cancelled.
+var statusTimestamps = map[models.AppointmentStatus]string{
+	models.AppointmentStatusCheckedIn:      "checked_in_at",
+	models.AppointmentStatusInConsultation: "consultation_started_at",
+	models.AppointmentStatusCompleted:      "consultation_ended_at",
+	models.AppointmentStatusCancelled:      "cancelled_at",
+}
+
+// StatusActor identifies who changed an appointment's status. A nil UserID
+// means the change was made by the system.
+type StatusActor struct {
+	UserID *uuid.UUID
+	Role   models.Role
+}
+
+func ActorFromClaims(claims *JWTClaims) StatusActor {
+	if claims == nil {
+		return StatusActor{}
+	}
+	id := claims.UserID
+	return StatusActor{UserID: &id, Role: models.Role(claims.Role)}
+}
+
+// IsTerminalStatus reports whether no further transitions are possible.
+func IsTerminalStatus(status models.AppointmentStatus) bool {
+	return len(appointmentTransitions[status]) == 0
+}
+
+// TerminalStatuses returns the statuses an appointment can no longer leave.
+func TerminalStatuses() []models.AppointmentStatus {
+	return []models.AppointmentStatus{models.AppointmentStatusCancelled, models.AppointmentStatusCompleted, models.AppointmentStatusNoShow}
+}
+
+// AppointmentStart returns the instant an appointment begins. Rows loaded
+// from the database carry it; otherwise the wall-clock fields are read as UTC.
+func AppointmentStart(appt models.Appointment) time.Time {
+	if appt.StartsAt != nil {
+		return *appt.StartsAt
+	}
+	clock, err := ParseClock(appt.StartTime)
+	if err != nil {
+		return appt.AppointmentDate
+	}
+	d := appt.AppointmentDate
+	return time.Date(d.Year(), d.Month(), d.Day(), clock.Hour(), clock.Minute(), 0, 0, d.Location())
+}
+
+// CheckTransition validates a status change without applying it. A system
+// actor (empty role) may make any legal transition.
+func CheckTransition(appt models.Appointment, to models.AppointmentStatus, role models.Role, now time.Time) error {
+	roles, ok := appointmentTransitions[appt.Status][to]
+	if !ok {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, appt.Status, to)
+	}
+	if role != "" && !slices.Contains(roles, role) {
+		return fmt.Errorf("%w: %s cannot move an appointment to %s", ErrTransitionNotAllowed, role, to)
+	}
+
+	start := AppointmentStart(appt)
+	switch to {
+	case models.AppointmentStatusNoShow:
+		if now.Before(start) {
+			return fmt.Errorf("%w: an appointment cannot be marked as a no-show before it starts", ErrInvalidTransition)
+		}
+	case models.AppointmentStatusCancelled:
+		if role == models.RolePatient && !now.Before(start) {
+			return fmt.Errorf("%w: an appointment cannot be cancelled after it has started", ErrTransitionNotAllowed)
+		}
+	case models.AppointmentStatusCheckedIn:
+		if appt.Mode != ModeInPerson {
+			return fmt.Errorf("%w: only in-person appointments are checked in", ErrInvalidTransition)
+		}
+		if now.Before(start.Add(-CheckInLead)) {
+			return fmt.Errorf("%w: check-in opens %s before the appointment", ErrInvalidTransition, CheckInLead)
+		}
+		if appt.EndsAt != nil && !now.Before(*appt.EndsAt) {
+			return fmt.Errorf("%w: the appointment has already ended", ErrInvalidTransition)
+		}
+	}
+	return nil
+}
+
+// TransitionAppointment moves appt to a new status and records the change in
+// the status history. The update is conditional on the current status so a
+// concurrent change is reported rather than overwritten.
+func TransitionAppointment(tx *gorm.DB, appt *models.Appointment, to models.AppointmentStatus, actor StatusActor, reason string) error {
+	if err := CheckTransition(*appt, to, actor.Role, time.Now()); err != nil {
+		return err
+	}
+
+	from := appt.Status
+	now := time.Now()
+	updates := map[string]interface{}{"status": to, "updated_by": actor.UserID}
+	if column, ok := statusTimestamps[to]; ok {
+		updates[column] = now
+	}
+	result := tx.Model(&models.Appointment{}).
+		Where("id = ? AND status = ?", appt.ID, from).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: the appointment status was changed by someone else", ErrInvalidTransition)
+	}
+
+	history := models.AppointmentStatusHistory{
+		AppointmentID: appt.ID,
+		FromStatus:    from,
+		ToStatus:      to,
+		ChangedBy:     actor.UserID,
+		ActorRole:     actor.Role,
+		Reason:        reason,
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return err
+	}
+
+	appt.Status = to
+	appt.UpdatedBy = actor.UserID
+	switch to {
+	case models.AppointmentStatusCheckedIn:
+		appt.CheckedInAt = &now
+	case models.AppointmentStatusInConsultation:
+		appt.ConsultationStartedAt = &now
+	case models.AppointmentStatusCompleted:
+		appt.ConsultationEndedAt = &now
+	case models.AppointmentStatusCancelled:
+		appt.CancelledAt = &now
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AttendanceStanding is a patient's position against the clinic's no-show
+// policy. Action is empty when the policy does not apply to them.
+type AttendanceStanding struct {
+	RecentNoShows int64               `json:"recent_no_shows"`
+	Limit         int                 `json:"limit"`
+	WindowDays    int                 `json:"window_days"`
+	Action        models.NoShowAction `json:"action,omitempty"`
+}
+
+// CheckAttendancePolicy counts the patient's no-shows inside the policy
+// window and reports the action new bookings are subject to.
+func CheckAttendancePolicy(db *gorm.DB, patientID uuid.UUID, settings models.ClinicSettings, now time.Time) (AttendanceStanding, error) {
+	standing := AttendanceStanding{Limit: settings.NoShowLimit, WindowDays: settings.NoShowWindowDays}
+
+	since := now.AddDate(0, 0, -settings.NoShowWindowDays)
+	err := db.Model(&models.Appointment{}).
+		Where("patient_id = ? AND status = ? AND starts_at >= ?", patientID, models.AppointmentStatusNoShow, since).
+		Count(&standing.RecentNoShows).Error
+	if err != nil {
+		return standing, err
+	}
+
+	if settings.NoShowLimit > 0 && standing.RecentNoShows >= int64(settings.NoShowLimit) {
+		standing.Action = settings.NoShowAction
+	}
+	return standing, nil
+}
@@ -0,0 +1,238 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrDoctorUnavailable = errors.New("doctor is unavailable")
+
+// DayAvailability is a doctor's effective schedule for a single date, after
+// the weekly template has been combined with holidays and exceptions.
+type DayAvailability struct {
+	Open    []TimeBlock
+	TimeOff []TimeBlock
+	Holiday *models.ClinicHoliday
+	// Closed holds the parts of the day a clinic holiday covers when the
+	// doctor's zone differs from the clinic's and the holiday does not span
+	// the whole of the doctor's date.
+	Closed []TimeBlock
+	// Configured is false when the doctor has neither a weekly template nor
+	// extra hours on this date, in which case bookings are not limited to
+	// working hours.
+	Configured bool
+}
+
+func GetDayAvailability(db *gorm.DB, doctorID uuid.UUID, date time.Time) (DayAvailability, error) {
+	var day DayAvailability
+	dateStr := date.Format("2006-01-02")
+
+	holiday, closed, err := holidayClosures(db, doctorID, date)
+	if err != nil {
+		return day, err
+	}
+	if holiday != nil {
+		day.Holiday = holiday
+		day.Configured = true
+		return day, nil
+	}
+	day.Closed = closed
+
+	var templateCount int64
+	if err := db.Model(&models.Doctor_working_hours{}).
+		Where("doctor_id = ? AND is_active = true", doctorID).
+		Count(&templateCount).Error; err != nil {
+		return day, err
+	}
+	day.Configured = templateCount > 0
+
+	open, err := GetWorkingHourBlocks(db, doctorID, date.Weekday())
+	if err != nil {
+		return day, err
+	}
+
+	var exceptions []models.ScheduleException
+	if err := db.Where("doctor_id = ? AND start_date <= ? AND end_date >= ?", doctorID, dateStr, dateStr).
+		Find(&exceptions).Error; err != nil {
+		return day, err
+	}
+
+	for _, ex := range exceptions {
+		block, err := exceptionBlock(ex)
+		if err != nil {
+			Log.Warnf("GetDayAvailability: skipping malformed exception %s", ex.ID)
+			continue
+		}
+		switch ex.Type {
+		case models.ScheduleExceptionExtraHours:
+			open = append(open, block)
+			day.Configured = true
+		case models.ScheduleExceptionTimeOff:
+			day.TimeOff = append(day.TimeOff, block)
+		}
+	}
+
+	day.Open = subtractBlocks(subtractBlocks(mergeBlocks(open), day.TimeOff), day.Closed)
+	return day, nil
+}
+
+// holidayClosures finds the clinic holidays falling on a doctor's date.
+// Holidays are dates on the clinic's calendar, so for a doctor in another
+// zone one may close only part of the date; those parts are returned on the
+// doctor's wall clock. A holiday covering the whole date is returned alone.
+func holidayClosures(db *gorm.DB, doctorID uuid.UUID, date time.Time) (*models.ClinicHoliday, []TimeBlock, error) {
+	doctorLoc, err := DoctorLocation(db, doctorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	clinicLoc, err := ClinicLocation(db)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if doctorLoc.String() == clinicLoc.String() {
+		var holiday models.ClinicHoliday
+		err := db.Where("date = ?", date.Format("2006-01-02")).First(&holiday).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		return &holiday, nil, nil
+	}
+
+	var holidays []models.ClinicHoliday
+	if err := db.Where("date BETWEEN ? AND ?", date.AddDate(0, 0, -1).Format("2006-01-02"), date.AddDate(0, 0, 1).Format("2006-01-02")).
+		Find(&holidays).Error; err != nil {
+		return nil, nil, err
+	}
+
+	dayStart := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, doctorLoc)
+	dayEnd := time.Date(date.Year(), date.Month(), date.Day()+1, 0, 0, 0, 0, doctorLoc)
+	midnight, _ := ParseClock("00:00")
+	wallClock := func(t time.Time) time.Time {
+		if t.Equal(dayEnd) {
+			return midnight.Add(24 * time.Hour)
+		}
+		local := t.In(doctorLoc)
+		return midnight.Add(time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute)
+	}
+
+	var closed []TimeBlock
+	for i, h := range holidays {
+		start := time.Date(h.Date.Year(), h.Date.Month(), h.Date.Day(), 0, 0, 0, 0, clinicLoc)
+		end := time.Date(h.Date.Year(), h.Date.Month(), h.Date.Day()+1, 0, 0, 0, 0, clinicLoc)
+		if start.Before(dayStart) {
+			start = dayStart
+		}
+		if end.After(dayEnd) {
+			end = dayEnd
+		}
+		if !start.Before(end) {
+			continue
+		}
+		if start.Equal(dayStart) && end.Equal(dayEnd) {
+			return &holidays[i], nil, nil
+		}
+		closed = append(closed, TimeBlock{Start: wallClock(start), End: wallClock(end)})
+	}
+	return nil, mergeBlocks(closed), nil
+}
+
+// CheckDoctorAvailability rejects bookings that fall on a clinic holiday,
+// into doctor time off, or outside the doctor's working hours.
+func CheckDoctorAvailability(db *gorm.DB, doctorID uuid.UUID, date time.Time, start, end time.Time) error {
+	day, err := GetDayAvailability(db, doctorID, date)
+	if err != nil {
+		return err
+	}
+
+	if day.Holiday != nil {
+		return fmt.Errorf("%w: the clinic is closed on %s (%s)", ErrDoctorUnavailable, date.Format("2006-01-02"), day.Holiday.Name)
+	}
+
+	requested := TimeBlock{Start: start, End: end}
+	if overlapsAny(requested, day.Closed) {
+		return fmt.Errorf("%w: the clinic is closed for a holiday during this period", ErrDoctorUnavailable)
+	}
+	if overlapsAny(requested, day.TimeOff) {
+		return fmt.Errorf("%w: the doctor is on leave during this period", ErrDoctorUnavailable)
+	}
+
+	if day.Configured {
+		for _, block := range day.Open {
+			if block.Contains(requested) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: the requested time is outside the doctor's working hours", ErrDoctorUnavailable)
+	}
+	return nil
+}
+
+// exceptionBlock returns the part of a day covered by an exception. An
+// exception without times covers the whole day.
+func exceptionBlock(ex models.ScheduleException) (TimeBlock, error) {
+	if ex.StartTime == nil || ex.EndTime == nil {
+		midnight, _ := ParseClock("00:00")
+		return TimeBlock{Start: midnight, End: midnight.Add(24 * time.Hour)}, nil
+	}
+	start, err := ParseClock(*ex.StartTime)
+	if err != nil {
+		return TimeBlock{}, err
+	}
+	end, err := ParseClock(*ex.EndTime)
+	if err != nil {
+		return TimeBlock{}, err
+	}
+	return TimeBlock{Start: start, End: end}, nil
+}
+
+func mergeBlocks(blocks []TimeBlock) []TimeBlock {
+	if len(blocks) == 0 {
+		return blocks
+	}
+	sorted := append([]TimeBlock(nil), blocks...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start.Before(sorted[j].Start) })
+
+	merged := []TimeBlock{sorted[0]}
+	for _, b := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if !b.Start.After(last.End) {
+			if b.End.After(last.End) {
+				last.End = b.End
+			}
+			continue
+		}
+		merged = append(merged, b)
+	}
+	return merged
+}
+
+func subtractBlocks(open []TimeBlock, closed []TimeBlock) []TimeBlock {
+	result := open
+	for _, c := range closed {
+		var next []TimeBlock
+		for _, o := range result {
+			if !o.Overlaps(c) {
+				next = append(next, o)
+				continue
+			}
+			if o.Start.Before(c.Start) {
+				next = append(next, TimeBlock{Start: o.Start, End: c.Start})
+			}
+			if c.End.Before(o.End) {
+				next = append(next, TimeBlock{Start: c.End, End: o.End})
+			}
+		}
+		result = next
+	}
+	return result
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SlotOffer is a free slot with a particular doctor.
+type SlotOffer struct {
+	DoctorID uuid.UUID
+	Date     time.Time
+	Slot
+}
+
+// AffectedAppointments returns the doctor's open appointments that overlap
+// the period from start to end.
+func AffectedAppointments(db *gorm.DB, doctorID uuid.UUID, start, end time.Time) ([]models.Appointment, error) {
+	var appts []models.Appointment
+	err := db.Where("doctor_id = ? AND status IN ?", doctorID,
+		[]models.AppointmentStatus{models.AppointmentStatusPending, models.AppointmentStatusConfirmed}).
+		Where("time_range && tstzrange(?, ?, '[)')", start, end).
+		Order("starts_at asc").
+		Find(&appts).Error
+	return appts, err
+}
+
+// EquivalentDoctors returns the doctor followed by the other doctors with the
+// same specialization.
+func EquivalentDoctors(db *gorm.DB, doctorID uuid.UUID) ([]models.Doctor, error) {
+	var doctor models.Doctor
+	if err := db.First(&doctor, "id = ?", doctorID).Error; err != nil {
+		return nil, err
+	}
+	doctors := []models.Doctor{doctor}
+	if doctor.Specialization == "" {
+		return doctors, nil
+	}
+
+	var others []models.Doctor
+	err := db.Where("specialization = ? AND id <> ?", doctor.Specialization, doctor.ID).
+		Order("id asc").
+		Find(&others).Error
+	return append(doctors, others...), err
+}
+
+// Reservations holds the slots already promised within a batch, per doctor,
+// so two appointments are not offered the same time.
+type Reservations map[uuid.UUID][]TimeRange
+
+// TimeRange is a period between two instants.
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (r Reservations) overlaps(doctorID uuid.UUID, start, end time.Time) bool {
+	for _, taken := range r[doctorID] {
+		if start.Before(taken.End) && end.After(taken.Start) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextFreeSlot finds the earliest free slot for appt within the rebooking
+// horizon. The candidates are tried in order and each is searched from its
+// own earliest instant, so the unavailable doctor can be searched from the
+// end of their absence while colleagues are searched from its start. Ties
+// go to the earlier candidate. Slots in reserved are skipped, as are slots
+// that clash with the patient's other appointments.
+func NextFreeSlot(db *gorm.DB, appt models.Appointment, candidates []models.Doctor, earliest map[uuid.UUID]time.Time, reserved Reservations) (*SlotOffer, error) {
+	var best *SlotOffer
+	for _, doctor := range candidates {
+		offer, err := firstFreeSlot(db, appt, doctor.ID, earliest[doctor.ID], reserved)
+		if err != nil {
+			return nil, err
+		}
+		if offer != nil && (best == nil || offer.StartsAt.Before(best.StartsAt)) {
+			best = offer
+		}
+	}
+	return best, nil
+}
+
+func firstFreeSlot(db *gorm.DB, appt models.Appointment, doctorID uuid.UUID, after time.Time, reserved Reservations) (*SlotOffer, error) {
+	loc, err := DoctorLocation(db, doctorID)
+	if err != nil {
+		return nil, err
+	}
+	day, _ := WallClock(after, loc)
+	for i := 0; i < rebookingSearchDays; i++ {
+		date := day.AddDate(0, 0, i)
+		slots, err := GetAvailableSlots(db, doctorID, date, appt.AppointmentType)
+		if err != nil {
+			return nil, err
+		}
+		for _, slot := range slots {
+			if slot.StartsAt.Before(after) || reserved.overlaps(doctorID, slot.StartsAt, slot.EndsAt) {
+				continue
+			}
+			start, _ := ParseClock(slot.StartTime)
+			end, _ := ParseClock(slot.EndTime)
+			clashes, err := countOverlaps(db, "patient_id", appt.PatientID, date, start, end, &appt.ID)
+			if err != nil {
+				return nil, err
+			}
+			if clashes > 0 {
+				continue
+			}
+			return &SlotOffer{DoctorID: doctorID, Date: date, Slot: slot}, nil
+		}
+	}
+	return nil, nil
+}
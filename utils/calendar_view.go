@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"sort"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CalendarBlock is a period on a doctor's calendar. StartTime and EndTime are
+// on the doctor's wall clock; a block running to midnight ends at "24:00".
+type CalendarBlock struct {
+	StartTime string    `json:"start_time"`
+	EndTime   string    `json:"end_time"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Reason    string    `json:"reason,omitempty"`
+}
+
+// CalendarDay is everything but the appointments that a schedule grid needs
+// for one date: when the doctor works, the breaks and time off in between,
+// and the slots still free. Time off includes the part of a clinic holiday
+// that falls on the date when the doctor keeps another zone than the clinic.
+type CalendarDay struct {
+	Date         string          `json:"date"`
+	Holiday      string          `json:"holiday,omitempty"`
+	WorkingHours []CalendarBlock `json:"workingHours"`
+	Breaks       []CalendarBlock `json:"breaks"`
+	TimeOff      []CalendarBlock `json:"timeOff"`
+	FreeSlots    []Slot          `json:"freeSlots"`
+}
+
+// GetCalendarDay builds a doctor's calendar for one date. Breaks are the gaps
+// between working blocks that are not time off, and the pacing breaks the
+// doctor's rules require after runs of consecutive bookings. Free slots are
+// for apptType and leave out those starting before now.
+func GetCalendarDay(db *gorm.DB, doctorID uuid.UUID, date time.Time, apptType models.ApptType, now time.Time) (CalendarDay, error) {
+	cal := CalendarDay{
+		Date:         date.Format("2006-01-02"),
+		WorkingHours: []CalendarBlock{},
+		Breaks:       []CalendarBlock{},
+		TimeOff:      []CalendarBlock{},
+		FreeSlots:    []Slot{},
+	}
+
+	loc, err := DoctorLocation(db, doctorID)
+	if err != nil {
+		return cal, err
+	}
+	day, err := GetDayAvailability(db, doctorID, date)
+	if err != nil {
+		return cal, err
+	}
+	if day.Holiday != nil {
+		cal.Holiday = day.Holiday.Name
+		return cal, nil
+	}
+
+	for _, b := range day.Open {
+		cal.WorkingHours = append(cal.WorkingHours, calendarBlock(date, b, loc, ""))
+	}
+	for _, b := range mergeBlocks(day.TimeOff) {
+		cal.TimeOff = append(cal.TimeOff, calendarBlock(date, b, loc, "Time off"))
+	}
+	for _, b := range day.Closed {
+		cal.TimeOff = append(cal.TimeOff, calendarBlock(date, b, loc, "Clinic holiday"))
+	}
+	sort.Slice(cal.TimeOff, func(i, j int) bool { return cal.TimeOff[i].StartsAt.Before(cal.TimeOff[j].StartsAt) })
+
+	var breaks []CalendarBlock
+	for i := 1; i < len(day.Open); i++ {
+		gap := TimeBlock{Start: day.Open[i-1].End, End: day.Open[i].Start}
+		for _, b := range subtractBlocks(subtractBlocks([]TimeBlock{gap}, day.TimeOff), day.Closed) {
+			breaks = append(breaks, calendarBlock(date, b, loc, "Between shifts"))
+		}
+	}
+
+	booked, err := GetBookedBlocks(db, doctorID, date, nil)
+	if err != nil {
+		return cal, err
+	}
+	rules, err := GetSchedulingRules(db, doctorID)
+	if err != nil {
+		return cal, err
+	}
+	for _, b := range rules.pacingBreaks(booked) {
+		breaks = append(breaks, calendarBlock(date, b, loc, "Pacing break"))
+	}
+	sort.Slice(breaks, func(i, j int) bool { return breaks[i].StartsAt.Before(breaks[j].StartsAt) })
+	cal.Breaks = append(cal.Breaks, breaks...)
+
+	slots, err := GetAvailableSlots(db, doctorID, date, apptType)
+	if err != nil {
+		return cal, err
+	}
+	for _, slot := range slots {
+		if !slot.StartsAt.Before(now) {
+			cal.FreeSlots = append(cal.FreeSlots, slot)
+		}
+	}
+	return cal, nil
+}
+
+// pacingBreaks returns the break due after each run of booked appointments
+// that reaches the consecutive limit.
+func (r SchedulingRules) pacingBreaks(booked []BookedBlock) []TimeBlock {
+	if r.BreakAfterConsecutive <= 0 || r.BreakMinutes <= 0 || len(booked) == 0 {
+		return nil
+	}
+	blocks := make([]TimeBlock, 0, len(booked))
+	for _, b := range booked {
+		blocks = append(blocks, b.TimeBlock)
+	}
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].Start.Before(blocks[j].Start) })
+
+	breakLength := time.Duration(r.BreakMinutes) * time.Minute
+	var breaks []TimeBlock
+	run := 1
+	for i := 1; i <= len(blocks); i++ {
+		if i < len(blocks) && blocks[i].Start.Sub(blocks[i-1].End) < breakLength {
+			run++
+			continue
+		}
+		if run >= r.BreakAfterConsecutive {
+			end := blocks[i-1].End
+			breaks = append(breaks, TimeBlock{Start: end, End: end.Add(breakLength)})
+		}
+		run = 1
+	}
+	return breaks
+}
+
+func calendarBlock(date time.Time, b TimeBlock, loc *time.Location, reason string) CalendarBlock {
+	startsAt := Instant(date, b.Start, loc)
+	block := CalendarBlock{
+		StartTime: b.Start.Format(ClockLayout),
+		EndTime:   b.End.Format(ClockLayout),
+		StartsAt:  startsAt,
+		EndsAt:    startsAt.Add(b.End.Sub(b.Start)),
+		Reason:    reason,
+	}
+	if b.End.Day() != b.Start.Day() {
+		block.EndTime = "24:00"
+	}
+	return block
+}
+
+// LocalizeCalendarDay renders a calendar day's instants in loc.
+func LocalizeCalendarDay(day *CalendarDay, loc *time.Location) {
+	for _, blocks := range [][]CalendarBlock{day.WorkingHours, day.Breaks, day.TimeOff} {
+		for i := range blocks {
+			blocks[i].StartsAt = blocks[i].StartsAt.In(loc)
+			blocks[i].EndsAt = blocks[i].EndsAt.In(loc)
+		}
+	}
+	LocalizeSlots(day.FreeSlots, loc)
+}
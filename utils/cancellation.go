@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/models"
+	"gorm.io/gorm"
+)
+
+// Rebooking suggestions look this far ahead and offer at most this many
+// slots.
+const (
+	rebookingSearchDays = 14
+	maxRebookingSlots   = 5
+)
+
+var cancellationLabels = map[models.CancellationReason]string{
+	models.CancelReasonPatientRequest:    "Cancelled at the patient's request",
+	models.CancelReasonPatientIllness:    "The patient is unwell",
+	models.CancelReasonScheduleConflict:  "Scheduling conflict",
+	models.CancelReasonDoctorUnavailable: "The doctor is unavailable",
+	models.CancelReasonClinicClosure:     "The clinic is closed",
+	models.CancelReasonDuplicateBooking:  "Duplicate booking",
+	models.CancelReasonOther:             "Other",
+}
+
+// CancellationNote describes a cancellation for the status history and the
+// notice sent to the other party.
+func CancellationNote(code models.CancellationReason, note string) string {
+	label, ok := cancellationLabels[code]
+	if !ok {
+		label = string(code)
+	}
+	if note == "" {
+		return label
+	}
+	return label + ": " + note
+}
+
+// IsLateCancellation reports whether cancelling appt at now falls inside the
+// clinic's late-cancellation window.
+func IsLateCancellation(appt models.Appointment, settings models.ClinicSettings, now time.Time) bool {
+	if settings.LateCancelWindowMinutes <= 0 {
+		return false
+	}
+	start := AppointmentStart(appt)
+	window := time.Duration(settings.LateCancelWindowMinutes) * time.Minute
+	return now.Before(start) && start.Sub(now) < window
+}
+
+// RebookingSlots offers the earliest free slots with the same doctor after a
+// cancellation, skipping the cancelled period itself.
+func RebookingSlots(db *gorm.DB, cancelled models.Appointment, now time.Time) ([]Slot, error) {
+	loc, err := DoctorLocation(db, cancelled.DoctorID)
+	if err != nil {
+		return nil, err
+	}
+	start := AppointmentStart(cancelled)
+	end := start
+	if cancelled.EndsAt != nil {
+		end = *cancelled.EndsAt
+	}
+
+	slots := []Slot{}
+	day, _ := WallClock(now, loc)
+	for i := 0; i < rebookingSearchDays && len(slots) < maxRebookingSlots; i++ {
+		available, err := GetAvailableSlots(db, cancelled.DoctorID, day.AddDate(0, 0, i), cancelled.AppointmentType)
+		if err != nil {
+			return nil, err
+		}
+		for _, slot := range available {
+			if !slot.StartsAt.After(now) || (slot.StartsAt.Before(end) && slot.EndsAt.After(start)) {
+				continue
+			}
+			slots = append(slots, slot)
+			if len(slots) == maxRebookingSlots {
+				break
+			}
+		}
+	}
+	return slots, nil
+}
+
+// DoctorInitiated reports whether a cancellation came from the doctor's side,
+// either made by the doctor or for their unavailability.
+func DoctorInitiated(actor StatusActor, code models.CancellationReason) bool {
+	return actor.Role == models.RoleDoctor || code == models.CancelReasonDoctorUnavailable
+}
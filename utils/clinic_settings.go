@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// MaxReminderOffsets caps how many reminders one appointment can get.
+const MaxReminderOffsets = 5
+
+// DefaultReminderOffsets are used when the clinic has not configured any,
+// one day and one hour before the appointment.
+var DefaultReminderOffsets = pq.Int64Array{24 * 60, 60}
+
+// GetClinicSettings returns the clinic's settings, falling back to defaults
+// when the row is missing.
+func GetClinicSettings(db *gorm.DB) (models.ClinicSettings, error) {
+	var settings models.ClinicSettings
+	err := db.First(&settings, "id = ?", 1).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.ClinicSettings{
+			ID:                      1,
+			ReminderOffsetsMinutes:  DefaultReminderOffsets,
+			NoShowGraceMinutes:      30,
+			NoShowWindowDays:        90,
+			NoShowAction:            models.NoShowRequireApproval,
+			LateCancelWindowMinutes: 24 * 60,
+			LateCancelAction:        models.LateCancelFlag,
+			TimeZone:                "UTC",
+		}, nil
+	}
+	return settings, err
+}
+
+// NormalizeReminderOffsets validates reminder offsets and returns them
+// de-duplicated, largest first. An empty list turns reminders off.
+func NormalizeReminderOffsets(offsets []int64) (pq.Int64Array, error) {
+	if len(offsets) > MaxReminderOffsets {
+		return nil, fmt.Errorf("at most %d reminder offsets are allowed", MaxReminderOffsets)
+	}
+	out := pq.Int64Array{}
+	for _, minutes := range offsets {
+		if minutes < 5 || minutes > 14*24*60 {
+			return nil, errors.New("reminder offsets must be between 5 minutes and 14 days")
+		}
+		if !slices.Contains(out, minutes) {
+			out = append(out, minutes)
+		}
+	}
+	slices.Sort(out)
+	slices.Reverse(out)
+	return out, nil
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Contact struct {
+	Email     string
+	FirstName string
+	LastName  string
+}
+
+// GetPatientContact returns the login email and name behind a patient profile.
+func GetPatientContact(db *gorm.DB, patientID uuid.UUID) (Contact, error) {
+	var contact Contact
+	err := db.Table("patients").
+		Select("auth.email, users.first_name, users.last_name").
+		Joins("JOIN users ON users.id = patients.user_id").
+		Joins("JOIN auth ON auth.id = users.auth_id").
+		Where("patients.id = ?", patientID).
+		Scan(&contact).Error
+	return contact, err
+}
+
+// GetDoctorContact returns the login email and name behind a doctor profile.
+func GetDoctorContact(db *gorm.DB, doctorID uuid.UUID) (Contact, error) {
+	var contact Contact
+	err := db.Table("doctors").
+		Select("auth.email, users.first_name, users.last_name").
+		Joins("JOIN users ON users.id = doctors.user_id").
+		Joins("JOIN auth ON auth.id = users.auth_id").
+		Where("doctors.id = ?", doctorID).
+		Scan(&contact).Error
+	return contact, err
+}
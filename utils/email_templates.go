@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"html"
 	"strings"
 	"time"
 )
@@ -122,3 +123,132 @@ func GetPasswordResetSuccessTemplate() EmailTemplate {
 		Body:    body,
 	}
 }
+
+func GetWaitlistOfferTemplate(date, startTime, token string, duration time.Duration) EmailTemplate {
+	subject := GetEnvWithDefault(
+		"EMAIL_WAITLIST_OFFER_SUBJECT",
+		"An appointment slot has opened up",
+	)
+
+	bodyTemplate := GetEnvWithDefault(
+		"EMAIL_WAITLIST_OFFER_BODY",
+		"A slot on <strong>{{.DATE}}</strong> at <strong>{{.TIME}}</strong> is available.<br>Claim it with code <strong>{{.TOKEN}}</strong> within {{.DURATION}}.",
+	)
+
+	body := strings.ReplaceAll(bodyTemplate, "{{.DATE}}", date)
+	body = strings.ReplaceAll(body, "{{.TIME}}", startTime)
+	body = strings.ReplaceAll(body, "{{.TOKEN}}", token)
+	body = strings.ReplaceAll(body, "{{.DURATION}}", formatDuration(duration))
+
+	return EmailTemplate{
+		Subject: subject,
+		Body:    body,
+	}
+}
+
+func GetBookingOutcomeTemplate(booked bool, date, startTime, reason string) EmailTemplate {
+	if booked {
+		subject := GetEnvWithDefault("EMAIL_BOOKING_CONFIRMED_SUBJECT", "Your appointment is booked")
+		body := GetEnvWithDefault(
+			"EMAIL_BOOKING_CONFIRMED_BODY",
+			"Your appointment on <strong>{{.DATE}}</strong> at <strong>{{.TIME}}</strong> has been booked.",
+		)
+		body = strings.ReplaceAll(body, "{{.DATE}}", date)
+		body = strings.ReplaceAll(body, "{{.TIME}}", startTime)
+		return EmailTemplate{Subject: subject, Body: body}
+	}
+
+	subject := GetEnvWithDefault("EMAIL_BOOKING_REJECTED_SUBJECT", "We could not book your appointment")
+	body := GetEnvWithDefault(
+		"EMAIL_BOOKING_REJECTED_BODY",
+		"Your request for <strong>{{.DATE}}</strong> at <strong>{{.TIME}}</strong> could not be booked: {{.REASON}}.",
+	)
+	body = strings.ReplaceAll(body, "{{.DATE}}", date)
+	body = strings.ReplaceAll(body, "{{.TIME}}", startTime)
+	body = strings.ReplaceAll(body, "{{.REASON}}", html.EscapeString(reason))
+	return EmailTemplate{Subject: subject, Body: body}
+}
+
+// GetCalendarInviteTemplate accompanies an .ics attachment. Cancellations use
+// their own wording; every other change is sent as an updated invite.
+func GetCalendarInviteTemplate(cancelled bool, date, startTime string) EmailTemplate {
+	if cancelled {
+		subject := GetEnvWithDefault("EMAIL_CALENDAR_CANCEL_SUBJECT", "Appointment cancelled")
+		body := GetEnvWithDefault(
+			"EMAIL_CALENDAR_CANCEL_BODY",
+			"The appointment on <strong>{{.DATE}}</strong> at <strong>{{.TIME}}</strong> has been cancelled. The attached file removes it from your calendar.",
+		)
+		body = strings.ReplaceAll(body, "{{.DATE}}", date)
+		body = strings.ReplaceAll(body, "{{.TIME}}", startTime)
+		return EmailTemplate{Subject: subject, Body: body}
+	}
+
+	subject := GetEnvWithDefault("EMAIL_CALENDAR_INVITE_SUBJECT", "Your appointment details")
+	body := GetEnvWithDefault(
+		"EMAIL_CALENDAR_INVITE_BODY",
+		"Your appointment is on <strong>{{.DATE}}</strong> at <strong>{{.TIME}}</strong>. Open the attached file to add it to your calendar.",
+	)
+	body = strings.ReplaceAll(body, "{{.DATE}}", date)
+	body = strings.ReplaceAll(body, "{{.TIME}}", startTime)
+	return EmailTemplate{Subject: subject, Body: body}
+}
+
+func GetAppointmentReminderTemplate(date, startTime, timeZone string) EmailTemplate {
+	subject := GetEnvWithDefault("EMAIL_APPOINTMENT_REMINDER_SUBJECT", "Appointment reminder")
+	body := GetEnvWithDefault(
+		"EMAIL_APPOINTMENT_REMINDER_BODY",
+		"This is a reminder of your appointment on <strong>{{.DATE}}</strong> at <strong>{{.TIME}}</strong> ({{.TIMEZONE}}).",
+	)
+	body = strings.ReplaceAll(body, "{{.DATE}}", date)
+	body = strings.ReplaceAll(body, "{{.TIME}}", startTime)
+	body = strings.ReplaceAll(body, "{{.TIMEZONE}}", timeZone)
+	return EmailTemplate{Subject: subject, Body: body}
+}
+
+func GetEmergencyDelayTemplate(date, startTime string, delay time.Duration) EmailTemplate {
+	subject := GetEnvWithDefault("EMAIL_EMERGENCY_DELAY_SUBJECT", "Your appointment may start late")
+	body := GetEnvWithDefault(
+		"EMAIL_EMERGENCY_DELAY_BODY",
+		"The doctor has been called to an emergency. Your appointment on <strong>{{.DATE}}</strong> at <strong>{{.TIME}}</strong> is expected to start about {{.DELAY}} late. We are sorry for the wait.",
+	)
+	body = strings.ReplaceAll(body, "{{.DATE}}", date)
+	body = strings.ReplaceAll(body, "{{.TIME}}", startTime)
+	body = strings.ReplaceAll(body, "{{.DELAY}}", formatDuration(delay))
+	return EmailTemplate{Subject: subject, Body: body}
+}
+
+// GetCancellationNoticeTemplate tells the other party an appointment was
+// cancelled and why. The reason is written by the cancelling party and is
+// escaped. Suggested slots, if any, are listed for rebooking.
+func GetCancellationNoticeTemplate(date, startTime, reason string, suggestions []string) EmailTemplate {
+	subject := GetEnvWithDefault("EMAIL_CANCELLATION_NOTICE_SUBJECT", "An appointment was cancelled")
+	body := GetEnvWithDefault(
+		"EMAIL_CANCELLATION_NOTICE_BODY",
+		"The appointment on <strong>{{.DATE}}</strong> at <strong>{{.TIME}}</strong> has been cancelled. Reason: {{.REASON}}.",
+	)
+	body = strings.ReplaceAll(body, "{{.DATE}}", date)
+	body = strings.ReplaceAll(body, "{{.TIME}}", startTime)
+	body = strings.ReplaceAll(body, "{{.REASON}}", reason)
+	if len(suggestions) > 0 {
+		body += "<br><br>These times are free with the same doctor:<ul><li>" + strings.Join(suggestions, "</li><li>") + "</li></ul>"
+	}
+	return EmailTemplate{Subject: subject, Body: body}
+}
+
+// GetRescheduleProposalTemplate tells a patient their appointment was moved
+// because the doctor is unavailable, and how to accept or decline the new
+// time.
+func GetRescheduleProposalTemplate(oldDate, oldTime, newDate, newTime, doctorName, proposalID string) EmailTemplate {
+	subject := GetEnvWithDefault("EMAIL_RESCHEDULE_PROPOSAL_SUBJECT", "Your appointment has been moved")
+	body := GetEnvWithDefault(
+		"EMAIL_RESCHEDULE_PROPOSAL_BODY",
+		"Your doctor is unavailable for your appointment on <strong>{{.OLD_DATE}}</strong> at <strong>{{.OLD_TIME}}</strong>. It has been moved to <strong>{{.DATE}}</strong> at <strong>{{.TIME}}</strong> with {{.DOCTOR}}.<br>Please accept or decline the new time using reference <strong>{{.PROPOSAL}}</strong>. Declining cancels the appointment.",
+	)
+	body = strings.ReplaceAll(body, "{{.OLD_DATE}}", oldDate)
+	body = strings.ReplaceAll(body, "{{.OLD_TIME}}", oldTime)
+	body = strings.ReplaceAll(body, "{{.DATE}}", newDate)
+	body = strings.ReplaceAll(body, "{{.TIME}}", newTime)
+	body = strings.ReplaceAll(body, "{{.DOCTOR}}", doctorName)
+	body = strings.ReplaceAll(body, "{{.PROPOSAL}}", proposalID)
+	return EmailTemplate{Subject: subject, Body: body}
+}
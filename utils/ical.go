@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/models"
+)
+
+// iCalendar methods used for email attachments (RFC 5546). Feeds carry no
+// method.
+const (
+	ICalMethodRequest = "REQUEST"
+	ICalMethodCancel  = "CANCEL"
+)
+
+const icalTimeLayout = "20060102T150405Z"
+
+type CalendarAttendee struct {
+	Name  string
+	Email string
+}
+
+// CalendarEvent is a VEVENT. UID stays fixed for an appointment and Sequence
+// grows with each change so clients replace their copy.
+type CalendarEvent struct {
+	UID         string
+	Sequence    int
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Location    string
+	Status      string
+	Organizer   string
+	Attendees   []CalendarAttendee
+}
+
+// AppointmentEvent maps an appointment to a calendar event. The appointment
+// must have been loaded from the database so its instants are set.
+func AppointmentEvent(appt models.Appointment) CalendarEvent {
+	start := AppointmentStart(appt)
+	end := start
+	if appt.EndsAt != nil {
+		end = *appt.EndsAt
+	}
+
+	status := "TENTATIVE"
+	switch appt.Status {
+	case models.AppointmentStatusConfirmed, models.AppointmentStatusCheckedIn, models.AppointmentStatusInConsultation, models.AppointmentStatusCompleted:
+		status = "CONFIRMED"
+	case models.AppointmentStatusCancelled:
+		status = "CANCELLED"
+	}
+
+	description := "Mode: " + appt.Mode
+	if appt.Notes != "" {
+		description += "\n" + appt.Notes
+	}
+
+	kind := strings.ToLower(string(appt.AppointmentType))
+	if kind == "" {
+		kind = "medical"
+	}
+
+	return CalendarEvent{
+		UID:         appt.ID.String() + "@medistream",
+		Sequence:    appt.Sequence,
+		Start:       start,
+		End:         end,
+		Summary:     "Medistream " + kind + " appointment",
+		Description: description,
+		Location:    appt.Location,
+		Status:      status,
+	}
+}
+
+// BuildICS renders events as an iCalendar object. An empty method produces a
+// subscription feed.
+func BuildICS(method string, events ...CalendarEvent) []byte {
+	var b strings.Builder
+	line := func(s string) {
+		b.WriteString(foldICalLine(s))
+		b.WriteString("\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//Medistream//Appointments//EN")
+	line("CALSCALE:GREGORIAN")
+	if method != "" {
+		line("METHOD:" + method)
+	}
+
+	stamp := time.Now().UTC().Format(icalTimeLayout)
+	for _, ev := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + ev.UID)
+		line(fmt.Sprintf("SEQUENCE:%d", ev.Sequence))
+		line("DTSTAMP:" + stamp)
+		line("DTSTART:" + ev.Start.UTC().Format(icalTimeLayout))
+		line("DTEND:" + ev.End.UTC().Format(icalTimeLayout))
+		line("SUMMARY:" + escapeICalText(ev.Summary))
+		if ev.Description != "" {
+			line("DESCRIPTION:" + escapeICalText(ev.Description))
+		}
+		if ev.Location != "" {
+			line("LOCATION:" + escapeICalText(ev.Location))
+		}
+		if method == ICalMethodCancel {
+			line("STATUS:CANCELLED")
+		} else {
+			line("STATUS:" + ev.Status)
+		}
+		if ev.Organizer != "" {
+			line("ORGANIZER;CN=Medistream:mailto:" + ev.Organizer)
+		}
+		for _, a := range ev.Attendees {
+			line(fmt.Sprintf("ATTENDEE;CN=%s;ROLE=REQ-PARTICIPANT:mailto:%s", escapeICalParam(a.Name), a.Email))
+		}
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+	return []byte(b.String())
+}
+
+func escapeICalText(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return r.Replace(s)
+}
+
+func escapeICalParam(s string) string {
+	if strings.ContainsAny(s, ";:,") {
+		return `"` + strings.ReplaceAll(s, `"`, "") + `"`
+	}
+	return s
+}
+
+// foldICalLine splits content lines longer than 75 octets, as RFC 5545
+// requires, without breaking UTF-8 sequences.
+func foldICalLine(s string) string {
+	const limit = 75
+	if len(s) <= limit {
+		return s
+	}
+	var b strings.Builder
+	width := 0
+	for _, r := range s {
+		size := len(string(r))
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
@@ -1,8 +1,12 @@
 package utils
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"os"
 )
 
 func GenerateOTP(length int) (string, error) {
@@ -24,3 +28,12 @@ func GenerateOTP(length int) (string, error) {
 
 	return string(otp), nil
 }
+
+// HashOTP returns the keyed digest stored in place of a one-time code. The
+// email is part of the digest, so a code is only good for its own account.
+// The key is OTP_SECRET, falling back to JWT_SECRET.
+func HashOTP(email, otp string) string {
+	mac := hmac.New(sha256.New, []byte(GetEnvWithDefault("OTP_SECRET", os.Getenv("JWT_SECRET"))))
+	mac.Write([]byte(email + ":" + otp))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/models"
+)
+
+// MaxSeriesOccurrences caps how many appointments a single series may create.
+const MaxSeriesOccurrences = 52
+
+// RecurrenceRule is the subset of RFC 5545 RRULE supported for appointment
+// series: FREQ (WEEKLY or MONTHLY), INTERVAL, COUNT and UNTIL.
+type RecurrenceRule struct {
+	Frequency models.RecurrenceFrequency
+	Interval  int
+	Count     int
+	Until     *time.Time
+}
+
+// ParseRRule parses strings such as "FREQ=WEEKLY;INTERVAL=2;COUNT=6" or
+// "FREQ=MONTHLY;UNTIL=20261231".
+func ParseRRule(value string) (RecurrenceRule, error) {
+	rule := RecurrenceRule{Interval: 1}
+	for _, part := range strings.Split(strings.TrimPrefix(value, "RRULE:"), ";") {
+		if part == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return rule, fmt.Errorf("invalid rrule part %q", part)
+		}
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Frequency = models.RecurrenceFrequency(strings.ToUpper(val))
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return rule, errors.New("INTERVAL must be a number")
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return rule, errors.New("COUNT must be a number")
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := time.Parse("20060102", val[:min(len(val), 8)])
+			if err != nil {
+				return rule, errors.New("UNTIL must be a date in YYYYMMDD form")
+			}
+			rule.Until = &until
+		default:
+			return rule, fmt.Errorf("unsupported rrule part %q", key)
+		}
+	}
+	return rule, rule.Validate()
+}
+
+func (r RecurrenceRule) Validate() error {
+	if r.Frequency != models.RecurrenceWeekly && r.Frequency != models.RecurrenceMonthly {
+		return errors.New("frequency must be WEEKLY or MONTHLY")
+	}
+	if r.Interval < 1 {
+		return errors.New("interval must be at least 1")
+	}
+	if r.Count < 0 || r.Count > MaxSeriesOccurrences {
+		return fmt.Errorf("count must be between 1 and %d", MaxSeriesOccurrences)
+	}
+	if r.Count == 0 && r.Until == nil {
+		return errors.New("either count or until is required")
+	}
+	return nil
+}
+
+// Expand returns the occurrence dates of the rule starting at start. Monthly
+// rules skip months that do not have the start day, as RRULE does.
+func (r RecurrenceRule) Expand(start time.Time) ([]time.Time, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	var dates []time.Time
+	for i := 0; len(dates) < MaxSeriesOccurrences && i < 12*MaxSeriesOccurrences; i++ {
+		var next time.Time
+		switch r.Frequency {
+		case models.RecurrenceWeekly:
+			next = start.AddDate(0, 0, 7*r.Interval*i)
+		case models.RecurrenceMonthly:
+			next = start.AddDate(0, r.Interval*i, 0)
+			if next.Day() != start.Day() {
+				continue
+			}
+		}
+
+		if r.Until != nil && next.After(endOfDay(*r.Until)) {
+			break
+		}
+		dates = append(dates, next)
+		if r.Count > 0 && len(dates) == r.Count {
+			break
+		}
+	}
+	return dates, nil
+}
+
+func endOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, t.Location())
+}
@@ -19,3 +19,34 @@ func DoctorHasAccessToPatient(doctorID, patientID uuid.UUID, c *gin.Context) (bo
 	}
 	return count > 0, nil
 }
+
+// GetDoctorByUserID resolves the doctor profile behind an authenticated user.
+func GetDoctorByUserID(userID uuid.UUID, c *gin.Context) (models.Doctor, error) {
+	var doctor models.Doctor
+	err := config.DB.WithContext(c).Where("user_id = ?", userID).First(&doctor).Error
+	return doctor, err
+}
+
+// GetPatientByUserID resolves the patient profile behind an authenticated user.
+func GetPatientByUserID(userID uuid.UUID, c *gin.Context) (models.Patient, error) {
+	var patient models.Patient
+	err := config.DB.WithContext(c).Where("user_id = ?", userID).First(&patient).Error
+	return patient, err
+}
+
+// GetReceptionistByUserID resolves the receptionist profile behind an
+// authenticated user.
+func GetReceptionistByUserID(userID uuid.UUID, c *gin.Context) (models.Receptionist, error) {
+	var receptionist models.Receptionist
+	err := config.DB.WithContext(c).Where("user_id = ?", userID).First(&receptionist).Error
+	return receptionist, err
+}
+
+// ReceptionistCovers reports whether an appointment is at the receptionist's
+// location.
+func ReceptionistCovers(receptionist models.Receptionist, appt models.Appointment) bool {
+	if receptionist.LocationID == nil {
+		return true
+	}
+	return appt.LocationID != nil && *appt.LocationID == *receptionist.LocationID
+}
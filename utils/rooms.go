@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"errors"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ModeInPerson is the appointment mode that needs a room.
+const ModeInPerson = "In-Person"
+
+// WorkingLocation returns the location of the doctor's working-hours block
+// that covers the given period, or nil when none is tied to a location.
+func WorkingLocation(db *gorm.DB, doctorID uuid.UUID, date time.Time, period TimeBlock) (*uuid.UUID, error) {
+	var rows []models.Doctor_working_hours
+	err := db.Where("doctor_id = ? AND weekday = ? AND is_active = true AND location_id IS NOT NULL", doctorID, int(date.Weekday())).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		start, err1 := ParseClock(row.StartTime)
+		end, err2 := ParseClock(row.EndTime)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		if (TimeBlock{Start: start, End: end}).Contains(period) {
+			return row.LocationID, nil
+		}
+	}
+	return nil, nil
+}
+
+// AssignRoom places an appointment at the location the doctor works at during
+// its time and, for in-person visits, picks the first room there that is
+// free for the whole period. Appointments outside any location-bound working
+// hours are left as they are. When every room is taken a schedule conflict
+// is returned; the room exclusion constraint settles concurrent picks.
+func AssignRoom(db *gorm.DB, appt *models.Appointment) error {
+	start, err1 := ParseClock(appt.StartTime)
+	end, err2 := ParseClock(appt.EndTime)
+	if err1 != nil || err2 != nil {
+		return conflict("invalid time format, expected HH:MM")
+	}
+
+	locationID, err := WorkingLocation(db, appt.DoctorID, appt.AppointmentDate, TimeBlock{Start: start, End: end})
+	if err != nil {
+		return err
+	}
+	appt.LocationID, appt.RoomID, appt.Clinic, appt.Room = locationID, nil, nil, nil
+	if locationID == nil {
+		return nil
+	}
+
+	var location models.Location
+	if err := db.First(&location, "id = ?", *locationID).Error; err != nil {
+		return err
+	}
+	appt.Location = location.Name
+	if appt.Mode != ModeInPerson {
+		return nil
+	}
+
+	loc, err := DoctorLocation(db, appt.DoctorID)
+	if err != nil {
+		return err
+	}
+	startsAt := Instant(appt.AppointmentDate, start, loc)
+	endsAt := Instant(appt.AppointmentDate, end, loc)
+
+	var room models.Room
+	err = db.Where("location_id = ? AND is_active = true", location.ID).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM appointments a
+			WHERE a.room_id = rooms.id AND a.status <> ? AND a.id <> ?
+				AND a.time_range && tstzrange(?, ?, '[)')
+		)`, models.AppointmentStatusCancelled, appt.ID, startsAt, endsAt).
+		Order("name asc").
+		First(&room).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return conflict("no room is free at " + location.Name + " for this time")
+	}
+	if err != nil {
+		return err
+	}
+
+	appt.RoomID = &room.ID
+	appt.Location = location.Name + ", " + room.Name
+	return nil
+}
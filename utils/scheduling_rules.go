@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BookedBlock is an appointment already holding time on a doctor's day.
+type BookedBlock struct {
+	TimeBlock
+	Type models.ApptType
+}
+
+// SchedulingRules are a doctor's pacing rules together with their daily caps
+// per appointment type.
+type SchedulingRules struct {
+	models.DoctorSchedulingRules
+	DailyCaps map[models.ApptType]int `json:"daily_caps"`
+}
+
+// Active reports whether any rule is switched on.
+func (r SchedulingRules) Active() bool {
+	return r.BufferMinutes > 0 || (r.BreakAfterConsecutive > 0 && r.BreakMinutes > 0) || len(r.DailyCaps) > 0
+}
+
+// GetSchedulingRules loads a doctor's rules. Doctors without any get rules
+// that are all switched off.
+func GetSchedulingRules(db *gorm.DB, doctorID uuid.UUID) (SchedulingRules, error) {
+	rules := SchedulingRules{
+		DoctorSchedulingRules: models.DoctorSchedulingRules{DoctorID: doctorID},
+		DailyCaps:             map[models.ApptType]int{},
+	}
+	err := db.First(&rules.DoctorSchedulingRules, "doctor_id = ?", doctorID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return rules, err
+	}
+
+	var caps []models.DoctorDailyCap
+	if err := db.Where("doctor_id = ?", doctorID).Find(&caps).Error; err != nil {
+		return rules, err
+	}
+	for _, c := range caps {
+		rules.DailyCaps[c.AppointmentType] = c.MaxPerDay
+	}
+	return rules, nil
+}
+
+// Violation explains why booking candidate would break the rules given the
+// doctor's other bookings that day, or returns "" when it would not.
+func (r SchedulingRules) Violation(candidate TimeBlock, apptType models.ApptType, booked []BookedBlock) string {
+	if limit, ok := r.DailyCaps[apptType]; ok {
+		count := 0
+		for _, b := range booked {
+			if b.Type == apptType {
+				count++
+			}
+		}
+		if count >= limit {
+			return fmt.Sprintf("the doctor's daily limit of %d %s appointments is reached", limit, strings.ToLower(string(apptType)))
+		}
+	}
+
+	if r.BufferMinutes > 0 {
+		buffer := time.Duration(r.BufferMinutes) * time.Minute
+		padded := TimeBlock{Start: candidate.Start.Add(-buffer), End: candidate.End.Add(buffer)}
+		for _, b := range booked {
+			if padded.Overlaps(b.TimeBlock) {
+				return fmt.Sprintf("the doctor needs %d minutes between appointments", r.BufferMinutes)
+			}
+		}
+	}
+
+	if r.BreakAfterConsecutive > 0 && r.BreakMinutes > 0 && r.consecutiveRun(candidate, booked) > r.BreakAfterConsecutive {
+		return fmt.Sprintf("the doctor needs a %d-minute break after %d consecutive appointments", r.BreakMinutes, r.BreakAfterConsecutive)
+	}
+	return ""
+}
+
+// consecutiveRun counts the appointments in the run candidate would join.
+// Two appointments are consecutive when less than a full break separates them.
+func (r SchedulingRules) consecutiveRun(candidate TimeBlock, booked []BookedBlock) int {
+	blocks := make([]TimeBlock, 0, len(booked)+1)
+	for _, b := range booked {
+		blocks = append(blocks, b.TimeBlock)
+	}
+	blocks = append(blocks, candidate)
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].Start.Before(blocks[j].Start) })
+
+	breakLength := time.Duration(r.BreakMinutes) * time.Minute
+	runStart, containsCandidate := 0, false
+	for i, b := range blocks {
+		if i > 0 && b.Start.Sub(blocks[i-1].End) >= breakLength {
+			if containsCandidate {
+				return i - runStart
+			}
+			runStart = i
+		}
+		if b == candidate {
+			containsCandidate = true
+		}
+	}
+	return len(blocks) - runStart
+}
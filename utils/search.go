@@ -0,0 +1,9 @@
+package utils
+
+import "strings"
+
+// ContainsPattern builds an ILIKE pattern matching s anywhere, with the LIKE
+// wildcards in s escaped so they match literally.
+func ContainsPattern(s string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s) + "%"
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"errors"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DefaultSlotDurations is used when a doctor has not configured a slot length
+// for an appointment type.
+var DefaultSlotDurations = map[models.ApptType]time.Duration{
+	models.ApptTypeConsultation: 45 * time.Minute,
+	models.ApptTypeFollowup:     15 * time.Minute,
+	models.ApptTypeCheckup:      30 * time.Minute,
+	models.ApptTypeEmergency:    30 * time.Minute,
+}
+
+func IsValidApptType(apptType models.ApptType) bool {
+	_, ok := DefaultSlotDurations[apptType]
+	return ok
+}
+
+// GetSlotDuration returns the slot length a doctor uses for an appointment type.
+func GetSlotDuration(db *gorm.DB, doctorID uuid.UUID, apptType models.ApptType) (time.Duration, error) {
+	fallback, ok := DefaultSlotDurations[apptType]
+	if !ok {
+		return 0, errors.New("unknown appointment type")
+	}
+
+	var override models.DoctorSlotDuration
+	err := db.Where("doctor_id = ? AND appointment_type = ?", doctorID, apptType).First(&override).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fallback, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(override.DurationMinutes) * time.Minute, nil
+}
+
+// GetSlotDurations returns the effective slot length, in minutes, of every
+// appointment type for a doctor.
+func GetSlotDurations(db *gorm.DB, doctorID uuid.UUID) (map[models.ApptType]int, error) {
+	durations := make(map[models.ApptType]int, len(DefaultSlotDurations))
+	for apptType, d := range DefaultSlotDurations {
+		durations[apptType] = int(d.Minutes())
+	}
+
+	var overrides []models.DoctorSlotDuration
+	if err := db.Where("doctor_id = ?", doctorID).Find(&overrides).Error; err != nil {
+		return nil, err
+	}
+	for _, o := range overrides {
+		durations[o.AppointmentType] = o.DurationMinutes
+	}
+	return durations, nil
+}
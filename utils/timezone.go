@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"errors"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TimeZoneHeader lets clients choose the zone times are rendered in when the
+// tz query parameter is not given.
+const TimeZoneHeader = "X-Time-Zone"
+
+// ClinicTimeZone is the IANA zone new doctors start in, the clinic's own.
+func ClinicTimeZone(db *gorm.DB) string {
+	loc, err := ClinicLocation(db)
+	if err != nil {
+		Log.Warnf("ClinicTimeZone: falling back to UTC - %v", err)
+		return "UTC"
+	}
+	return loc.String()
+}
+
+// LoadTimeZone validates an IANA zone name. Empty and "Local" are rejected so
+// results never depend on the server's zone.
+func LoadTimeZone(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, errors.New("time zone must be an IANA name such as Europe/Berlin")
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, errors.New("unknown time zone " + name)
+	}
+	return loc, nil
+}
+
+// DoctorLocation returns the zone a doctor's schedule is kept in.
+func DoctorLocation(db *gorm.DB, doctorID uuid.UUID) (*time.Location, error) {
+	var doctor models.Doctor
+	if err := db.Select("time_zone").First(&doctor, "id = ?", doctorID).Error; err != nil {
+		return nil, err
+	}
+	if doctor.TimeZone == "" {
+		return time.UTC, nil
+	}
+	return LoadTimeZone(doctor.TimeZone)
+}
+
+// ClinicLocation returns the zone of the clinic's calendar, which holidays
+// and clinic-wide views are kept in.
+func ClinicLocation(db *gorm.DB) (*time.Location, error) {
+	settings, err := GetClinicSettings(db)
+	if err != nil {
+		return nil, err
+	}
+	if settings.TimeZone == "" {
+		return time.UTC, nil
+	}
+	return LoadTimeZone(settings.TimeZone)
+}
+
+// SiteLocation returns the zone of a clinic location, the clinic's unless the
+// location keeps its own.
+func SiteLocation(db *gorm.DB, locationID uuid.UUID) (*time.Location, error) {
+	var location models.Location
+	if err := db.Select("time_zone").First(&location, "id = ?", locationID).Error; err != nil {
+		return nil, err
+	}
+	if location.TimeZone == "" {
+		return ClinicLocation(db)
+	}
+	return LoadTimeZone(location.TimeZone)
+}
+
+// CallerLocation returns the zone the caller wants times rendered in, taken
+// from the tz query parameter or the X-Time-Zone header. It defaults to UTC.
+func CallerLocation(c *gin.Context) (*time.Location, error) {
+	return CallerLocationOr(c, time.UTC)
+}
+
+// CallerLocationOr is CallerLocation with fallback used when the caller names
+// no zone.
+func CallerLocationOr(c *gin.Context, fallback *time.Location) (*time.Location, error) {
+	name := c.Query("tz")
+	if name == "" {
+		name = c.GetHeader(TimeZoneHeader)
+	}
+	if name == "" {
+		return fallback, nil
+	}
+	return LoadTimeZone(name)
+}
+
+// CalendarDate strips the clock and zone from t, keeping its calendar date.
+// Appointment dates are stored this way, as the date on the doctor's wall.
+func CalendarDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// WallClock converts an absolute instant to the calendar date and HH:MM time
+// it falls on in loc.
+func WallClock(instant time.Time, loc *time.Location) (time.Time, string) {
+	local := instant.In(loc)
+	return CalendarDate(local), local.Format(ClockLayout)
+}
+
+// Instant is the inverse of WallClock.
+func Instant(date time.Time, clock time.Time, loc *time.Location) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+}
+
+// ResolveWallClock turns booking input into the doctor's calendar date and
+// wall-clock times. Absolute instants take precedence; otherwise date, start
+// and end are taken to be on the doctor's wall clock already.
+func ResolveWallClock(db *gorm.DB, doctorID uuid.UUID, date time.Time, start, end string, startsAt, endsAt *time.Time) (time.Time, string, string, error) {
+	if startsAt == nil {
+		return CalendarDate(date), start, end, nil
+	}
+	if endsAt == nil || !endsAt.After(*startsAt) {
+		return time.Time{}, "", "", errors.New("endsAt must be after startsAt")
+	}
+	loc, err := DoctorLocation(db, doctorID)
+	if err != nil {
+		return time.Time{}, "", "", errors.New("doctor not found")
+	}
+	day, startClock := WallClock(*startsAt, loc)
+	endDay, endClock := WallClock(*endsAt, loc)
+	if !endDay.Equal(day) {
+		return time.Time{}, "", "", errors.New("an appointment cannot span midnight in the doctor's time zone")
+	}
+	return day, startClock, endClock, nil
+}
+
+// LocalizeAppointment renders an appointment's instants in loc.
+func LocalizeAppointment(appt *models.Appointment, loc *time.Location) {
+	if appt.StartsAt != nil {
+		t := appt.StartsAt.In(loc)
+		appt.StartsAt = &t
+	}
+	if appt.EndsAt != nil {
+		t := appt.EndsAt.In(loc)
+		appt.EndsAt = &t
+	}
+}
+
+func LocalizeAppointments(appts []models.Appointment, loc *time.Location) {
+	for i := range appts {
+		LocalizeAppointment(&appts[i], loc)
+	}
+}
+
+func LocalizeSlots(slots []Slot, loc *time.Location) {
+	for i := range slots {
+		slots[i].StartsAt = slots[i].StartsAt.In(loc)
+		slots[i].EndsAt = slots[i].EndsAt.In(loc)
+	}
+}
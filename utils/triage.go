@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/models"
+	"gorm.io/gorm"
+)
+
+// DelayedAppointment is a visit an emergency pushes back.
+type DelayedAppointment struct {
+	Appointment models.Appointment
+	Delay       time.Duration
+}
+
+// EmergencyDelays works out which of the doctor's visits an emergency pushes
+// back and by how much. Visits starting from the emergency onwards are taken
+// to follow it back to back until one starts after the backlog has cleared.
+// Visits already under way and other emergencies are left alone. The
+// emergency must have been loaded from the database so its instants are set.
+func EmergencyDelays(db *gorm.DB, emergency models.Appointment) ([]DelayedAppointment, error) {
+	if emergency.StartsAt == nil || emergency.EndsAt == nil {
+		return nil, nil
+	}
+
+	var following []models.Appointment
+	err := db.Where("doctor_id = ? AND DATE(appointment_date) = DATE(?) AND id <> ?", emergency.DoctorID, emergency.AppointmentDate, emergency.ID).
+		Where("status IN ? AND appointment_type <> ? AND starts_at >= ?",
+			[]models.AppointmentStatus{models.AppointmentStatusPending, models.AppointmentStatusConfirmed, models.AppointmentStatusCheckedIn},
+			models.ApptTypeEmergency, *emergency.StartsAt).
+		Order("starts_at asc").
+		Find(&following).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var delayed []DelayedAppointment
+	cursor := *emergency.EndsAt
+	for _, appt := range following {
+		if appt.StartsAt == nil || appt.EndsAt == nil || !appt.StartsAt.Before(cursor) {
+			break
+		}
+		delayed = append(delayed, DelayedAppointment{Appointment: appt, Delay: cursor.Sub(*appt.StartsAt)})
+		cursor = cursor.Add(appt.EndsAt.Sub(*appt.StartsAt))
+	}
+	return delayed, nil
+}
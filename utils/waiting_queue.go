@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"math"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// consultationSample is how many of a doctor's recent consultations the wait
+// estimate averages over.
+const consultationSample = 20
+
+// QueueEntry is a checked-in patient in a doctor's waiting room.
+type QueueEntry struct {
+	AppointmentID        uuid.UUID  `json:"appointment_id"`
+	PatientID            uuid.UUID  `json:"patient_id"`
+	PatientName          string     `json:"patient_name"`
+	Status               string     `json:"status"`
+	TriageLevel          *int       `json:"triage_level,omitempty"`
+	StartsAt             *time.Time `json:"starts_at"`
+	EndsAt               *time.Time `json:"ends_at"`
+	CheckedInAt          *time.Time `json:"checked_in_at"`
+	StartedAt            *time.Time `json:"consultation_started_at,omitempty"`
+	Position             int        `json:"position"`
+	EstimatedWaitMinutes int        `json:"estimated_wait_minutes"`
+}
+
+// DoctorQueue is a doctor's waiting room for one day: the patient being seen
+// and the patients waiting, in the order they will be called.
+type DoctorQueue struct {
+	DoctorID                   uuid.UUID    `json:"doctor_id"`
+	Date                       string       `json:"date"`
+	Current                    *QueueEntry  `json:"current,omitempty"`
+	Waiting                    []QueueEntry `json:"waiting"`
+	AverageConsultationMinutes *float64     `json:"average_consultation_minutes,omitempty"`
+}
+
+// QueueDate is the doctor's calendar day at now.
+func QueueDate(db *gorm.DB, doctorID uuid.UUID, now time.Time) (time.Time, error) {
+	loc, err := DoctorLocation(db, doctorID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	day, _ := WallClock(now, loc)
+	return day, nil
+}
+
+// AverageConsultation returns how long the doctor's recent consultations
+// took. ok is false until the doctor has finished one through the queue.
+func AverageConsultation(db *gorm.DB, doctorID uuid.UUID) (avg time.Duration, ok bool, err error) {
+	var seconds *float64
+	err = db.Raw(`
+		SELECT AVG(EXTRACT(EPOCH FROM consultation_ended_at - consultation_started_at))
+		FROM (
+			SELECT consultation_started_at, consultation_ended_at FROM appointments
+			WHERE doctor_id = ? AND consultation_started_at IS NOT NULL AND consultation_ended_at IS NOT NULL
+			ORDER BY consultation_ended_at DESC
+			LIMIT ?
+		) recent`, doctorID, consultationSample).Scan(&seconds).Error
+	if err != nil || seconds == nil {
+		return 0, false, err
+	}
+	return time.Duration(*seconds * float64(time.Second)), true, nil
+}
+
+// GetDoctorQueue loads a doctor's waiting room and estimates each patient's
+// wait. Emergencies come first, most urgent first, then everyone else by
+// booked time. Visits are expected to take the doctor's recent average, or
+// their booked length before there is history.
+func GetDoctorQueue(db *gorm.DB, doctorID uuid.UUID, date time.Time, now time.Time) (DoctorQueue, error) {
+	queue := DoctorQueue{DoctorID: doctorID, Date: date.Format("2006-01-02"), Waiting: []QueueEntry{}}
+
+	var entries []QueueEntry
+	err := db.Table("appointments").
+		Select(`appointments.id AS appointment_id, appointments.patient_id, appointments.status,
+			appointments.triage_level, appointments.starts_at, appointments.ends_at, appointments.checked_in_at,
+			appointments.consultation_started_at AS started_at,
+			users.first_name || ' ' || users.last_name AS patient_name`).
+		Joins("JOIN patients ON patients.id = appointments.patient_id").
+		Joins("JOIN users ON users.id = patients.user_id").
+		Where("appointments.doctor_id = ? AND DATE(appointments.appointment_date) = ? AND appointments.status IN ?",
+			doctorID, queue.Date, []models.AppointmentStatus{models.AppointmentStatusCheckedIn, models.AppointmentStatusInConsultation}).
+		Order("appointments.triage_level asc nulls last, appointments.starts_at asc, appointments.checked_in_at asc").
+		Scan(&entries).Error
+	if err != nil {
+		return queue, err
+	}
+
+	avg, known, err := AverageConsultation(db, doctorID)
+	if err != nil {
+		return queue, err
+	}
+	if known {
+		minutes := math.Round(avg.Minutes()*10) / 10
+		queue.AverageConsultationMinutes = &minutes
+	}
+	expected := func(e QueueEntry) time.Duration {
+		if known || e.StartsAt == nil || e.EndsAt == nil {
+			return avg
+		}
+		return e.EndsAt.Sub(*e.StartsAt)
+	}
+
+	// The consultation in progress holds up everyone waiting.
+	var ahead time.Duration
+	for _, e := range entries {
+		if e.Status == string(models.AppointmentStatusInConsultation) {
+			current := e
+			queue.Current = &current
+			if e.StartedAt != nil {
+				ahead = max(expected(e)-now.Sub(*e.StartedAt), 0)
+			}
+		}
+	}
+	for _, e := range entries {
+		if e.Status != string(models.AppointmentStatusCheckedIn) {
+			continue
+		}
+		e.Position = len(queue.Waiting) + 1
+		e.EstimatedWaitMinutes = int(math.Ceil(ahead.Minutes()))
+		queue.Waiting = append(queue.Waiting, e)
+		ahead += expected(e)
+	}
+	return queue, nil
+}
+
+// NextInQueue locks the first patient waiting for the doctor on date, in the
+// order GetDoctorQueue lists them.
+func NextInQueue(tx *gorm.DB, doctorID uuid.UUID, date time.Time) (models.Appointment, error) {
+	var appt models.Appointment
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("doctor_id = ? AND DATE(appointment_date) = ? AND status = ?", doctorID, date.Format("2006-01-02"), models.AppointmentStatusCheckedIn).
+		Order("triage_level asc nulls last, starts_at asc, checked_in_at asc").
+		First(&appt).Error
+	return appt, err
+}
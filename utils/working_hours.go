@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const ClockLayout = "15:04"
+
+// ParseClock parses a wall-clock time as sent by clients ("15:04") or as
+// returned by Postgres TIME columns ("15:04:05").
+func ParseClock(value string) (time.Time, error) {
+	if t, err := time.Parse(ClockLayout, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("15:04:05", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return t, nil
+}
+
+type TimeBlock struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (b TimeBlock) Overlaps(other TimeBlock) bool {
+	return b.Start.Before(other.End) && other.Start.Before(b.End)
+}
+
+func (b TimeBlock) Contains(other TimeBlock) bool {
+	return !other.Start.Before(b.Start) && !other.End.After(b.End)
+}
+
+// GetWorkingHourBlocks returns every active working-hour block of a doctor for
+// the given weekday, ordered by start time.
+func GetWorkingHourBlocks(db *gorm.DB, doctorID uuid.UUID, weekday time.Weekday) ([]TimeBlock, error) {
+	var rows []models.Doctor_working_hours
+	err := db.Where("doctor_id = ? AND weekday = ? AND is_active = true", doctorID, int(weekday)).
+		Order("start_time asc").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := make([]TimeBlock, 0, len(rows))
+	for _, row := range rows {
+		start, err1 := ParseClock(row.StartTime)
+		end, err2 := ParseClock(row.EndTime)
+		if err1 != nil || err2 != nil {
+			Log.Warnf("GetWorkingHourBlocks: skipping malformed block %s", row.ID)
+			continue
+		}
+		blocks = append(blocks, TimeBlock{Start: start, End: end})
+	}
+	return blocks, nil
+}
+
+// ValidateWorkingHours checks that a block is well formed and does not overlap
+// another active block of the same doctor on the same weekday.
+func ValidateWorkingHours(db *gorm.DB, doctorID uuid.UUID, weekday int, start string, end string, excludeID *uuid.UUID) error {
+	if weekday < 0 || weekday > 6 {
+		return errors.New("weekday must be between 0 (Sunday) and 6 (Saturday)")
+	}
+
+	startTime, err1 := ParseClock(start)
+	endTime, err2 := ParseClock(end)
+	if err1 != nil || err2 != nil {
+		return errors.New("invalid time format, expected HH:MM")
+	}
+	if !endTime.After(startTime) {
+		return errors.New("end time must be after start time")
+	}
+
+	query := db.Model(&models.Doctor_working_hours{}).
+		Where("doctor_id = ? AND weekday = ? AND is_active = true", doctorID, weekday).
+		Where("start_time < ? AND end_time > ?", endTime.Format(ClockLayout), startTime.Format(ClockLayout))
+	if excludeID != nil {
+		query = query.Where("id != ?", *excludeID)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("working hours overlap an existing block for this weekday")
+	}
+	return nil
+}
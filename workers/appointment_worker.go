@@ -6,18 +6,16 @@ import (
 	"fmt"
 
 	"github.com/AltSumpreme/Medistream.git/controllers/appointments"
-	"github.com/AltSumpreme/Medistream.git/handlers"
+	"github.com/AltSumpreme/Medistream.git/queue"
 	"github.com/hibiken/asynq"
 )
 
 func ProcessCreateAppointmentTask(ctx context.Context, t *asynq.Task) error {
-	var input handlers.AppointmentInput
+	var p queue.BookingRequestPayload
 
-	if err := json.Unmarshal(t.Payload(), &input); err != nil {
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
 		return fmt.Errorf("failed to decode appointment task: %v", err)
 	}
 
-	appointments.CreateAppointment(input)
-
-	return nil
+	return appointments.CreateAppointment(ctx, p.RequestID)
 }
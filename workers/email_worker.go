@@ -14,6 +14,7 @@ func RegisterEmailHandlers(mux *asynq.ServeMux) {
 	mux.HandleFunc(string(queue.JobTypeWelcomeEmail), handleWelcomeEmail)
 	mux.HandleFunc(string(queue.JobOTPEmail), handleOTPEmail)
 	mux.HandleFunc(string(queue.JobTypeResetPassword), handleresetPasswordEmail)
+	mux.HandleFunc(string(queue.JobTypeNotificationEmail), handleNotificationEmail)
 
 }
 
@@ -56,3 +57,19 @@ func handleresetPasswordEmail(ctx context.Context, task *asynq.Task) error {
 	)
 
 }
+
+func handleNotificationEmail(ctx context.Context, task *asynq.Task) error {
+	var p queue.EmailPayload
+	if err := json.Unmarshal(task.Payload(), &p); err != nil {
+		return err
+	}
+	if len(p.Attachments) == 0 {
+		return mail.SendEmail(p.To, p.Subject, p.Body)
+	}
+
+	attachments := make([]mail.Attachment, 0, len(p.Attachments))
+	for _, a := range p.Attachments {
+		attachments = append(attachments, mail.Attachment{Filename: a.Filename, ContentType: a.ContentType, Content: a.Content})
+	}
+	return mail.SendEmailWithAttachments(p.To, p.Subject, p.Body, attachments)
+}
@@ -0,0 +1,37 @@
+package workers
+
+import (
+	"context"
+	"time"
+
+	"github.com/AltSumpreme/Medistream.git/config"
+	"github.com/AltSumpreme/Medistream.git/controllers/appointments"
+	"github.com/AltSumpreme/Medistream.git/queue"
+	"github.com/AltSumpreme/Medistream.git/utils"
+	"github.com/hibiken/asynq"
+)
+
+func RegisterNoShowHandlers(mux *asynq.ServeMux) {
+	mux.HandleFunc(string(queue.JobTypeNoShowSweep), processNoShowSweepTask)
+}
+
+// NewNoShowScheduler enqueues the no-show sweep on the NO_SHOW_SWEEP_SCHEDULE
+// cron spec, every 15 minutes by default. Uniqueness keeps several worker
+// replicas from queueing overlapping sweeps.
+func NewNoShowScheduler() (*asynq.Scheduler, error) {
+	scheduler := asynq.NewScheduler(config.QueueRedisOpt, nil)
+	task, err := queue.NewTask(queue.JobTypeNoShowSweep, struct{}{})
+	if err != nil {
+		return nil, err
+	}
+	spec := utils.GetEnvWithDefault("NO_SHOW_SWEEP_SCHEDULE", "@every 15m")
+	if _, err := scheduler.Register(spec, task, asynq.Queue("appointments"), asynq.Unique(10*time.Minute), asynq.MaxRetry(1)); err != nil {
+		return nil, err
+	}
+	return scheduler, nil
+}
+
+func processNoShowSweepTask(ctx context.Context, t *asynq.Task) error {
+	_, err := appointments.MarkNoShows(ctx)
+	return err
+}
@@ -0,0 +1,23 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/AltSumpreme/Medistream.git/controllers/appointments"
+	"github.com/AltSumpreme/Medistream.git/queue"
+	"github.com/hibiken/asynq"
+)
+
+func RegisterReminderHandlers(mux *asynq.ServeMux) {
+	mux.HandleFunc(string(queue.JobTypeAppointmentReminder), processAppointmentReminderTask)
+}
+
+func processAppointmentReminderTask(ctx context.Context, t *asynq.Task) error {
+	var p queue.ReminderPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("failed to decode reminder task: %v", err)
+	}
+	return appointments.SendAppointmentReminder(ctx, p)
+}
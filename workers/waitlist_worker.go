@@ -0,0 +1,32 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/AltSumpreme/Medistream.git/controllers/waitlist"
+	"github.com/AltSumpreme/Medistream.git/queue"
+	"github.com/hibiken/asynq"
+)
+
+func RegisterWaitlistHandlers(mux *asynq.ServeMux) {
+	mux.HandleFunc(string(queue.JobTypeWaitlistBackfill), processWaitlistBackfillTask)
+	mux.HandleFunc(string(queue.JobTypeWaitlistOfferExpire), processWaitlistOfferExpireTask)
+}
+
+func processWaitlistBackfillTask(ctx context.Context, t *asynq.Task) error {
+	var p queue.FreedSlotPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("failed to decode waitlist backfill task: %v", err)
+	}
+	return waitlist.BackfillSlot(ctx, p)
+}
+
+func processWaitlistOfferExpireTask(ctx context.Context, t *asynq.Task) error {
+	var p queue.WaitlistOfferPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("failed to decode waitlist expiry task: %v", err)
+	}
+	return waitlist.ExpireWaitlistOffer(ctx, p)
+}